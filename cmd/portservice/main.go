@@ -2,25 +2,110 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
+	"fmt"
+	"io/fs"
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
+	"portservice/internal/adapters/primary/rest"
+	"portservice/internal/adapters/secondary/file"
+	"portservice/internal/adapters/secondary/kv"
 	"portservice/internal/adapters/secondary/memory"
+	"portservice/internal/adapters/secondary/postgres"
+	"portservice/internal/adapters/secondary/sqlite"
 	"portservice/internal/core"
+	"portservice/internal/domain"
+	"portservice/internal/ports/in"
 	"portservice/internal/ports/out"
 )
 
+// stdioPath is the -file and -export value selecting standard input and
+// standard output
+const stdioPath = "-"
+
+// defaultImportPath is the ports file imported on startup when -file is not
+// given and ports are stored in memory. Other repositories keep their ports
+// across restarts, so re-importing it on every boot would overwrite changes
+// made through the API.
+const defaultImportPath = "ports.json"
+
 func main() {
 	// Parse command line flags
-	filePath := flag.String("file", "ports.json", "Path to the ports JSON file")
+	filePath := flag.String("file", "", "Path to the ports file to import on startup (\"-\" for stdin, empty to skip; defaults to "+defaultImportPath+" with memory storage)")
+	mode := flag.String("mode", string(domain.ImportLenient), "Import validation mode: lenient, strict or threshold")
+	maxRejected := flag.Float64("max-rejected", 0, "Percentage of invalid records tolerated in threshold mode")
+	workers := flag.Int("workers", 0, "Number of import workers converting records (0 for one per CPU)")
+	batchSize := flag.Int("batch-size", domain.DefaultImportBatchSize, "Number of ports written to the repository per batch")
+	format := flag.String("format", string(domain.ImportJSON), "Import file format: json, csv, ndjson, geojson or unlocode")
+	csvColumns := flag.String("csv-columns", "", "CSV header for each port field, as field=column pairs separated by commas")
+	csvDelimiter := flag.String("csv-delimiter", ",", "CSV field delimiter")
+	csvListSeparator := flag.String("csv-list-separator", domain.DefaultCSVListSeparator, "Separator between the values of multi-value CSV columns such as unlocs")
+	startLine := flag.Int("start-line", 0, "Line of an NDJSON file to resume the import at")
+	exportPath := flag.String("export", "", "Path to export the ports to after the import instead of serving the API (\"-\" for stdout)")
+	exportFormat := flag.String("export-format", string(domain.ExportNDJSON), "Export file format: ndjson, json, geojson or csv")
+	exportFields := flag.String("export-fields", "", "Comma-separated fields a CSV export writes, in column order (empty for all)")
+	exportBOM := flag.Bool("export-bom", false, "Start CSV exports with a UTF-8 byte order mark for spreadsheets")
 	flag.Parse()
+	if storage := os.Getenv("STORAGE"); !flagSet("file") && (storage == "" || storage == "memory") {
+		*filePath = defaultImportPath
+	}
+
+	importMode, err := domain.ParseImportMode(*mode)
+	if err != nil {
+		log.Fatalf("Invalid -mode flag: %v", err)
+	}
+	importFormat, err := domain.ParseImportFormat(*format)
+	if err != nil {
+		log.Fatalf("Invalid -format flag: %v", err)
+	}
+	columns, err := domain.ParseCSVColumns(*csvColumns)
+	if err != nil {
+		log.Fatalf("Invalid -csv-columns flag: %v", err)
+	}
+	delimiter := []rune(*csvDelimiter)
+	if len(delimiter) != 1 {
+		log.Fatalf("Invalid -csv-delimiter flag: %q is not a single character", *csvDelimiter)
+	}
+	csvOpts := domain.CSVOptions{
+		Columns:       columns,
+		Comma:         delimiter[0],
+		ListSeparator: *csvListSeparator,
+	}
+	importOpts := domain.ImportOptions{
+		Mode:               importMode,
+		Format:             importFormat,
+		MaxRejectedPercent: *maxRejected,
+		Workers:            *workers,
+		BatchSize:          *batchSize,
+		CSV:                csvOpts,
+		StartLine:          *startLine,
+	}
+	if err := importOpts.Validate(); err != nil {
+		log.Fatalf("Invalid import flags: %v", err)
+	}
+	exportFmt, err := domain.ParseExportFormat(*exportFormat)
+	if err != nil {
+		log.Fatalf("Invalid -export-format flag: %v", err)
+	}
+	if csvOpts.Fields, err = domain.ParseCSVFields(*exportFields); err != nil {
+		log.Fatalf("Invalid -export-fields flag: %v", err)
+	}
+	csvOpts.BOM = *exportBOM
+	exportOpts := domain.ExportOptions{Format: exportFmt, CSV: csvOpts}
+
+	cfg := loadConfig()
 
 	// Create repository and service
-	repo := memory.NewPortRepository()
+	repo, err := newRepository()
+	if err != nil {
+		log.Fatalf("Failed to open repository: %v", err)
+	}
 	service := core.NewPortService(repo)
 
 	// Create context that will be canceled on interrupt
@@ -31,58 +116,287 @@ func main() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
+	err = importFile(ctx, cancel, sigChan, service, repo, *filePath, importOpts)
+	if err == nil && *exportPath != "" {
+		err = exportFile(ctx, service, *exportPath, exportOpts)
+	} else if err == nil {
+		err = serve(sigChan, service, cfg)
+	}
+
+	// Close repository
+	closeCtx, closeCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer closeCancel()
+
+	if closeErr := repo.Close(closeCtx); closeErr != nil {
+		log.Printf("Error closing repository: %v", closeErr)
+	}
+
+	if err != nil && err != context.Canceled {
+		os.Exit(1)
+	}
+	log.Println("Service stopped")
+}
+
+// importFile loads the initial ports file, returning context.Canceled if a
+// shutdown signal arrives before processing completes
+func importFile(ctx context.Context, cancel context.CancelFunc, sigChan <-chan os.Signal, service in.PortService, repo out.PortRepository, filePath string, opts domain.ImportOptions) error {
+	if filePath == "" {
+		return nil
+	}
+
 	// Start processing in a goroutine
-	errChan := make(chan error, 1)
+	type importResult struct {
+		report *domain.ImportReport
+		err    error
+	}
+	resultChan := make(chan importResult, 1)
 	startTime := time.Now()
 	go func() {
-		errChan <- service.ProcessPortsFile(ctx, *filePath)
+		var report *domain.ImportReport
+		var err error
+		if filePath == stdioPath {
+			report, err = service.ProcessPorts(ctx, os.Stdin, opts)
+		} else {
+			report, err = service.ProcessPortsFile(ctx, filePath, opts)
+		}
+		resultChan <- importResult{report: report, err: err}
 	}()
 
 	// Wait for either completion or interruption
-	var err error
 	select {
-	case err = <-errChan:
+	case result := <-resultChan:
+		err := result.err
+		logImportReport(result.report)
 		if err == context.Canceled {
 			log.Println("Processing was canceled")
-		} else if err != nil {
+			return err
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Ports file %s not found, starting with an empty repository", filePath)
+			return nil
+		}
+		if err != nil {
 			log.Printf("Error processing file: %v", err)
-		} else {
-			duration := time.Since(startTime)
-			log.Printf("File processing completed successfully in %v", duration)
-
-			// Display repository statistics
-			if stats, ok := repo.(interface{ GetStatistics() out.RepositoryStats }); ok {
-				repoStats := stats.GetStatistics()
-				log.Printf("Repository statistics:")
-				log.Printf("  - Total ports processed: %d", repoStats.TotalPorts)
-				log.Printf("  - Total updates: %d", repoStats.TotalUpdates)
-				log.Printf("  - Last update: %v", repoStats.LastUpdate)
-				log.Printf("  - Average processing speed: %.2f ports/second",
-					float64(repoStats.TotalPorts)/duration.Seconds())
-			}
+			return err
 		}
+
+		duration := time.Since(startTime)
+		log.Printf("File processing completed successfully in %v", duration)
+
+		// Display repository statistics
+		repoStats := repo.GetStatistics()
+		log.Printf("Repository statistics:")
+		log.Printf("  - Total ports processed: %d", repoStats.TotalPorts)
+		log.Printf("  - Total updates: %d", repoStats.TotalUpdates)
+		log.Printf("  - Last update: %v", repoStats.LastUpdate)
+		log.Printf("  - Average processing speed: %.2f ports/second",
+			float64(repoStats.TotalPorts)/duration.Seconds())
+		return nil
 	case sig := <-sigChan:
 		log.Printf("Received signal %v, shutting down...", sig)
 		cancel()
 		// Wait for processing to stop or timeout
 		select {
-		case err = <-errChan:
-			log.Printf("Processing stopped with error: %v", err)
+		case result := <-resultChan:
+			log.Printf("Processing stopped with error: %v", result.err)
 		case <-time.After(5 * time.Second):
 			log.Println("Processing shutdown timed out")
 		}
+		return context.Canceled
 	}
+}
 
-	// Close repository
-	closeCtx, closeCancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer closeCancel()
+// logImportReport logs the outcome of an import, listing every rejected record
+func logImportReport(report *domain.ImportReport) {
+	if report == nil {
+		return
+	}
+	log.Printf("Import report: %d created, %d updated, %d unchanged, %d rejected",
+		report.Created, report.Updated, report.Unchanged, report.Rejected)
+	for _, rejection := range report.Rejections {
+		location := fmt.Sprintf("offset %d", rejection.Offset)
+		if rejection.Line > 0 {
+			location = fmt.Sprintf("line %d", rejection.Line)
+		}
+		log.Printf("  - Rejected port %q at %s: %s (field %q)",
+			rejection.PortID, location, rejection.Reason, rejection.Field)
+	}
+}
 
-	if closeErr := repo.(interface{ Close(context.Context) error }).Close(closeCtx); closeErr != nil {
-		log.Printf("Error closing repository: %v", closeErr)
+// exportFile writes the ports to filePath, or to standard output for "-"
+func exportFile(ctx context.Context, service in.PortService, filePath string, opts domain.ExportOptions) error {
+	if filePath == stdioPath {
+		count, err := service.ExportPorts(ctx, os.Stdout, opts)
+		if err != nil {
+			log.Printf("Error exporting ports: %v", err)
+			return err
+		}
+		log.Printf("Exported %d ports to standard output", count)
+		return nil
 	}
 
-	if err != nil && err != context.Canceled {
-		os.Exit(1)
+	file, err := os.Create(filePath)
+	if err != nil {
+		log.Printf("Error creating export file: %v", err)
+		return err
 	}
-	log.Println("Service stopped")
+	count, err := service.ExportPorts(ctx, file, opts)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Printf("Error exporting ports: %v", err)
+		return err
+	}
+	log.Printf("Exported %d ports to %s", count, filePath)
+	return nil
+}
+
+// serve runs the HTTP server until a shutdown signal arrives
+func serve(sigChan <-chan os.Signal, service in.PortService, cfg rest.Config) error {
+	server := rest.NewServer(cfg, service)
+
+	errChan := make(chan error, 1)
+	go func() {
+		log.Printf("HTTP server listening on %s", server.Addr())
+		errChan <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errChan:
+		if err != nil {
+			log.Printf("HTTP server error: %v", err)
+		}
+		return err
+	case sig := <-sigChan:
+		log.Printf("Received signal %v, shutting down HTTP server...", sig)
+		if err := server.Shutdown(context.Background()); err != nil {
+			log.Printf("HTTP server shutdown error: %v", err)
+			return err
+		}
+		return <-errChan
+	}
+}
+
+// flagSet reports whether the named flag was given on the command line
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// loadConfig reads the HTTP server configuration from environment variables
+func loadConfig() rest.Config {
+	cfg := rest.DefaultConfig()
+	if port := os.Getenv("PORT"); port != "" {
+		cfg.Port = port
+	}
+	cfg.ReadTimeout = envSeconds("READ_TIMEOUT", cfg.ReadTimeout)
+	cfg.WriteTimeout = envSeconds("WRITE_TIMEOUT", cfg.WriteTimeout)
+	cfg.ShutdownTimeout = envSeconds("SHUTDOWN_TIMEOUT", cfg.ShutdownTimeout)
+	return cfg
+}
+
+// newRepository creates the repository chosen by the STORAGE environment
+// variable
+func newRepository() (out.PortRepository, error) {
+	softDelete, _ := strconv.ParseBool(os.Getenv("SOFT_DELETE"))
+	switch storage := os.Getenv("STORAGE"); storage {
+	case "", "memory":
+		var opts []memory.Option
+		if softDelete {
+			opts = append(opts, memory.WithSoftDelete())
+		}
+		return memory.NewPortRepository(opts...), nil
+	case "file":
+		policy, err := file.ParseSyncPolicy(os.Getenv("SYNC_POLICY"))
+		if err != nil {
+			return nil, err
+		}
+		opts := []file.Option{
+			file.WithSyncPolicy(policy),
+			file.WithSyncInterval(envSeconds("SYNC_INTERVAL", file.DefaultSyncInterval)),
+		}
+		if softDelete {
+			opts = append(opts, file.WithSoftDelete())
+		}
+		dir := os.Getenv("DATA_DIR")
+		if dir == "" {
+			dir = "data"
+		}
+		log.Printf("Storing ports in %s", dir)
+		return file.NewPortRepository(dir, opts...)
+	case "sqlite":
+		var opts []sqlite.Option
+		if softDelete {
+			opts = append(opts, sqlite.WithSoftDelete())
+		}
+		path := os.Getenv("SQLITE_PATH")
+		if path == "" {
+			path = "ports.db"
+		}
+		log.Printf("Storing ports in %s", path)
+		return sqlite.NewPortRepository(path, opts...)
+	case "kv":
+		var opts []kv.Option
+		if softDelete {
+			opts = append(opts, kv.WithSoftDelete())
+		}
+		path := os.Getenv("KV_PATH")
+		if path == "" {
+			path = "ports.kv"
+		}
+		log.Printf("Storing ports in %s", path)
+		return kv.NewPortRepository(path, opts...)
+	case "postgres":
+		dsn := os.Getenv("POSTGRES_DSN")
+		if dsn == "" {
+			return nil, errors.New("POSTGRES_DSN is required for postgres storage")
+		}
+		opts := []postgres.Option{
+			postgres.WithMaxConns(int32(envInt("POSTGRES_MAX_CONNS", 0))),
+			postgres.WithMinConns(int32(envInt("POSTGRES_MIN_CONNS", 0))),
+			postgres.WithMaxConnLifetime(envSeconds("POSTGRES_MAX_CONN_LIFETIME", 0)),
+			postgres.WithMaxConnIdleTime(envSeconds("POSTGRES_MAX_CONN_IDLE_TIME", 0)),
+		}
+		if softDelete {
+			opts = append(opts, postgres.WithSoftDelete())
+		}
+		log.Printf("Storing ports in PostgreSQL")
+		return postgres.NewPortRepository(dsn, opts...)
+	default:
+		return nil, fmt.Errorf("unknown STORAGE %q", storage)
+	}
+}
+
+// envSeconds reads a duration in seconds from an environment variable
+func envSeconds(key string, defaultValue time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue
+	}
+	seconds, err := strconv.Atoi(raw)
+	if err != nil || seconds < 0 {
+		log.Printf("Warning: invalid %s value %q, using default %v", key, raw, defaultValue)
+		return defaultValue
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// envInt reads a non-negative integer from an environment variable
+func envInt(key string, defaultValue int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		log.Printf("Warning: invalid %s value %q, using default %v", key, raw, defaultValue)
+		return defaultValue
+	}
+	return value
 }
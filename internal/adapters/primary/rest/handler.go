@@ -0,0 +1,676 @@
+package rest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"portservice/internal/domain"
+	"portservice/internal/ports/in"
+)
+
+// uploadFormField is the multipart form field carrying the ports file
+const uploadFormField = "file"
+
+// maxPortBodyBytes limits the size of a single port JSON request body
+const maxPortBodyBytes = 1 << 20
+
+// maxBulkBodyBytes limits the size of a bulk port JSON request body
+const maxBulkBodyBytes = 32 << 20
+
+// maxBulkPorts limits the number of ports in a single bulk request
+const maxBulkPorts = 10000
+
+// maxPolygonBodyBytes limits the size of a GeoJSON polygon request body
+const maxPolygonBodyBytes = 10 << 20
+
+// Handler implements the REST API on top of in.PortService
+type Handler struct {
+	service in.PortService
+	mux     *http.ServeMux
+}
+
+// NewHandler creates a new Handler and registers its routes
+func NewHandler(service in.PortService) *Handler {
+	h := &Handler{
+		service: service,
+		mux:     http.NewServeMux(),
+	}
+	h.mux.HandleFunc("GET /api/v1/ports", h.listPorts)
+	h.mux.HandleFunc("POST /api/v1/ports", h.createOrUpdatePort)
+	h.mux.HandleFunc("POST /api/v1/ports/bulk", h.createOrUpdatePorts)
+	h.mux.HandleFunc("GET /api/v1/ports/nearest", h.findNearest)
+	h.mux.HandleFunc("GET /api/v1/ports/within-radius", h.findWithinRadius)
+	h.mux.HandleFunc("GET /api/v1/ports/search", h.searchPorts)
+	h.mux.HandleFunc("GET /api/v1/ports/within-bbox", h.findInBoundingBox)
+	h.mux.HandleFunc("POST /api/v1/ports/within-polygon", h.findInPolygon)
+	h.mux.HandleFunc("GET /api/v1/ports/{id}", h.getPort)
+	h.mux.HandleFunc("DELETE /api/v1/ports/{id}", h.deletePort)
+	h.mux.HandleFunc("POST /api/v1/ports/file", h.processPortsFile)
+	h.mux.HandleFunc("GET /api/v1/ports/export", h.exportPorts)
+	return h
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// listPorts handles GET /api/v1/ports
+func (h *Handler) listPorts(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseInt(r, "limit")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	filter, err := parsePortFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := h.service.ListPorts(r.Context(), filter, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// distanceResponse is the JSON body returned by spatial queries
+type distanceResponse struct {
+	Results []domain.PortDistance `json:"results"`
+}
+
+// findNearest handles GET /api/v1/ports/nearest
+func (h *Handler) findNearest(w http.ResponseWriter, r *http.Request) {
+	center, err := parseCoordinate(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	k, err := parseInt(r, "k")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if k == 0 {
+		k = 1
+	}
+
+	results, err := h.service.FindNearest(r.Context(), center, k)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, distanceResponse{Results: results})
+}
+
+// findWithinRadius handles GET /api/v1/ports/within-radius
+func (h *Handler) findWithinRadius(w http.ResponseWriter, r *http.Request) {
+	center, err := parseCoordinate(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	radiusKm, err := parseFloat(r, "radius_km")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	results, err := h.service.FindWithinRadius(r.Context(), center, radiusKm)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, distanceResponse{Results: results})
+}
+
+// searchResponse is the JSON body returned by text search
+type searchResponse struct {
+	Results []domain.SearchResult `json:"results"`
+}
+
+// searchPorts handles GET /api/v1/ports/search
+func (h *Handler) searchPorts(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseInt(r, "limit")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	results, err := h.service.SearchPorts(r.Context(), r.URL.Query().Get("q"), limit)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, searchResponse{Results: results})
+}
+
+// portsResponse is the JSON body returned by unpaginated area queries
+type portsResponse struct {
+	Ports []*domain.Port `json:"ports"`
+}
+
+// findInBoundingBox handles GET /api/v1/ports/within-bbox
+func (h *Handler) findInBoundingBox(w http.ResponseWriter, r *http.Request) {
+	box, err := parseBoundingBox(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ports, err := h.service.FindInBoundingBox(r.Context(), box)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, portsResponse{Ports: ports})
+}
+
+// findInPolygon handles POST /api/v1/ports/within-polygon
+func (h *Handler) findInPolygon(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPolygonBodyBytes))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	polygon, err := domain.ParseGeoJSONPolygon(body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ports, err := h.service.FindInPolygon(r.Context(), polygon)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, portsResponse{Ports: ports})
+}
+
+// createOrUpdatePort handles POST /api/v1/ports
+func (h *Handler) createOrUpdatePort(w http.ResponseWriter, r *http.Request) {
+	var port domain.Port
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPortBodyBytes))
+	if err := decoder.Decode(&port); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	if err := h.service.CreateOrUpdatePort(r.Context(), &port); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, &port)
+}
+
+// bulkItemResponse reports the outcome of one port of a bulk request
+type bulkItemResponse struct {
+	// Index is the position of the port in the request array
+	Index int    `json:"index"`
+	ID    string `json:"id"`
+	Error string `json:"error,omitempty"`
+}
+
+// bulkResponse is the JSON body returned for bulk writes
+type bulkResponse struct {
+	Saved   int                `json:"saved"`
+	Failed  int                `json:"failed"`
+	Results []bulkItemResponse `json:"results"`
+}
+
+// createOrUpdatePorts handles POST /api/v1/ports/bulk
+func (h *Handler) createOrUpdatePorts(w http.ResponseWriter, r *http.Request) {
+	var items []json.RawMessage
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBulkBodyBytes))
+	if err := decoder.Decode(&items); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	if len(items) > maxBulkPorts {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("too many ports: %d, at most %d allowed", len(items), maxBulkPorts))
+		return
+	}
+
+	// Items that don't decode are reported alongside the service results
+	// rather than failing the whole request
+	ports := make([]*domain.Port, 0, len(items))
+	decodeErrs := make([]error, len(items))
+	for i, item := range items {
+		var port domain.Port
+		if err := json.Unmarshal(item, &port); err != nil {
+			decodeErrs[i] = fmt.Errorf("%w: %w", domain.ErrInvalidPort, err)
+			continue
+		}
+		ports = append(ports, &port)
+	}
+
+	result, err := h.service.CreateOrUpdatePorts(r.Context(), ports)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	resp := bulkResponse{Results: make([]bulkItemResponse, len(items))}
+	next := 0
+	for i, item := range items {
+		resp.Results[i].Index = i
+		var itemErr error
+		if decodeErrs[i] != nil {
+			resp.Results[i].ID = rawPortID(item)
+			itemErr = decodeErrs[i]
+		} else {
+			resp.Results[i].ID = result.Items[next].ID
+			itemErr = result.Items[next].Err
+			next++
+		}
+		if itemErr != nil {
+			resp.Results[i].Error = itemErr.Error()
+			resp.Failed++
+		} else {
+			resp.Saved++
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// rawPortID extracts the id of a bulk item that failed to decode as a port,
+// returning "" when the item has none. IDs of the wrong type are returned as
+// their JSON text.
+func rawPortID(item json.RawMessage) string {
+	var probe struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if json.Unmarshal(item, &probe) != nil || len(probe.ID) == 0 || string(probe.ID) == "null" {
+		return ""
+	}
+	var id string
+	if json.Unmarshal(probe.ID, &id) == nil {
+		return id
+	}
+	return string(probe.ID)
+}
+
+// getPort handles GET /api/v1/ports/{id}
+func (h *Handler) getPort(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	includeDeleted, err := parseBool(r, "include_deleted")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var port *domain.Port
+	if includeDeleted {
+		port, err = h.service.GetPortIncludingDeleted(r.Context(), id)
+	} else {
+		port, err = h.service.GetPort(r.Context(), id)
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if port == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("port %s not found", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, port)
+}
+
+// deletePort handles DELETE /api/v1/ports/{id}
+func (h *Handler) deletePort(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeletePort(r.Context(), r.PathValue("id")); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// processPortsFile handles POST /api/v1/ports/file
+func (h *Handler) processPortsFile(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseImportOptions(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Stream the file field straight into the import so large uploads never
+	// sit in memory or on disk
+	part, err := findUploadPart(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	defer part.Close()
+
+	report, err := h.service.ProcessPorts(r.Context(), part, opts)
+	if errors.Is(err, domain.ErrImportRejected) {
+		writeJSON(w, http.StatusUnprocessableEntity, importErrorResponse{Error: err.Error(), Report: report})
+		return
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// exportPorts handles GET /api/v1/ports/export
+func (h *Handler) exportPorts(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseExportOptions(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// writeServiceError replaces the content type if the export fails
+	// before any port is written
+	w.Header().Set("Content-Type", exportContentType(opts.Format))
+	body := &exportWriter{ResponseWriter: w}
+	_, err = h.service.ExportPorts(r.Context(), body, opts)
+	if err != nil && !body.started {
+		writeServiceError(w, err)
+		return
+	}
+	if err != nil {
+		// The status line has gone out with the first ports, so the client
+		// only sees a truncated body
+		log.Printf("Error exporting ports: %v", err)
+	}
+}
+
+// exportContentType returns the media type of an export format
+func exportContentType(format domain.ExportFormat) string {
+	switch format {
+	case domain.ExportNDJSON:
+		return "application/x-ndjson"
+	case domain.ExportGeoJSON:
+		return "application/geo+json"
+	case domain.ExportJSON:
+		return "application/json"
+	case domain.ExportCSV:
+		return "text/csv; charset=utf-8"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// exportWriter streams an export to the response, tracking whether any of
+// it has been written
+type exportWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+// Write implements io.Writer
+func (e *exportWriter) Write(p []byte) (int, error) {
+	e.started = true
+	return e.ResponseWriter.Write(p)
+}
+
+// findUploadPart returns the multipart part carrying the ports file
+func findUploadPart(r *http.Request) (*multipart.Part, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, fmt.Errorf("invalid multipart request: %w", err)
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, fmt.Errorf("missing form field %q", uploadFormField)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read multipart body: %w", err)
+		}
+		if part.FormName() == uploadFormField {
+			return part, nil
+		}
+		part.Close()
+	}
+}
+
+// writeServiceError maps service errors to HTTP status codes
+func writeServiceError(w http.ResponseWriter, err error) {
+	if errors.Is(err, domain.ErrPortNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if isClientError(err) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	log.Printf("Error handling request: %v", err)
+	writeError(w, http.StatusInternalServerError, err)
+}
+
+// isClientError reports whether err was caused by invalid input data
+func isClientError(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var csvErr *csv.ParseError
+	switch {
+	case errors.Is(err, domain.ErrInvalidPort), errors.Is(err, domain.ErrInvalidCursor),
+		errors.Is(err, domain.ErrInvalidQuery), errors.Is(err, domain.ErrInvalidImportOptions),
+		errors.Is(err, domain.ErrInvalidExportOptions):
+		return true
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr), errors.As(err, &csvErr):
+		return true
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return true
+	}
+	return false
+}
+
+// parseBool parses an optional boolean query parameter
+func parseBool(r *http.Request, name string) (bool, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return false, nil
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s parameter: %q", name, raw)
+	}
+	return value, nil
+}
+
+// parseInt parses an optional integer query parameter
+func parseInt(r *http.Request, name string) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return 0, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid %s parameter: %q", name, raw)
+	}
+	return value, nil
+}
+
+// parseFloat parses a required floating point query parameter
+func parseFloat(r *http.Request, name string) (float64, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return 0, fmt.Errorf("missing %s parameter", name)
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s parameter: %q", name, raw)
+	}
+	return value, nil
+}
+
+// parsePortFilter parses the optional filter query parameters shared by
+// port listings and exports
+func parsePortFilter(r *http.Request) (domain.PortFilter, error) {
+	includeDeleted, err := parseBool(r, "include_deleted")
+	if err != nil {
+		return domain.PortFilter{}, err
+	}
+	query := r.URL.Query()
+	return domain.PortFilter{
+		Countries:      query["country"],
+		Provinces:      query["province"],
+		Timezones:      query["timezone"],
+		Codes:          query["code"],
+		Unlocs:         query["unloc"],
+		Regions:        query["region"],
+		IncludeDeleted: includeDeleted,
+	}, nil
+}
+
+// parseExportOptions parses the optional format, filter, fields, columns,
+// list_separator and bom query parameters. Without a format, CSV is chosen
+// if the Accept header lists text/csv.
+func parseExportOptions(r *http.Request) (domain.ExportOptions, error) {
+	filter, err := parsePortFilter(r)
+	if err != nil {
+		return domain.ExportOptions{}, err
+	}
+	query := r.URL.Query()
+	format, err := domain.ParseExportFormat(query.Get("format"))
+	if err != nil {
+		return domain.ExportOptions{}, err
+	}
+	if query.Get("format") == "" && acceptsCSV(r) {
+		format = domain.ExportCSV
+	}
+	fields, err := domain.ParseCSVFields(query.Get("fields"))
+	if err != nil {
+		return domain.ExportOptions{}, err
+	}
+	columns, err := domain.ParseCSVColumns(query.Get("columns"))
+	if err != nil {
+		return domain.ExportOptions{}, err
+	}
+	bom, err := parseBool(r, "bom")
+	if err != nil {
+		return domain.ExportOptions{}, err
+	}
+	opts := domain.ExportOptions{
+		Format: format,
+		Filter: filter,
+		CSV: domain.CSVOptions{
+			Columns:       columns,
+			Fields:        fields,
+			BOM:           bom,
+			ListSeparator: query.Get("list_separator"),
+		},
+	}
+	return opts, opts.Validate()
+}
+
+// acceptsCSV reports whether the Accept header lists text/csv
+func acceptsCSV(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaType := range strings.Split(accept, ",") {
+			if parsed, _, err := mime.ParseMediaType(mediaType); err == nil && parsed == "text/csv" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseImportOptions parses the optional mode, max_rejected_percent, format,
+// columns and start_line query parameters
+func parseImportOptions(r *http.Request) (domain.ImportOptions, error) {
+	mode, err := domain.ParseImportMode(r.URL.Query().Get("mode"))
+	if err != nil {
+		return domain.ImportOptions{}, err
+	}
+	format, err := domain.ParseImportFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		return domain.ImportOptions{}, err
+	}
+	columns, err := domain.ParseCSVColumns(r.URL.Query().Get("columns"))
+	if err != nil {
+		return domain.ImportOptions{}, err
+	}
+	opts := domain.ImportOptions{Mode: mode, Format: format, CSV: domain.CSVOptions{Columns: columns}}
+	if r.URL.Query().Has("max_rejected_percent") {
+		if opts.MaxRejectedPercent, err = parseFloat(r, "max_rejected_percent"); err != nil {
+			return domain.ImportOptions{}, err
+		}
+	}
+	if opts.StartLine, err = parseInt(r, "start_line"); err != nil {
+		return domain.ImportOptions{}, err
+	}
+	return opts, opts.Validate()
+}
+
+// parseCoordinate parses the required lon and lat query parameters
+func parseCoordinate(r *http.Request) (domain.Coordinate, error) {
+	lon, err := parseFloat(r, "lon")
+	if err != nil {
+		return domain.Coordinate{}, err
+	}
+	lat, err := parseFloat(r, "lat")
+	if err != nil {
+		return domain.Coordinate{}, err
+	}
+	return domain.Coordinate{Longitude: lon, Latitude: lat}, nil
+}
+
+// parseBoundingBox parses the required bbox query parameter, given in
+// GeoJSON order as min_lon,min_lat,max_lon,max_lat
+func parseBoundingBox(r *http.Request) (domain.BoundingBox, error) {
+	raw := r.URL.Query().Get("bbox")
+	if raw == "" {
+		return domain.BoundingBox{}, errors.New("missing bbox parameter")
+	}
+	parts := strings.Split(raw, ",")
+	if len(parts) != 4 {
+		return domain.BoundingBox{}, fmt.Errorf("invalid bbox parameter %q: expected min_lon,min_lat,max_lon,max_lat", raw)
+	}
+	var values [4]float64
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return domain.BoundingBox{}, fmt.Errorf("invalid bbox parameter %q: %w", raw, err)
+		}
+		values[i] = value
+	}
+	box, err := domain.NewBoundingBox(values[0], values[1], values[2], values[3])
+	if err != nil {
+		return domain.BoundingBox{}, fmt.Errorf("invalid bbox parameter %q: %w", raw, err)
+	}
+	return box, nil
+}
+
+// errorResponse is the JSON body returned for failed requests
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// importErrorResponse is the JSON body returned when an import is rejected
+// as a whole
+type importErrorResponse struct {
+	Error  string               `json:"error"`
+	Report *domain.ImportReport `json:"report"`
+}
+
+// writeError writes an error response with the given status code
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// writeJSON writes v as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
@@ -0,0 +1,589 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"portservice/internal/adapters/secondary/memory"
+	"portservice/internal/core"
+	"portservice/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testPortJSON = `{
+	"id": "AEAJM",
+	"name": "Ajman",
+	"city": "Ajman",
+	"country": "United Arab Emirates",
+	"coordinates": [55.5136433, 25.4052165],
+	"province": "Ajman",
+	"timezone": "Asia/Dubai",
+	"unlocs": ["AEAJM"],
+	"code": "52000"
+}`
+
+func newTestHandler() *Handler {
+	return NewHandler(core.NewPortService(memory.NewPortRepository()))
+}
+
+func doRequest(h http.Handler, method, path, contentType string, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandler_CreateAndGetPort(t *testing.T) {
+	h := newTestHandler()
+
+	rec := doRequest(h, http.MethodPost, "/api/v1/ports", "application/json", []byte(testPortJSON))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = doRequest(h, http.MethodGet, "/api/v1/ports/AEAJM", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var port domain.Port
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &port))
+	assert.Equal(t, "AEAJM", port.ID)
+	assert.Equal(t, "Ajman", port.Name)
+	assert.NotNil(t, port.Coordinates)
+	assert.Equal(t, 55.5136433, port.Coordinates.Longitude)
+	assert.Equal(t, 25.4052165, port.Coordinates.Latitude)
+	assert.Equal(t, []string{"AEAJM"}, port.Unlocs)
+}
+
+func TestHandler_CreatePort_Deleted(t *testing.T) {
+	h := newTestHandler()
+
+	// Clients cannot save tombstones, which could then be neither fetched
+	// nor deleted
+	body := strings.Replace(testPortJSON, `"code": "52000"`, `"code": "52000", "deleted_at": "2024-01-01T00:00:00Z"`, 1)
+	rec := doRequest(h, http.MethodPost, "/api/v1/ports", "application/json", []byte(body))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = doRequest(h, http.MethodGet, "/api/v1/ports/AEAJM", "", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = doRequest(h, http.MethodPost, "/api/v1/ports/bulk", "application/json", []byte("["+body+"]"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp bulkResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, 1, resp.Failed)
+	rec = doRequest(h, http.MethodGet, "/api/v1/ports/AEAJM", "", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestHandler_DeletePort(t *testing.T) {
+	h := NewHandler(core.NewPortService(memory.NewPortRepository(memory.WithSoftDelete())))
+
+	rec := doRequest(h, http.MethodPost, "/api/v1/ports", "application/json", []byte(testPortJSON))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = doRequest(h, http.MethodDelete, "/api/v1/ports/AEAJM", "", nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = doRequest(h, http.MethodGet, "/api/v1/ports/AEAJM", "", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// Soft-deleted ports can still be requested explicitly
+	rec = doRequest(h, http.MethodGet, "/api/v1/ports/AEAJM?include_deleted=true", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var port domain.Port
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &port))
+	assert.NotNil(t, port.DeletedAt)
+
+	rec = doRequest(h, http.MethodDelete, "/api/v1/ports/AEAJM", "", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = doRequest(h, http.MethodGet, "/api/v1/ports/AEAJM?include_deleted=maybe", "", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestHandler_ListPorts(t *testing.T) {
+	h := newTestHandler()
+
+	for _, id := range []string{"AEAJM", "AEAUH", "AEDXB"} {
+		body := strings.Replace(testPortJSON, `"id": "AEAJM"`, `"id": "`+id+`"`, 1)
+		rec := doRequest(h, http.MethodPost, "/api/v1/ports", "application/json", []byte(body))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+
+	rec := doRequest(h, http.MethodGet, "/api/v1/ports?limit=2", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var page domain.PortPage
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+	assert.Len(t, page.Ports, 2)
+	assert.Equal(t, "AEAJM", page.Ports[0].ID)
+	assert.NotEmpty(t, page.NextCursor)
+
+	rec = doRequest(h, http.MethodGet, "/api/v1/ports?limit=2&cursor="+page.NextCursor, "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	page = domain.PortPage{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+	assert.Len(t, page.Ports, 1)
+	assert.Equal(t, "AEDXB", page.Ports[0].ID)
+	assert.Empty(t, page.NextCursor)
+
+	// Filters narrow the listing
+	rec = doRequest(h, http.MethodGet, "/api/v1/ports?unloc=AEAJM&country=united+arab+emirates", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	page = domain.PortPage{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+	assert.Len(t, page.Ports, 3)
+
+	rec = doRequest(h, http.MethodGet, "/api/v1/ports?country=Norway&country=Sweden", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	page = domain.PortPage{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+	assert.Empty(t, page.Ports)
+
+	rec = doRequest(h, http.MethodGet, "/api/v1/ports?cursor=bogus", "", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(h, http.MethodGet, "/api/v1/ports?limit=-1", "", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestHandler_SpatialQueries(t *testing.T) {
+	h := newTestHandler()
+
+	rec := doRequest(h, http.MethodPost, "/api/v1/ports", "application/json", []byte(testPortJSON))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = doRequest(h, http.MethodGet, "/api/v1/ports/nearest?lon=55.27&lat=25.25&k=3", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp distanceResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Len(t, resp.Results, 1)
+	assert.Equal(t, "AEAJM", resp.Results[0].Port.ID)
+	assert.Greater(t, resp.Results[0].DistanceKm, 0.0)
+
+	rec = doRequest(h, http.MethodGet, "/api/v1/ports/within-radius?lon=55.27&lat=25.25&radius_km=10", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	resp = distanceResponse{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Empty(t, resp.Results)
+
+	for _, path := range []string{
+		"/api/v1/ports/nearest?lon=55.27",
+		"/api/v1/ports/nearest?lon=555&lat=25",
+		"/api/v1/ports/within-radius?lon=55.27&lat=25.25",
+		"/api/v1/ports/within-radius?lon=55.27&lat=25.25&radius_km=0",
+	} {
+		rec = doRequest(h, http.MethodGet, path, "", nil)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, path)
+	}
+}
+
+func TestHandler_AreaQueries(t *testing.T) {
+	h := newTestHandler()
+
+	ports := map[string]string{
+		"AEAJM": "[55.5136433, 25.4052165]",
+		"FJSUV": "[178.42, -18.14]",
+		"WSAPW": "[-171.76, -13.83]",
+	}
+	for id, coords := range ports {
+		body := strings.Replace(testPortJSON, `"id": "AEAJM"`, `"id": "`+id+`"`, 1)
+		body = strings.Replace(body, "[55.5136433, 25.4052165]", coords, 1)
+		rec := doRequest(h, http.MethodPost, "/api/v1/ports", "application/json", []byte(body))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+
+	query := func(method, path, body string) []string {
+		rec := doRequest(h, method, path, "application/json", []byte(body))
+		assert.Equal(t, http.StatusOK, rec.Code, path)
+
+		var resp portsResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		ids := make([]string, 0, len(resp.Ports))
+		for _, p := range resp.Ports {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []string{"AEAJM"}, query(http.MethodGet, "/api/v1/ports/within-bbox?bbox=50,20,60,30", ""))
+	assert.Equal(t, []string{"FJSUV", "WSAPW"}, query(http.MethodGet, "/api/v1/ports/within-bbox?bbox=170,-25,-170,-10", ""))
+	assert.Empty(t, query(http.MethodGet, "/api/v1/ports/within-bbox?bbox=-170,-25,170,-10", ""))
+
+	polygon := `{"type": "Polygon", "coordinates": [[[50, 20], [60, 20], [60, 30], [50, 20]]]}`
+	assert.Equal(t, []string{"AEAJM"}, query(http.MethodPost, "/api/v1/ports/within-polygon", polygon))
+
+	for _, path := range []string{
+		"/api/v1/ports/within-bbox",
+		"/api/v1/ports/within-bbox?bbox=1,2,3",
+		"/api/v1/ports/within-bbox?bbox=0,10,1,5",
+	} {
+		rec := doRequest(h, http.MethodGet, path, "", nil)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, path)
+	}
+
+	rec := doRequest(h, http.MethodPost, "/api/v1/ports/within-polygon", "application/json", []byte(`{"type": "Point", "coordinates": [0, 0]}`))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestHandler_SearchPorts(t *testing.T) {
+	h := newTestHandler()
+
+	rec := doRequest(h, http.MethodPost, "/api/v1/ports", "application/json", []byte(testPortJSON))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = doRequest(h, http.MethodGet, "/api/v1/ports/search?q=ajmn", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp searchResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Len(t, resp.Results, 1)
+	assert.Equal(t, "AEAJM", resp.Results[0].Port.ID)
+	assert.Greater(t, resp.Results[0].Score, 0.0)
+
+	rec = doRequest(h, http.MethodGet, "/api/v1/ports/search?q=", "", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestHandler_Errors(t *testing.T) {
+	h := newTestHandler()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{
+			name:       "malformed JSON",
+			method:     http.MethodPost,
+			path:       "/api/v1/ports",
+			body:       `{"id": `,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "missing name",
+			method:     http.MethodPost,
+			path:       "/api/v1/ports",
+			body:       `{"id": "X", "coordinates": [1, 2]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "coordinates out of range",
+			method:     http.MethodPost,
+			path:       "/api/v1/ports",
+			body:       `{"id": "X", "name": "X", "coordinates": [181, 2]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown port",
+			method:     http.MethodGet,
+			path:       "/api/v1/ports/NOPE",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "file upload without multipart",
+			method:     http.MethodPost,
+			path:       "/api/v1/ports/file",
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(h, tt.method, tt.path, "application/json", []byte(tt.body))
+			assert.Equal(t, tt.wantStatus, rec.Code)
+
+			var resp errorResponse
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.NotEmpty(t, resp.Error)
+		})
+	}
+}
+
+func TestHandler_ProcessPortsFile(t *testing.T) {
+	h := newTestHandler()
+
+	upload := func(content string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		part, err := writer.CreateFormFile("file", "ports.json")
+		assert.NoError(t, err)
+		_, err = part.Write([]byte(content))
+		assert.NoError(t, err)
+		assert.NoError(t, writer.Close())
+		return doRequest(h, http.MethodPost, "/api/v1/ports/file", writer.FormDataContentType(), body.Bytes())
+	}
+
+	rec := upload(`{"AEAJM": ` + strings.Replace(testPortJSON, `"id": "AEAJM",`, "", 1) + `, "BAD": {"name": "Bad"}}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var report domain.ImportReport
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Rejected)
+	if assert.Len(t, report.Rejections, 1) {
+		assert.Equal(t, "BAD", report.Rejections[0].PortID)
+		assert.Equal(t, "coordinates", report.Rejections[0].Field)
+	}
+
+	rec = doRequest(h, http.MethodGet, "/api/v1/ports/AEAJM", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// Malformed uploads are reported as client errors
+	rec = upload(`{"AEAUH": {`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestHandler_ProcessPortsFile_Modes(t *testing.T) {
+	h := newTestHandler()
+
+	upload := func(query, content string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		part, err := writer.CreateFormFile("file", "ports.json")
+		assert.NoError(t, err)
+		_, err = part.Write([]byte(content))
+		assert.NoError(t, err)
+		assert.NoError(t, writer.Close())
+		return doRequest(h, http.MethodPost, "/api/v1/ports/file"+query, writer.FormDataContentType(), body.Bytes())
+	}
+	content := `{"AEAJM": ` + strings.Replace(testPortJSON, `"id": "AEAJM",`, "", 1) + `, "BAD": {"name": "Bad"}}`
+
+	// Strict imports reject the whole file with the report attached
+	rec := upload("?mode=strict", content)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	var resp importErrorResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.NotEmpty(t, resp.Error)
+	if assert.NotNil(t, resp.Report) {
+		assert.Equal(t, 1, resp.Report.Rejected)
+	}
+	rec = doRequest(h, http.MethodGet, "/api/v1/ports/AEAJM", "", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = upload("?mode=threshold&max_rejected_percent=50", content)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	for _, query := range []string{"?mode=careful", "?mode=threshold&max_rejected_percent=abc", "?mode=threshold&max_rejected_percent=-1"} {
+		rec = upload(query, content)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func TestHandler_ProcessPortsFile_CSV(t *testing.T) {
+	h := newTestHandler()
+
+	upload := func(query, content string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		part, err := writer.CreateFormFile("file", "ports.csv")
+		assert.NoError(t, err)
+		_, err = part.Write([]byte(content))
+		assert.NoError(t, err)
+		assert.NoError(t, writer.Close())
+		return doRequest(h, http.MethodPost, "/api/v1/ports/file"+query, writer.FormDataContentType(), body.Bytes())
+	}
+
+	rec := upload("?format=csv&columns=id=LOCODE", "LOCODE,name,lon,lat\nAEAJM,Ajman,55.5136433,25.4052165\n")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var report domain.ImportReport
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, 1, report.Created)
+
+	rec = doRequest(h, http.MethodGet, "/api/v1/ports/AEAJM", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	for _, tt := range []struct{ query, content string }{
+		{"?format=xml", "id,name,lon,lat\n"},
+		{"?format=csv&columns=depth=Depth", "id,name,lon,lat\n"},
+		{"?format=csv", "id,name\n"},
+		{"?format=csv", "id,name,lon,lat\n\"X,X,1,2\n"},
+	} {
+		rec = upload(tt.query, tt.content)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, tt.query)
+	}
+}
+
+func TestHandler_ExportPorts(t *testing.T) {
+	h := newTestHandler()
+
+	rec := doRequest(h, http.MethodPost, "/api/v1/ports", "application/json", []byte(testPortJSON))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = doRequest(h, http.MethodGet, "/api/v1/ports/export", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if assert.Len(t, lines, 1) {
+		var port domain.Port
+		assert.NoError(t, json.Unmarshal([]byte(lines[0]), &port))
+		assert.Equal(t, "AEAJM", port.ID)
+	}
+
+	rec = doRequest(h, http.MethodGet, "/api/v1/ports/export?format=geojson", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/geo+json", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `"type":"FeatureCollection"`)
+	assert.Contains(t, rec.Body.String(), `"id":"AEAJM"`)
+
+	rec = doRequest(h, http.MethodGet, "/api/v1/ports/export?format=json", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var ports map[string]domain.Port
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &ports))
+	assert.Contains(t, ports, "AEAJM")
+
+	rec = doRequest(h, http.MethodGet, "/api/v1/ports/export?country=Netherlands", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	for _, query := range []string{"?format=xml", "?include_deleted=maybe", "?format=csv&fields=depth", "?bom=maybe"} {
+		rec = doRequest(h, http.MethodGet, "/api/v1/ports/export"+query, "", nil)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func TestHandler_ExportPorts_Deleted(t *testing.T) {
+	h := NewHandler(core.NewPortService(memory.NewPortRepository(memory.WithSoftDelete())))
+
+	rec := doRequest(h, http.MethodPost, "/api/v1/ports", "application/json", []byte(testPortJSON))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = doRequest(h, http.MethodPost, "/api/v1/ports", "application/json",
+		[]byte(`{"id": "AEAUH", "name": "Abu Dhabi", "coordinates": [54.37, 24.47]}`))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = doRequest(h, http.MethodDelete, "/api/v1/ports/AEAJM", "", nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	// Formats with a deleted_at field can include tombstones
+	rec = doRequest(h, http.MethodGet, "/api/v1/ports/export?include_deleted=true", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"deleted_at"`)
+
+	// Keyed ports files cannot, so importing them never revives a port
+	rec = doRequest(h, http.MethodGet, "/api/v1/ports/export?format=json&include_deleted=true", "", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(h, http.MethodGet, "/api/v1/ports/export?format=json", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	other := core.NewPortService(memory.NewPortRepository(memory.WithSoftDelete()))
+	report, err := other.ProcessPorts(context.Background(), rec.Body, domain.ImportOptions{Format: domain.ImportJSON})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+	port, err := other.GetPortIncludingDeleted(context.Background(), "AEAJM")
+	assert.NoError(t, err)
+	assert.Nil(t, port)
+}
+
+func TestHandler_ExportPorts_CSV(t *testing.T) {
+	h := newTestHandler()
+
+	rec := doRequest(h, http.MethodPost, "/api/v1/ports", "application/json", []byte(testPortJSON))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/ports/export?fields=id,name,unlocs&list_separator=|", nil)
+	req.Header.Set("Accept", "text/html, text/csv;q=0.9")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "id,name,unlocs\nAEAJM,Ajman,AEAJM\n", rec.Body.String())
+
+	// An explicit format wins over the Accept header
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/ports/export?format=ndjson", nil)
+	req.Header.Set("Accept", "text/csv")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+
+	rec = doRequest(h, http.MethodGet, "/api/v1/ports/export?format=csv&bom=true&fields=id", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "\uFEFFid\nAEAJM\n", rec.Body.String())
+}
+
+func TestHandler_ProcessPortsFile_NDJSON(t *testing.T) {
+	h := newTestHandler()
+
+	var line bytes.Buffer
+	assert.NoError(t, json.Compact(&line, []byte(testPortJSON)))
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "ports.ndjson")
+	assert.NoError(t, err)
+	_, err = part.Write([]byte(line.String() + "\n{\"id\": \"BAD\"}\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	rec := doRequest(h, http.MethodPost, "/api/v1/ports/file?format=ndjson&start_line=1", writer.FormDataContentType(), body.Bytes())
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var report domain.ImportReport
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, 1, report.Created)
+	if assert.Len(t, report.Rejections, 1) {
+		assert.Equal(t, 2, report.Rejections[0].Line)
+	}
+}
+
+func TestHandler_CreateOrUpdatePorts(t *testing.T) {
+	h := newTestHandler()
+
+	body := `[` + testPortJSON + `,
+		{"id": "BAD", "name": "Bad", "coordinates": [200, 0]},
+		{"id": "NONAME", "coordinates": [54.37, 24.47]},
+		{"id": "AEAUH", "name": "Abu Dhabi", "coordinates": [54.37, 24.47]},
+		{"id": "AEDXB", "name": 42},
+		{"id": 7, "name": "Number"},
+		"AEJEA"
+	]`
+	rec := doRequest(h, http.MethodPost, "/api/v1/ports/bulk", "application/json", []byte(body))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp bulkResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, 2, resp.Saved)
+	assert.Equal(t, 5, resp.Failed)
+	if assert.Len(t, resp.Results, 7) {
+		for i, result := range resp.Results {
+			assert.Equal(t, i, result.Index)
+		}
+		assert.Equal(t, "AEAJM", resp.Results[0].ID)
+		assert.Empty(t, resp.Results[0].Error)
+		assert.NotEmpty(t, resp.Results[1].Error)
+		assert.Equal(t, "NONAME", resp.Results[2].ID)
+		assert.NotEmpty(t, resp.Results[2].Error)
+		assert.Equal(t, "AEAUH", resp.Results[3].ID)
+		assert.Empty(t, resp.Results[3].Error)
+
+		// Items that don't decode keep whatever ID can be read from them
+		assert.Equal(t, "AEDXB", resp.Results[4].ID)
+		assert.NotEmpty(t, resp.Results[4].Error)
+		assert.Equal(t, "7", resp.Results[5].ID)
+		assert.NotEmpty(t, resp.Results[5].Error)
+		assert.Empty(t, resp.Results[6].ID)
+		assert.NotEmpty(t, resp.Results[6].Error)
+	}
+
+	rec = doRequest(h, http.MethodGet, "/api/v1/ports/AEAUH", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = doRequest(h, http.MethodPost, "/api/v1/ports/bulk", "application/json", []byte(`{"id": "AEAJM"}`))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
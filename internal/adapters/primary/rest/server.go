@@ -0,0 +1,69 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"portservice/internal/ports/in"
+)
+
+// Config holds the HTTP server configuration
+type Config struct {
+	Port            string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	ShutdownTimeout time.Duration
+}
+
+// DefaultConfig returns the configuration documented in the README
+func DefaultConfig() Config {
+	return Config{
+		Port:            "8080",
+		ReadTimeout:     30 * time.Second,
+		WriteTimeout:    30 * time.Second,
+		ShutdownTimeout: 30 * time.Second,
+	}
+}
+
+// Server is the HTTP primary adapter serving the REST API
+type Server struct {
+	httpServer      *http.Server
+	shutdownTimeout time.Duration
+}
+
+// NewServer creates a new Server exposing the given service
+func NewServer(cfg Config, service in.PortService) *Server {
+	return &Server{
+		httpServer: &http.Server{
+			Addr:         net.JoinHostPort("", cfg.Port),
+			Handler:      NewHandler(service),
+			ReadTimeout:  cfg.ReadTimeout,
+			WriteTimeout: cfg.WriteTimeout,
+		},
+		shutdownTimeout: cfg.ShutdownTimeout,
+	}
+}
+
+// Addr returns the address the server listens on
+func (s *Server) Addr() string {
+	return s.httpServer.Addr
+}
+
+// ListenAndServe starts serving requests and blocks until the server stops
+func (s *Server) ListenAndServe() error {
+	if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown gracefully stops the server, waiting for in-flight requests
+// until the configured shutdown timeout expires
+func (s *Server) Shutdown(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.shutdownTimeout)
+	defer cancel()
+	return s.httpServer.Shutdown(ctx)
+}
@@ -0,0 +1,481 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"portservice/internal/adapters/secondary/memory"
+	"portservice/internal/domain"
+	"portservice/internal/ports/out"
+)
+
+// Files kept in the data directory
+const (
+	walFile      = "ports.wal"
+	snapshotFile = "ports.snapshot"
+)
+
+const (
+	// DefaultSyncInterval is how often SyncInterval flushes the log
+	DefaultSyncInterval = time.Second
+
+	// DefaultSnapshotSize is the log size that triggers a snapshot
+	DefaultSnapshotSize = 64 << 20
+)
+
+// ErrClosed is returned by writes to a closed repository
+var ErrClosed = errors.New("repository is closed")
+
+// SyncPolicy chooses when logged writes are flushed to stable storage
+type SyncPolicy string
+
+const (
+	// SyncAlways flushes the log before each write returns, so no
+	// acknowledged write is lost
+	SyncAlways SyncPolicy = "always"
+
+	// SyncInterval flushes the log periodically, so a crash loses at most
+	// the writes of the last interval
+	SyncInterval SyncPolicy = "interval"
+
+	// SyncNever leaves flushing to the operating system; writes survive a
+	// process crash but not a power failure
+	SyncNever SyncPolicy = "never"
+)
+
+// ParseSyncPolicy parses a sync policy name; the empty string selects
+// SyncAlways
+func ParseSyncPolicy(name string) (SyncPolicy, error) {
+	switch policy := SyncPolicy(domain.NormalizeFilterValue(name)); policy {
+	case "":
+		return SyncAlways, nil
+	case SyncAlways, SyncInterval, SyncNever:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown sync policy %q", name)
+	}
+}
+
+// PortRepository implements out.PortRepository by keeping ports in a
+// memory.PortRepository and persisting every write to a write-ahead log in
+// its data directory. Once the log grows past the snapshot size it is
+// compacted into a snapshot of all ports. On startup the snapshot is loaded
+// and the log replayed, discarding a torn record left by a crash.
+type PortRepository struct {
+	// ports holds the recovered ports and serves every read
+	ports *memory.PortRepository
+
+	dir string
+
+	// mu serializes writes so log order matches the order they are applied
+	mu     sync.Mutex
+	wal    *wal
+	seq    uint64
+	closed bool
+
+	// failed holds the error that left the log unwritable or unsynced;
+	// the repository refuses writes from then on, since the log may no
+	// longer match the ports in memory
+	failed error
+
+	syncPolicy   SyncPolicy
+	syncInterval time.Duration
+	snapshotSize int64
+	memoryOpts   []memory.Option
+
+	// stop ends the background sync started for SyncInterval, which closes
+	// stopped once it returns
+	stop     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+// Option configures a PortRepository
+type Option func(*PortRepository)
+
+// WithSoftDelete makes DeletePort tombstone ports, as memory.WithSoftDelete
+func WithSoftDelete() Option {
+	return func(r *PortRepository) {
+		r.memoryOpts = append(r.memoryOpts, memory.WithSoftDelete())
+	}
+}
+
+// WithSyncPolicy sets when writes are flushed to stable storage; the
+// default is SyncAlways
+func WithSyncPolicy(policy SyncPolicy) Option {
+	return func(r *PortRepository) {
+		r.syncPolicy = policy
+	}
+}
+
+// WithSyncInterval sets how often SyncInterval flushes the log
+func WithSyncInterval(interval time.Duration) Option {
+	return func(r *PortRepository) {
+		r.syncInterval = interval
+	}
+}
+
+// WithSnapshotSize sets the log size in bytes that triggers a snapshot;
+// zero or less only snapshots on Close
+func WithSnapshotSize(size int64) Option {
+	return func(r *PortRepository) {
+		r.snapshotSize = size
+	}
+}
+
+// NewPortRepository opens the repository stored in dir, creating the
+// directory if needed, and recovers its ports
+func NewPortRepository(dir string, opts ...Option) (out.PortRepository, error) {
+	r := &PortRepository{
+		dir:          dir,
+		syncPolicy:   SyncAlways,
+		syncInterval: DefaultSyncInterval,
+		snapshotSize: DefaultSnapshotSize,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if _, err := ParseSyncPolicy(string(r.syncPolicy)); err != nil {
+		return nil, err
+	}
+	if r.syncPolicy == SyncInterval && r.syncInterval <= 0 {
+		return nil, fmt.Errorf("invalid sync interval %v", r.syncInterval)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	r.ports = memory.NewPortRepository(r.memoryOpts...).(*memory.PortRepository)
+	if err := r.recover(); err != nil {
+		return nil, err
+	}
+
+	if r.syncPolicy == SyncInterval {
+		r.stop = make(chan struct{})
+		r.stopped = make(chan struct{})
+		go r.syncPeriodically()
+	}
+	return r, nil
+}
+
+// recover loads the snapshot, replays the log entries written after it and
+// opens the log for appending
+func (r *PortRepository) recover() error {
+	ctx := context.Background()
+	snapshotSeq, err := loadSnapshot(r.path(snapshotFile), func(e *entry) error {
+		return r.apply(ctx, e)
+	})
+	if err != nil {
+		return err
+	}
+	r.seq = snapshotSeq
+
+	// A crash between writing a snapshot and emptying the log leaves
+	// entries the snapshot already includes
+	walPath := r.path(walFile)
+	size, torn, err := replayLog(walPath, func(e *entry) error {
+		if e.Seq <= snapshotSeq {
+			return nil
+		}
+		r.seq = e.Seq
+		return r.apply(ctx, e)
+	})
+	if err != nil {
+		return err
+	}
+	if torn {
+		log.Printf("Discarding torn write at offset %d of %s", size, walPath)
+	}
+
+	if r.wal, err = openWAL(walPath, size); err != nil {
+		return err
+	}
+	return syncDir(r.dir)
+}
+
+// apply performs a logged write on the ports in memory
+func (r *PortRepository) apply(ctx context.Context, e *entry) error {
+	if len(e.Ports) > 0 {
+		result, err := r.ports.SavePorts(ctx, e.Ports)
+		if err != nil {
+			return err
+		}
+		if err := result.FirstError(); err != nil {
+			return fmt.Errorf("invalid port in entry %d: %w", e.Seq, err)
+		}
+	}
+	if e.Delete != "" {
+		deletedAt := time.Now()
+		if e.DeletedAt != nil {
+			deletedAt = *e.DeletedAt
+		}
+		err := r.ports.DeletePortAt(ctx, e.Delete, deletedAt)
+		if err != nil && !errors.Is(err, domain.ErrPortNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// path returns the path of a file in the data directory
+func (r *PortRepository) path(name string) string {
+	return filepath.Join(r.dir, name)
+}
+
+// SavePort saves or updates a port in the repository
+func (r *PortRepository) SavePort(ctx context.Context, port *domain.Port) error {
+	result, err := r.SavePorts(ctx, []*domain.Port{port})
+	if err != nil {
+		return err
+	}
+	return result.FirstError()
+}
+
+// SavePorts logs the valid ports of a batch as one write and then saves
+// them, so a crash keeps all of them or none
+func (r *PortRepository) SavePorts(ctx context.Context, ports []*domain.Port) (domain.BatchResult, error) {
+	if ctx.Err() != nil {
+		return domain.BatchResult{}, ctx.Err()
+	}
+	stored, result := domain.PrepareBatch(ports)
+	if err := r.store(ctx, stored); err != nil {
+		return domain.BatchResult{}, err
+	}
+	return result, nil
+}
+
+// store logs and saves validated ports as one write, in order
+func (r *PortRepository) store(ctx context.Context, ports []*domain.Port) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.writeLocked(ctx, &entry{Ports: ports})
+}
+
+// DeletePort logs and deletes a port, returning domain.ErrPortNotFound if
+// it does not exist
+func (r *PortRepository) DeletePort(ctx context.Context, id string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.writableLocked(); err != nil {
+		return err
+	}
+	port, err := r.ports.GetPortIncludingDeleted(ctx, id)
+	if err != nil {
+		return err
+	}
+	if port == nil || port.IsDeleted() {
+		return fmt.Errorf("%w: %s", domain.ErrPortNotFound, id)
+	}
+
+	// Log the deletion time so replaying a soft delete keeps it
+	deletedAt := time.Now().UTC()
+	return r.writeLocked(ctx, &entry{Delete: id, DeletedAt: &deletedAt})
+}
+
+// GetPort retrieves a port by its ID, hiding soft-deleted ports
+func (r *PortRepository) GetPort(ctx context.Context, id string) (*domain.Port, error) {
+	return r.ports.GetPort(ctx, id)
+}
+
+// GetPortIncludingDeleted retrieves a port by its ID, including soft-deleted ports
+func (r *PortRepository) GetPortIncludingDeleted(ctx context.Context, id string) (*domain.Port, error) {
+	return r.ports.GetPortIncludingDeleted(ctx, id)
+}
+
+// ListPorts returns a page of ports matching the filter, ordered by port ID
+func (r *PortRepository) ListPorts(ctx context.Context, filter domain.PortFilter, cursor string, limit int) (domain.PortPage, error) {
+	return r.ports.ListPorts(ctx, filter, cursor, limit)
+}
+
+// FindWithinRadius returns live ports within radiusKm of center, nearest first
+func (r *PortRepository) FindWithinRadius(ctx context.Context, center domain.Coordinate, radiusKm float64) ([]domain.PortDistance, error) {
+	return r.ports.FindWithinRadius(ctx, center, radiusKm)
+}
+
+// FindNearest returns the k live ports closest to center, nearest first
+func (r *PortRepository) FindNearest(ctx context.Context, center domain.Coordinate, k int) ([]domain.PortDistance, error) {
+	return r.ports.FindNearest(ctx, center, k)
+}
+
+// FindInBoundingBox returns live ports inside the box, ordered by port ID
+func (r *PortRepository) FindInBoundingBox(ctx context.Context, box domain.BoundingBox) ([]*domain.Port, error) {
+	return r.ports.FindInBoundingBox(ctx, box)
+}
+
+// SearchPorts returns live ports matching the text query, most relevant first
+func (r *PortRepository) SearchPorts(ctx context.Context, query string, limit int) ([]domain.SearchResult, error) {
+	return r.ports.SearchPorts(ctx, query, limit)
+}
+
+// GetStatistics returns the statistics of the ports in memory, which count
+// the writes replayed on startup as well as those made since
+func (r *PortRepository) GetStatistics() out.RepositoryStats {
+	return r.ports.GetStatistics()
+}
+
+// BeginTx starts a transaction whose staged ports are logged as one write
+// on Commit
+func (r *PortRepository) BeginTx(ctx context.Context) (out.PortTx, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return out.NewStagedTx(r.store), nil
+}
+
+// writableLocked returns the error that prevents writes, if any. The caller
+// must hold r.mu.
+func (r *PortRepository) writableLocked() error {
+	if r.closed {
+		return ErrClosed
+	}
+	return r.failed
+}
+
+// writeLocked appends e to the log, syncing it as the policy requires, and
+// then applies it. The write is applied even if ctx is canceled once it is
+// logged, so memory never falls behind the log. The caller must hold r.mu.
+func (r *PortRepository) writeLocked(ctx context.Context, e *entry) error {
+	if err := r.writableLocked(); err != nil {
+		return err
+	}
+	if len(e.Ports) == 0 && e.Delete == "" {
+		return nil
+	}
+
+	e.Seq = r.seq + 1
+	start := r.wal.size
+	for _, record := range splitEntry(e) {
+		data, err := appendRecord(nil, record)
+		if err != nil {
+			// Drop the records of the write already appended, which replay
+			// would discard anyway, so the log can be written again
+			if truncErr := r.wal.truncate(start); truncErr != nil {
+				r.failed = truncErr
+			}
+			return err
+		}
+		if err := r.wal.append(data); err != nil {
+			r.failed = err
+			return err
+		}
+	}
+	r.seq = e.Seq
+	if r.syncPolicy == SyncAlways {
+		if err := r.wal.sync(); err != nil {
+			r.failed = err
+			return err
+		}
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	if err := r.apply(ctx, e); err != nil {
+		return err
+	}
+	if r.snapshotSize > 0 && r.wal.size >= r.snapshotSize {
+		// The write is durable in the log either way, so a failed
+		// snapshot is retried on the next write rather than reported
+		if err := r.snapshotLocked(ctx); err != nil {
+			log.Printf("Error writing snapshot of %s: %v", r.dir, err)
+		}
+	}
+	return nil
+}
+
+// splitEntry splits a write into entries of at most logBatchSize ports
+func splitEntry(e *entry) []*entry {
+	if len(e.Ports) <= logBatchSize {
+		return []*entry{e}
+	}
+	var entries []*entry
+	for start := 0; start < len(e.Ports); start += logBatchSize {
+		end := min(start+logBatchSize, len(e.Ports))
+		entries = append(entries, &entry{Seq: e.Seq, Ports: e.Ports[start:end], Partial: end < len(e.Ports)})
+	}
+	return entries
+}
+
+// Snapshot compacts the log into a snapshot of every port
+func (r *PortRepository) Snapshot(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.writableLocked(); err != nil {
+		return err
+	}
+	return r.snapshotLocked(ctx)
+}
+
+// snapshotLocked writes a snapshot including every logged entry and then
+// empties the log. The caller must hold r.mu.
+func (r *PortRepository) snapshotLocked(ctx context.Context) error {
+	if err := writeSnapshot(ctx, r.path(snapshotFile), r.seq, r.ports.ListPorts); err != nil {
+		return err
+	}
+	if err := r.wal.reset(); err != nil {
+		r.failed = err
+		return err
+	}
+	return nil
+}
+
+// syncPeriodically flushes the log every sync interval until stopped
+func (r *PortRepository) syncPeriodically() {
+	defer close(r.stopped)
+	ticker := time.NewTicker(r.syncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.mu.Lock()
+			if r.writableLocked() == nil {
+				if err := r.wal.sync(); err != nil {
+					r.failed = err
+					log.Printf("Error syncing %s: %v", r.dir, err)
+				}
+			}
+			r.mu.Unlock()
+		}
+	}
+}
+
+// Close writes a final snapshot, so the next start needn't replay the log,
+// and closes the log and the ports in memory
+func (r *PortRepository) Close(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if r.stop != nil {
+		r.stopOnce.Do(func() { close(r.stop) })
+		<-r.stopped
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	var err error
+	if r.failed == nil && r.wal.size > 0 {
+		err = r.snapshotLocked(ctx)
+	}
+	if closeErr := r.wal.close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := r.ports.Close(ctx); err == nil {
+		err = closeErr
+	}
+	return err
+}
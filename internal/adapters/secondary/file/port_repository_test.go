@@ -0,0 +1,321 @@
+package file
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"portservice/internal/domain"
+	"portservice/internal/ports/out"
+	"portservice/internal/ports/out/outtest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// openRepository opens the repository in dir, failing the test on error
+func openRepository(t *testing.T, dir string, opts ...Option) *PortRepository {
+	t.Helper()
+	repo, err := NewPortRepository(dir, opts...)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return repo.(*PortRepository)
+}
+
+// portNames returns the names of every port in repo, keyed by ID
+func portNames(t *testing.T, repo out.PortRepository) map[string]string {
+	t.Helper()
+	page, err := repo.ListPorts(context.Background(), domain.PortFilter{}, "", domain.MaxPageLimit)
+	assert.NoError(t, err)
+	names := make(map[string]string)
+	for _, port := range page.Ports {
+		names[port.ID] = port.Name
+	}
+	return names
+}
+
+// storage gives each test a directory of its own
+func storage(t *testing.T) outtest.Opener {
+	dir := t.TempDir()
+	return func(softDelete bool) out.PortRepository {
+		var repo *PortRepository
+		if softDelete {
+			repo = openRepository(t, dir, WithSoftDelete())
+		} else {
+			repo = openRepository(t, dir)
+		}
+		t.Cleanup(func() { repo.Close(context.Background()) })
+		return repo
+	}
+}
+
+func TestPortRepository(t *testing.T) {
+	outtest.TestPortRepository(t, storage)
+}
+
+func TestPortRepository_Persistence(t *testing.T) {
+	outtest.TestPersistence(t, storage)
+}
+
+func TestPortRepository_Recover(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo := openRepository(t, dir)
+	assert.NoError(t, repo.SavePort(ctx, outtest.NewPort("AEAJM", "Ajman")))
+	result, err := repo.SavePorts(ctx, []*domain.Port{outtest.NewPort("AEAUH", "Abu Dhabi"), {ID: "INVALID"}, outtest.NewPort("AEDXB", "Dubai")})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Saved())
+	assert.NoError(t, repo.SavePort(ctx, outtest.NewPort("AEAJM", "Ajman Port")))
+	assert.NoError(t, repo.DeletePort(ctx, "AEDXB"))
+	assert.ErrorIs(t, repo.DeletePort(ctx, "AEDXB"), domain.ErrPortNotFound)
+
+	// Reopening without closing, as after a crash, replays the log
+	recovered := openRepository(t, dir)
+	assert.Equal(t, map[string]string{"AEAJM": "Ajman Port", "AEAUH": "Abu Dhabi"}, portNames(t, recovered))
+	assert.Equal(t, int64(2), recovered.GetStatistics().TotalPorts)
+
+	// Closing writes a snapshot and empties the log
+	assert.NoError(t, recovered.Close(ctx))
+	info, err := os.Stat(filepath.Join(dir, walFile))
+	assert.NoError(t, err)
+	assert.Zero(t, info.Size())
+	assert.ErrorIs(t, recovered.SavePort(ctx, outtest.NewPort("NLRTM", "Rotterdam")), ErrClosed)
+	assert.NoError(t, recovered.Close(ctx))
+
+	reopened := openRepository(t, dir)
+	assert.Equal(t, map[string]string{"AEAJM": "Ajman Port", "AEAUH": "Abu Dhabi"}, portNames(t, reopened))
+	port, err := reopened.GetPort(ctx, "AEAUH")
+	assert.NoError(t, err)
+	assert.Equal(t, outtest.NewPort("AEAUH", "Abu Dhabi"), port)
+	assert.NoError(t, reopened.Close(ctx))
+}
+
+func TestPortRepository_TornWrite(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo := openRepository(t, dir)
+	assert.NoError(t, repo.SavePort(ctx, outtest.NewPort("AEAJM", "Ajman")))
+	intact := repo.wal.size
+	assert.NoError(t, repo.SavePort(ctx, outtest.NewPort("AEAUH", "Abu Dhabi")))
+
+	// Cut the last record short, as a crash partway through writing it would
+	walPath := filepath.Join(dir, walFile)
+	assert.NoError(t, os.Truncate(walPath, repo.wal.size-3))
+
+	recovered := openRepository(t, dir)
+	assert.Equal(t, map[string]string{"AEAJM": "Ajman"}, portNames(t, recovered))
+	info, err := os.Stat(walPath)
+	assert.NoError(t, err)
+	assert.Equal(t, intact, info.Size())
+
+	// The log continues after the intact records
+	assert.NoError(t, recovered.SavePort(ctx, outtest.NewPort("AEDXB", "Dubai")))
+	reopened := openRepository(t, dir)
+	assert.Equal(t, map[string]string{"AEAJM": "Ajman", "AEDXB": "Dubai"}, portNames(t, reopened))
+}
+
+func TestPortRepository_LargeWrite(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo := openRepository(t, dir)
+	assert.NoError(t, repo.SavePort(ctx, outtest.NewPort("AEAJM", "Ajman")))
+	intact := repo.wal.size
+
+	// A transaction larger than a record spans several, sharing one sequence
+	// number
+	tx, err := repo.BeginTx(ctx)
+	assert.NoError(t, err)
+	for i := 0; i < 2*logBatchSize+1; i++ {
+		assert.NoError(t, tx.SavePort(ctx, outtest.NewPort(fmt.Sprintf("P%05d", i), "Port")))
+	}
+	assert.NoError(t, tx.Commit(ctx))
+	recovered := openRepository(t, dir)
+	assert.Equal(t, int64(2*logBatchSize+2), recovered.GetStatistics().TotalPorts)
+
+	// Losing its last record loses the whole write, which is cut from the log
+	walPath := filepath.Join(dir, walFile)
+	data, err := os.ReadFile(walPath)
+	assert.NoError(t, err)
+	var records []int64
+	for offset := int64(0); offset < int64(len(data)); {
+		length := int64(binary.LittleEndian.Uint32(data[offset:]))
+		offset += recordHeaderSize + length
+		records = append(records, offset)
+	}
+	assert.Len(t, records, 4)
+	assert.NoError(t, os.Truncate(walPath, records[2]))
+
+	recovered = openRepository(t, dir)
+	assert.Equal(t, map[string]string{"AEAJM": "Ajman"}, portNames(t, recovered))
+	info, err := os.Stat(walPath)
+	assert.NoError(t, err)
+	assert.Equal(t, intact, info.Size())
+}
+
+func TestPortRepository_CorruptRecord(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo := openRepository(t, dir)
+	assert.NoError(t, repo.SavePort(ctx, outtest.NewPort("AEAJM", "Ajman")))
+	intact := repo.wal.size
+	assert.NoError(t, repo.SavePort(ctx, outtest.NewPort("AEAUH", "Abu Dhabi")))
+	assert.NoError(t, repo.SavePort(ctx, outtest.NewPort("AEDXB", "Dubai")))
+
+	// A flipped bit fails the checksum, discarding the record and the rest
+	// of the log after it
+	walPath := filepath.Join(dir, walFile)
+	data, err := os.ReadFile(walPath)
+	assert.NoError(t, err)
+	data[intact+recordHeaderSize+10] ^= 0x01
+	assert.NoError(t, os.WriteFile(walPath, data, 0o644))
+
+	recovered := openRepository(t, dir)
+	assert.Equal(t, map[string]string{"AEAJM": "Ajman"}, portNames(t, recovered))
+}
+
+func TestPortRepository_Snapshot(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	walPath := filepath.Join(dir, walFile)
+
+	// Snapshot after every write
+	repo := openRepository(t, dir, WithSnapshotSize(1))
+	assert.NoError(t, repo.SavePort(ctx, outtest.NewPort("AEAJM", "Ajman")))
+	stale, err := os.ReadFile(walPath)
+	assert.NoError(t, err)
+	assert.Empty(t, stale)
+
+	// Entries the snapshot includes are skipped if a crash left them in the
+	// log, while later entries are replayed
+	repo = openRepository(t, dir, WithSnapshotSize(0))
+	assert.NoError(t, repo.SavePort(ctx, outtest.NewPort("AEAUH", "Abu Dhabi")))
+	stale, err = os.ReadFile(walPath)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Snapshot(ctx))
+	assert.NoError(t, repo.DeletePort(ctx, "AEAUH"))
+	assert.NoError(t, repo.SavePort(ctx, outtest.NewPort("AEDXB", "Dubai")))
+	latest, err := os.ReadFile(walPath)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(walPath, append(stale, latest...), 0o644))
+
+	recovered := openRepository(t, dir)
+	assert.Equal(t, map[string]string{"AEAJM": "Ajman", "AEDXB": "Dubai"}, portNames(t, recovered))
+}
+
+func TestPortRepository_CorruptSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo := openRepository(t, dir)
+	assert.NoError(t, repo.SavePort(ctx, outtest.NewPort("AEAJM", "Ajman")))
+	assert.NoError(t, repo.Close(ctx))
+
+	snapshotPath := filepath.Join(dir, snapshotFile)
+	data, err := os.ReadFile(snapshotPath)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(snapshotPath, data[:len(data)-1], 0o644))
+
+	_, err = NewPortRepository(dir)
+	assert.ErrorIs(t, err, ErrCorruptSnapshot)
+}
+
+func TestPortRepository_SoftDelete(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo := openRepository(t, dir, WithSoftDelete())
+	assert.NoError(t, repo.SavePort(ctx, outtest.NewPort("AEAJM", "Ajman")))
+	assert.NoError(t, repo.DeletePort(ctx, "AEAJM"))
+	deleted, err := repo.GetPortIncludingDeleted(ctx, "AEAJM")
+	assert.NoError(t, err)
+
+	// The tombstone keeps its deletion time when the log is replayed
+	recovered := openRepository(t, dir, WithSoftDelete())
+	port, err := recovered.GetPort(ctx, "AEAJM")
+	assert.NoError(t, err)
+	assert.Nil(t, port)
+	port, err = recovered.GetPortIncludingDeleted(ctx, "AEAJM")
+	assert.NoError(t, err)
+	if assert.NotNil(t, port) && assert.NotNil(t, port.DeletedAt) {
+		assert.True(t, deleted.DeletedAt.Equal(*port.DeletedAt))
+	}
+
+	// and when loaded from a snapshot
+	assert.NoError(t, recovered.Close(ctx))
+	reopened := openRepository(t, dir, WithSoftDelete())
+	port, err = reopened.GetPortIncludingDeleted(ctx, "AEAJM")
+	assert.NoError(t, err)
+	if assert.NotNil(t, port) && assert.NotNil(t, port.DeletedAt) {
+		assert.True(t, deleted.DeletedAt.Equal(*port.DeletedAt))
+	}
+}
+
+func TestPortRepository_Transaction(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo := openRepository(t, dir)
+	tx, err := repo.BeginTx(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.SavePort(ctx, outtest.NewPort("AEAJM", "Ajman")))
+	assert.NoError(t, tx.SavePort(ctx, outtest.NewPort("AEAUH", "Abu Dhabi")))
+	assert.Error(t, tx.SavePort(ctx, &domain.Port{ID: "INVALID"}))
+	assert.Empty(t, portNames(t, repo))
+	assert.NoError(t, tx.Commit(ctx))
+	assert.ErrorIs(t, tx.Commit(ctx), out.ErrTxDone)
+
+	rolledBack, err := repo.BeginTx(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, rolledBack.SavePort(ctx, outtest.NewPort("AEDXB", "Dubai")))
+	assert.NoError(t, rolledBack.Rollback(ctx))
+
+	recovered := openRepository(t, dir)
+	assert.Equal(t, map[string]string{"AEAJM": "Ajman", "AEAUH": "Abu Dhabi"}, portNames(t, recovered))
+}
+
+func TestPortRepository_SyncPolicies(t *testing.T) {
+	ctx := context.Background()
+
+	for _, policy := range []SyncPolicy{SyncAlways, SyncInterval, SyncNever} {
+		dir := t.TempDir()
+		repo := openRepository(t, dir, WithSyncPolicy(policy), WithSyncInterval(time.Millisecond))
+		assert.NoError(t, repo.SavePort(ctx, outtest.NewPort("AEAJM", "Ajman")), policy)
+		if policy == SyncInterval {
+			assert.Eventually(t, func() bool {
+				repo.mu.Lock()
+				defer repo.mu.Unlock()
+				return !repo.wal.dirty
+			}, time.Second, time.Millisecond)
+		}
+		assert.NoError(t, repo.Close(ctx), policy)
+
+		reopened := openRepository(t, dir)
+		assert.Equal(t, map[string]string{"AEAJM": "Ajman"}, portNames(t, reopened), policy)
+	}
+
+	_, err := NewPortRepository(t.TempDir(), WithSyncPolicy("sometimes"))
+	assert.Error(t, err)
+	_, err = NewPortRepository(t.TempDir(), WithSyncPolicy(SyncInterval), WithSyncInterval(0))
+	assert.Error(t, err)
+}
+
+func TestParseSyncPolicy(t *testing.T) {
+	policy, err := ParseSyncPolicy("")
+	assert.NoError(t, err)
+	assert.Equal(t, SyncAlways, policy)
+
+	policy, err = ParseSyncPolicy(" Interval ")
+	assert.NoError(t, err)
+	assert.Equal(t, SyncInterval, policy)
+
+	_, err = ParseSyncPolicy("sometimes")
+	assert.Error(t, err)
+}
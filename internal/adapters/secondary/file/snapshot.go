@@ -0,0 +1,136 @@
+package file
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"portservice/internal/domain"
+)
+
+// ErrCorruptSnapshot is returned when the snapshot file fails its checksums
+// or is incomplete. Unlike a torn log record it can't be discarded, since
+// snapshots are replaced atomically and hold data no longer in the log.
+var ErrCorruptSnapshot = errors.New("corrupt snapshot")
+
+// snapshotBatchSize is the number of ports written per snapshot record
+const snapshotBatchSize = domain.MaxPageLimit
+
+// loadSnapshot calls apply with each entry of the snapshot at path and
+// returns the sequence number of the last log entry it includes. A missing
+// snapshot is empty.
+func loadSnapshot(path string, apply func(*entry) error) (uint64, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	for {
+		e, _, err := readRecord(reader)
+		if err == io.EOF || err == errTornRecord {
+			return 0, ErrCorruptSnapshot
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read snapshot: %w", err)
+		}
+		if e.End {
+			return e.Seq, nil
+		}
+		if err := apply(e); err != nil {
+			return 0, fmt.Errorf("failed to load snapshot: %w", err)
+		}
+	}
+}
+
+// writeSnapshot atomically replaces the snapshot at path with the ports
+// listed by list, which includes soft-deleted ones, recording seq as the
+// last log entry they include. The snapshot is written to a temporary file
+// that is synced and renamed over the old one, so a crash leaves either
+// snapshot intact.
+func writeSnapshot(ctx context.Context, path string, seq uint64, list listFunc) error {
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	defer os.Remove(tmpPath)
+
+	if err := writeSnapshotEntries(ctx, f, seq, list); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync snapshot: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close snapshot: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// listFunc lists a page of ports like out.PortRepository.ListPorts
+type listFunc func(ctx context.Context, filter domain.PortFilter, cursor string, limit int) (domain.PortPage, error)
+
+// writeSnapshotEntries writes every port listed by list to w in batches,
+// followed by the end entry
+func writeSnapshotEntries(ctx context.Context, w io.Writer, seq uint64, list listFunc) error {
+	writer := bufio.NewWriter(w)
+	var record []byte
+	cursor := ""
+	for {
+		page, err := list(ctx, domain.PortFilter{IncludeDeleted: true}, cursor, snapshotBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to list ports for snapshot: %w", err)
+		}
+		if len(page.Ports) > 0 {
+			if record, err = appendRecord(record[:0], &entry{Seq: seq, Ports: page.Ports}); err != nil {
+				return err
+			}
+			if _, err := writer.Write(record); err != nil {
+				return fmt.Errorf("failed to write snapshot: %w", err)
+			}
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	record, err := appendRecord(record[:0], &entry{Seq: seq, End: true})
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(record); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// syncDir syncs a directory so renames and new files in it are durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open directory: %w", err)
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("failed to sync directory: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package file
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"time"
+
+	"portservice/internal/domain"
+)
+
+// recordHeaderSize is the size of the length and checksum preceding each
+// record payload
+const recordHeaderSize = 8
+
+// maxRecordSize bounds the payload length of a record, so a corrupt length
+// read from a header can't trigger a huge allocation
+const maxRecordSize = 1 << 30
+
+// logBatchSize is the number of ports written per log record. Larger writes
+// span several records, keeping each well under maxRecordSize.
+const logBatchSize = domain.MaxPageLimit
+
+// crcTable is the CRC-32C table record checksums are computed with
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// errTornRecord is returned by readRecord when the file ends partway through
+// a record or its checksum doesn't match, as after a crash mid-write
+var errTornRecord = errors.New("torn or corrupt record")
+
+// errRecordTooLarge is returned by appendRecord for payloads that readRecord
+// would reject
+var errRecordTooLarge = errors.New("record exceeds the maximum size")
+
+// entry is the payload of a log or snapshot record. Log entries either save
+// ports or delete one; snapshots hold the ports followed by an end entry. A
+// write of more than logBatchSize ports spans several entries with the same
+// sequence number, all but the last marked partial.
+type entry struct {
+	// Seq numbers log entries in the order they were written; snapshot
+	// entries carry the sequence number of the last entry they include
+	Seq uint64 `json:"seq"`
+
+	// Ports are saved, in order
+	Ports []*domain.Port `json:"ports,omitempty"`
+
+	// Delete is the ID of a deleted port, and DeletedAt its deletion time
+	Delete    string     `json:"delete,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	// Partial marks an entry continued by the next one, which belongs to
+	// the same write
+	Partial bool `json:"partial,omitempty"`
+
+	// End marks the last entry of a complete snapshot
+	End bool `json:"end,omitempty"`
+}
+
+// appendRecord appends e to buf as a record: the payload length and its
+// CRC-32C, both little-endian uint32s, followed by the JSON payload
+func appendRecord(buf []byte, e *entry) ([]byte, error) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return buf, fmt.Errorf("failed to encode record: %w", err)
+	}
+	if len(payload) > maxRecordSize {
+		return buf, fmt.Errorf("%w: %d bytes", errRecordTooLarge, len(payload))
+	}
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(payload)))
+	buf = binary.LittleEndian.AppendUint32(buf, crc32.Checksum(payload, crcTable))
+	return append(buf, payload...), nil
+}
+
+// readRecord reads the next record from r, returning its entry and size.
+// It returns io.EOF at a clean end of the file and errTornRecord for a
+// partial or corrupt record.
+func readRecord(r io.Reader) (*entry, int64, error) {
+	var header [recordHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err == io.EOF {
+		return nil, 0, io.EOF
+	} else if err == io.ErrUnexpectedEOF {
+		return nil, 0, errTornRecord
+	} else if err != nil {
+		return nil, 0, err
+	}
+
+	length := binary.LittleEndian.Uint32(header[:4])
+	if length > maxRecordSize {
+		return nil, 0, errTornRecord
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil, 0, errTornRecord
+	} else if err != nil {
+		return nil, 0, err
+	}
+	if crc32.Checksum(payload, crcTable) != binary.LittleEndian.Uint32(header[4:]) {
+		return nil, 0, errTornRecord
+	}
+
+	var e entry
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return nil, 0, errTornRecord
+	}
+	return &e, int64(recordHeaderSize + length), nil
+}
+
+// replayLog calls apply with each write logged in the file at path in
+// order, joining the entries of a write that spans several, and returns the
+// size of its intact prefix and whether anything follows it. Reading stops
+// at a torn or corrupt record; the caller discards it and everything after
+// it, including the earlier entries of a write it leaves incomplete.
+func replayLog(path string, apply func(*entry) error) (int64, bool, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to open log: %w", err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	size := int64(0)
+	// pending joins the partial entries of the current write, which starts
+	// at offset committed, the end of the last complete write
+	var pending *entry
+	committed := int64(0)
+	for {
+		e, n, err := readRecord(reader)
+		if err == io.EOF {
+			return committed, pending != nil, nil
+		}
+		if err == errTornRecord {
+			return committed, true, nil
+		}
+		if err != nil {
+			return committed, false, fmt.Errorf("failed to read log at offset %d: %w", size, err)
+		}
+		size += n
+
+		if pending != nil {
+			if e.Seq != pending.Seq || e.Delete != "" {
+				// Only a torn tail ends a write early, so a new write
+				// here means the log is corrupt
+				return committed, true, nil
+			}
+			pending.Ports = append(pending.Ports, e.Ports...)
+			pending.Partial = e.Partial
+			e = pending
+		}
+		if e.Partial {
+			pending = e
+			continue
+		}
+		pending = nil
+		if err := apply(e); err != nil {
+			return committed, false, fmt.Errorf("failed to replay log at offset %d: %w", committed, err)
+		}
+		committed = size
+	}
+}
+
+// wal appends records to the write-ahead log file
+type wal struct {
+	file *os.File
+	size int64
+
+	// dirty is set while appended records may not have been synced
+	dirty bool
+}
+
+// openWAL opens the log at path for appending, truncating it to size to
+// drop any torn record after the intact prefix
+func openWAL(path string, size int64) (*wal, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log: %w", err)
+	}
+	if err := f.Truncate(size); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to truncate log: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to sync log: %w", err)
+	}
+	return &wal{file: f, size: size}, nil
+}
+
+// append writes record to the end of the log, truncating away whatever part
+// of it was written if the write fails
+func (w *wal) append(record []byte) error {
+	n, err := w.file.Write(record)
+	if err == nil {
+		w.size += int64(n)
+		w.dirty = true
+		return nil
+	}
+	if truncErr := w.file.Truncate(w.size); truncErr != nil {
+		return fmt.Errorf("failed to append to log: %w (and to truncate it: %v)", err, truncErr)
+	}
+	return fmt.Errorf("failed to append to log: %w", err)
+}
+
+// truncate cuts the log back to size, dropping the records after it
+func (w *wal) truncate(size int64) error {
+	if err := w.file.Truncate(size); err != nil {
+		return fmt.Errorf("failed to truncate log: %w", err)
+	}
+	w.size = size
+	return nil
+}
+
+// sync flushes appended records to stable storage
+func (w *wal) sync() error {
+	if !w.dirty {
+		return nil
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync log: %w", err)
+	}
+	w.dirty = false
+	return nil
+}
+
+// reset empties the log once a snapshot holds everything in it
+func (w *wal) reset() error {
+	if err := w.file.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate log: %w", err)
+	}
+	w.size = 0
+	w.dirty = true
+	return w.sync()
+}
+
+// close syncs and closes the log file
+func (w *wal) close() error {
+	if err := w.sync(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
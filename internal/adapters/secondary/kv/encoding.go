@@ -0,0 +1,182 @@
+package kv
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"portservice/internal/domain"
+)
+
+// encodingVersion is the first byte of every encoded port, so the layout
+// can change without misreading stored ports
+const encodingVersion = 1
+
+// ErrCorruptPort is returned when a stored port cannot be decoded
+var ErrCorruptPort = errors.New("corrupt port record")
+
+// encodePort appends the compact binary form of a port to buf. The ID is
+// the record's key and is not repeated. Strings and lists are prefixed with
+// their uvarint length, coordinates are little-endian float64 bits, and the
+// deletion time is a flag byte followed by varint Unix nanoseconds.
+func encodePort(buf []byte, port *domain.Port) []byte {
+	buf = append(buf, encodingVersion)
+	buf = appendString(buf, port.Name)
+	buf = appendString(buf, port.City)
+	buf = appendString(buf, port.Country)
+	buf = appendStrings(buf, port.Alias)
+	buf = appendStrings(buf, port.Regions)
+	buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(port.Coordinates.Longitude))
+	buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(port.Coordinates.Latitude))
+	buf = appendString(buf, port.Province)
+	buf = appendString(buf, port.Timezone)
+	buf = appendStrings(buf, port.Unlocs)
+	buf = appendString(buf, port.Code)
+	buf = binary.AppendUvarint(buf, uint64(len(port.Functions)))
+	for _, function := range port.Functions {
+		buf = appendString(buf, string(function))
+	}
+	if port.DeletedAt == nil {
+		return append(buf, 0)
+	}
+	buf = append(buf, 1)
+	return binary.AppendVarint(buf, port.DeletedAt.UnixNano())
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// appendStrings appends a list as its length followed by its values. Nil
+// and empty lists encode alike and decode as nil, as domain.NewPort uses.
+func appendStrings(buf []byte, values []string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(values)))
+	for _, value := range values {
+		buf = appendString(buf, value)
+	}
+	return buf
+}
+
+// decoder reads the fields of an encoded port, remembering the first error
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) fail() {
+	if d.err == nil {
+		d.err = ErrCorruptPort
+	}
+	d.data = nil
+}
+
+func (d *decoder) byte() byte {
+	if len(d.data) < 1 {
+		d.fail()
+		return 0
+	}
+	b := d.data[0]
+	d.data = d.data[1:]
+	return b
+}
+
+func (d *decoder) uvarint() uint64 {
+	value, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+	d.data = d.data[n:]
+	return value
+}
+
+func (d *decoder) varint() int64 {
+	value, n := binary.Varint(d.data)
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+	d.data = d.data[n:]
+	return value
+}
+
+func (d *decoder) float64() float64 {
+	if len(d.data) < 8 {
+		d.fail()
+		return 0
+	}
+	value := math.Float64frombits(binary.LittleEndian.Uint64(d.data))
+	d.data = d.data[8:]
+	return value
+}
+
+func (d *decoder) string() string {
+	n := d.uvarint()
+	if n > uint64(len(d.data)) {
+		d.fail()
+		return ""
+	}
+	s := string(d.data[:n])
+	d.data = d.data[n:]
+	return s
+}
+
+func (d *decoder) strings() []string {
+	n := d.uvarint()
+	// Each value takes at least its length byte, which bounds a corrupt
+	// count before it is allocated
+	if n > uint64(len(d.data)) {
+		d.fail()
+		return nil
+	}
+	if n == 0 {
+		return nil
+	}
+	values := make([]string, n)
+	for i := range values {
+		values[i] = d.string()
+	}
+	return values
+}
+
+// decodePort decodes a port encoded with encodePort and stored under id.
+// The port copies what it needs, so data may be reused afterwards.
+func decodePort(id string, data []byte) (*domain.Port, error) {
+	d := &decoder{data: data}
+	if version := d.byte(); d.err == nil && version != encodingVersion {
+		return nil, fmt.Errorf("%w: port %s has unknown encoding version %d", ErrCorruptPort, id, version)
+	}
+
+	port := &domain.Port{ID: id}
+	port.Name = d.string()
+	port.City = d.string()
+	port.Country = d.string()
+	port.Alias = d.strings()
+	port.Regions = d.strings()
+	port.Coordinates = &domain.Coordinate{Longitude: d.float64(), Latitude: d.float64()}
+	port.Province = d.string()
+	port.Timezone = d.string()
+	port.Unlocs = d.strings()
+	port.Code = d.string()
+	for _, function := range d.strings() {
+		port.Functions = append(port.Functions, domain.PortFunction(function))
+	}
+	switch d.byte() {
+	case 0:
+	case 1:
+		deletedAt := time.Unix(0, d.varint())
+		port.DeletedAt = &deletedAt
+	default:
+		d.fail()
+	}
+	if d.err == nil && len(d.data) > 0 {
+		d.fail()
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("%w: port %s", d.err, id)
+	}
+	return port, nil
+}
@@ -0,0 +1,63 @@
+package kv
+
+import (
+	"testing"
+	"time"
+
+	"portservice/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodePort(t *testing.T) {
+	port, err := domain.NewPort("AEAJM", "Ajman", "Ajman", "United Arab Emirates",
+		[]float64{55.5136433, 25.4052165}, "Ajman", "Asia/Dubai", []string{"AEAJM", "AEAJX"}, "52000")
+	assert.NoError(t, err)
+	port.Alias = []string{"ajman", "عجمان"}
+	port.Regions = []string{"Gulf"}
+	port.Functions = []domain.PortFunction{domain.FunctionPort, domain.FunctionRail}
+
+	data := encodePort(nil, port)
+	decoded, err := decodePort("AEAJM", data)
+	assert.NoError(t, err)
+	assert.Equal(t, port, decoded)
+
+	// Tombstones keep their deletion time
+	deletedAt := time.Unix(0, 1700000000123456789)
+	port.DeletedAt = &deletedAt
+	decoded, err = decodePort("AEAJM", encodePort(nil, port))
+	assert.NoError(t, err)
+	if assert.NotNil(t, decoded.DeletedAt) {
+		assert.True(t, deletedAt.Equal(*decoded.DeletedAt))
+	}
+
+	// Empty lists decode as nil
+	minimal := &domain.Port{ID: "X", Alias: []string{}, Coordinates: &domain.Coordinate{}}
+	decoded, err = decodePort("X", encodePort(nil, minimal))
+	assert.NoError(t, err)
+	assert.Nil(t, decoded.Alias)
+	assert.Nil(t, decoded.Functions)
+}
+
+func TestDecodePort_Corrupt(t *testing.T) {
+	port, _ := domain.NewPort("AEAJM", "Ajman", "Ajman", "United Arab Emirates",
+		[]float64{55.5136433, 25.4052165}, "Ajman", "Asia/Dubai", []string{"AEAJM"}, "52000")
+	data := encodePort(nil, port)
+
+	// Every truncation fails rather than panicking or decoding a partial port
+	for n := 0; n < len(data); n++ {
+		_, err := decodePort("AEAJM", data[:n])
+		assert.ErrorIs(t, err, ErrCorruptPort, n)
+	}
+
+	_, err := decodePort("AEAJM", append(data, 0))
+	assert.ErrorIs(t, err, ErrCorruptPort)
+
+	unknown := append([]byte{encodingVersion + 1}, data[1:]...)
+	_, err = decodePort("AEAJM", unknown)
+	assert.ErrorIs(t, err, ErrCorruptPort)
+
+	// A huge list length is rejected before it is allocated
+	_, err = decodePort("AEAJM", []byte{encodingVersion, 0, 0, 0, 0xff, 0xff, 0xff, 0xff, 0x0f})
+	assert.ErrorIs(t, err, ErrCorruptPort)
+}
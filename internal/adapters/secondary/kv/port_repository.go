@@ -0,0 +1,496 @@
+package kv
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"portservice/internal/domain"
+	"portservice/internal/ports/out"
+)
+
+// openTimeout is how long opening waits for another process to release the
+// database file lock
+const openTimeout = time.Second
+
+// Bucket names. Index keys are the normalized filter value, a zero byte and
+// the port ID, with empty values, so the ports with one value form a key
+// prefix whose entries are already ordered by ID.
+var (
+	portsBucket   = []byte("ports")
+	countryBucket = []byte("country")
+	unlocBucket   = []byte("unloc")
+	metaBucket    = []byte("meta")
+)
+
+// Keys of the counters in the meta bucket, stored as big-endian uint64s
+var (
+	livePortsKey    = []byte("live_ports")
+	totalUpdatesKey = []byte("total_updates")
+	totalDeletesKey = []byte("total_deletes")
+	lastUpdateKey   = []byte("last_update")
+)
+
+// indexSeparator ends the value part of an index key. A value containing it
+// can only add candidates under a shorter value's prefix, which the filter
+// check on each port then drops.
+const indexSeparator = 0
+
+// ctxCheckInterval is how many records a scan reads between checks of its
+// context
+const ctxCheckInterval = 1024
+
+// PortRepository implements out.PortRepository on an embedded bbolt
+// key-value store, so the dataset can grow well beyond memory. Ports are
+// stored in a compact binary encoding keyed by ID, with secondary index
+// buckets for country and UN/LOCODE.
+type PortRepository struct {
+	db *bolt.DB
+
+	// softDelete tombstones ports on delete instead of removing them
+	softDelete bool
+}
+
+// Option configures a PortRepository
+type Option func(*PortRepository)
+
+// WithSoftDelete makes DeletePort tombstone ports with a deletion timestamp
+// instead of removing them, as memory.WithSoftDelete
+func WithSoftDelete() Option {
+	return func(r *PortRepository) {
+		r.softDelete = true
+	}
+}
+
+// NewPortRepository opens the database file at path, creating it if needed
+func NewPortRepository(path string, opts ...Option) (out.PortRepository, error) {
+	r := &PortRepository{}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	db, err := bolt.Open(path, 0o644, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{portsBucket, countryBucket, unlocBucket, metaBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create buckets: %w", err)
+	}
+	r.db = db
+	return r, nil
+}
+
+// SavePort saves or updates a port in the repository
+func (r *PortRepository) SavePort(ctx context.Context, port *domain.Port) error {
+	result, err := r.SavePorts(ctx, []*domain.Port{port})
+	if err != nil {
+		return err
+	}
+	return result.FirstError()
+}
+
+// SavePorts saves the valid ports of a batch in one database transaction,
+// skipping and reporting ports that fail validation
+func (r *PortRepository) SavePorts(ctx context.Context, ports []*domain.Port) (domain.BatchResult, error) {
+	if ctx.Err() != nil {
+		return domain.BatchResult{}, ctx.Err()
+	}
+	stored, result := domain.PrepareBatch(ports)
+	if err := r.store(ctx, stored); err != nil {
+		return domain.BatchResult{}, err
+	}
+	return result, nil
+}
+
+// store writes validated ports in one database transaction, in order
+func (r *PortRepository) store(ctx context.Context, ports []*domain.Port) error {
+	if len(ports) == 0 {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		var live int64
+		for _, port := range ports {
+			previous, err := getPort(tx, port.ID)
+			if err != nil {
+				return err
+			}
+			if previous != nil {
+				if !previous.IsDeleted() {
+					live--
+				}
+				if err := unindex(tx, previous); err != nil {
+					return err
+				}
+			}
+			if !port.IsDeleted() {
+				live++
+			}
+			// Values must stay unchanged until the transaction commits, so
+			// each port gets its own buffer
+			if err := tx.Bucket(portsBucket).Put([]byte(port.ID), encodePort(nil, port)); err != nil {
+				return err
+			}
+			if err := index(tx, port); err != nil {
+				return err
+			}
+		}
+		meta := tx.Bucket(metaBucket)
+		return putCounters(meta,
+			counter{livePortsKey, getCounter(meta, livePortsKey) + live},
+			counter{totalUpdatesKey, getCounter(meta, totalUpdatesKey) + int64(len(ports))},
+			counter{lastUpdateKey, time.Now().UnixNano()},
+		)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save ports: %w", err)
+	}
+	return nil
+}
+
+// indexKeys returns the keys of a port in the country and UN/LOCODE indexes
+func indexKeys(port *domain.Port) (country [][]byte, unlocs [][]byte) {
+	country = [][]byte{indexKey(port.Country, port.ID)}
+	for _, unloc := range port.Unlocs {
+		unlocs = append(unlocs, indexKey(unloc, port.ID))
+	}
+	return country, unlocs
+}
+
+// indexKey returns the index key of a port ID under a field value
+func indexKey(value, id string) []byte {
+	key := indexPrefix(value)
+	return append(key, id...)
+}
+
+// indexPrefix returns the prefix shared by the index keys of a field value
+func indexPrefix(value string) []byte {
+	key := []byte(domain.NormalizeFilterValue(value))
+	return append(key, indexSeparator)
+}
+
+// index adds a port to the secondary indexes
+func index(tx *bolt.Tx, port *domain.Port) error {
+	country, unlocs := indexKeys(port)
+	for _, idx := range []struct {
+		bucket []byte
+		keys   [][]byte
+	}{{countryBucket, country}, {unlocBucket, unlocs}} {
+		bucket := tx.Bucket(idx.bucket)
+		for _, key := range idx.keys {
+			if err := bucket.Put(key, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// unindex removes a port from the secondary indexes
+func unindex(tx *bolt.Tx, port *domain.Port) error {
+	country, unlocs := indexKeys(port)
+	for _, idx := range []struct {
+		bucket []byte
+		keys   [][]byte
+	}{{countryBucket, country}, {unlocBucket, unlocs}} {
+		bucket := tx.Bucket(idx.bucket)
+		for _, key := range idx.keys {
+			if err := bucket.Delete(key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// getPort reads a port within tx, returning nil if it is not stored
+func getPort(tx *bolt.Tx, id string) (*domain.Port, error) {
+	data := tx.Bucket(portsBucket).Get([]byte(id))
+	if data == nil {
+		return nil, nil
+	}
+	return decodePort(id, data)
+}
+
+// counter is a named counter of the meta bucket
+type counter struct {
+	key   []byte
+	value int64
+}
+
+func getCounter(meta *bolt.Bucket, key []byte) int64 {
+	data := meta.Get(key)
+	if len(data) != 8 {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(data))
+}
+
+func putCounters(meta *bolt.Bucket, counters ...counter) error {
+	for _, c := range counters {
+		if err := meta.Put(c.key, binary.BigEndian.AppendUint64(nil, uint64(c.value))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BeginTx starts a transaction that stages port writes in memory and saves
+// them in one database transaction on Commit, so imports don't hold the
+// single writer lock while they run
+func (r *PortRepository) BeginTx(ctx context.Context) (out.PortTx, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return out.NewStagedTx(r.store), nil
+}
+
+// GetPort retrieves a port by its ID, hiding soft-deleted ports
+func (r *PortRepository) GetPort(ctx context.Context, id string) (*domain.Port, error) {
+	port, err := r.GetPortIncludingDeleted(ctx, id)
+	if err != nil || port == nil || port.IsDeleted() {
+		return nil, err
+	}
+	return port, nil
+}
+
+// GetPortIncludingDeleted retrieves a port by its ID, including soft-deleted ports
+func (r *PortRepository) GetPortIncludingDeleted(ctx context.Context, id string) (*domain.Port, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	var port *domain.Port
+	err := r.db.View(func(tx *bolt.Tx) error {
+		var err error
+		port, err = getPort(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get port %s: %w", id, err)
+	}
+	return port, nil
+}
+
+// DeletePort removes a port from the repository, or tombstones it when
+// soft delete is enabled
+func (r *PortRepository) DeletePort(ctx context.Context, id string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	now := time.Now()
+	return r.db.Update(func(tx *bolt.Tx) error {
+		port, err := getPort(tx, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete port %s: %w", id, err)
+		}
+		if port == nil || port.IsDeleted() {
+			return fmt.Errorf("%w: %s", domain.ErrPortNotFound, id)
+		}
+
+		if r.softDelete {
+			// Tombstones stay indexed, for listings that include them
+			port.DeletedAt = &now
+			err = tx.Bucket(portsBucket).Put([]byte(id), encodePort(nil, port))
+		} else {
+			if err = unindex(tx, port); err == nil {
+				err = tx.Bucket(portsBucket).Delete([]byte(id))
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete port %s: %w", id, err)
+		}
+
+		meta := tx.Bucket(metaBucket)
+		return putCounters(meta,
+			counter{livePortsKey, getCounter(meta, livePortsKey) - 1},
+			counter{totalDeletesKey, getCounter(meta, totalDeletesKey) + 1},
+			counter{lastUpdateKey, now.UnixNano()},
+		)
+	})
+}
+
+// ListPorts returns a page of ports matching the filter, ordered by port ID.
+// A UN/LOCODE or country criterion walks the matching index prefixes, so
+// only candidate ports are read; other criteria are checked on each port.
+func (r *PortRepository) ListPorts(ctx context.Context, filter domain.PortFilter, cursor string, limit int) (domain.PortPage, error) {
+	afterID, err := domain.DecodeCursor(cursor)
+	if err != nil {
+		return domain.PortPage{}, err
+	}
+	limit = domain.NormalizeLimit(limit)
+
+	page := domain.PortPage{Ports: make([]*domain.Port, 0, limit)}
+	err = r.db.View(func(tx *bolt.Tx) error {
+		ids := r.candidates(tx, filter, afterID)
+		ports := tx.Bucket(portsBucket)
+		for scanned := 1; ; scanned++ {
+			if scanned%ctxCheckInterval == 0 && ctx.Err() != nil {
+				return ctx.Err()
+			}
+			id, data := ids()
+			if id == nil {
+				return nil
+			}
+			if data == nil {
+				data = ports.Get(id)
+				if data == nil {
+					continue
+				}
+			}
+			port, err := decodePort(string(id), data)
+			if err != nil {
+				return err
+			}
+			if !filter.Matches(port) {
+				continue
+			}
+			if len(page.Ports) == limit {
+				page.NextCursor = domain.EncodeCursor(page.Ports[limit-1].ID)
+				return nil
+			}
+			page.Ports = append(page.Ports, port)
+		}
+	})
+	if err != nil {
+		return domain.PortPage{}, fmt.Errorf("failed to list ports: %w", err)
+	}
+	return page, nil
+}
+
+// idIterator yields port IDs in ascending order, and nil when done. It also
+// yields the encoded port when it has it at hand, or nil for the caller to
+// look it up.
+type idIterator func() (id, data []byte)
+
+// candidates returns the IDs after afterID of the ports that may match the
+// filter, using the most selective index the filter allows
+func (r *PortRepository) candidates(tx *bolt.Tx, filter domain.PortFilter, afterID string) idIterator {
+	switch {
+	case len(filter.Unlocs) > 0:
+		return indexIDs(tx.Bucket(unlocBucket), filter.Unlocs, afterID)
+	case len(filter.Countries) > 0:
+		return indexIDs(tx.Bucket(countryBucket), filter.Countries, afterID)
+	default:
+		return allIDs(tx.Bucket(portsBucket), afterID)
+	}
+}
+
+// allIDs iterates over every port after afterID
+func allIDs(ports *bolt.Bucket, afterID string) idIterator {
+	c := ports.Cursor()
+	var key, value []byte
+	started := false
+	return func() ([]byte, []byte) {
+		if !started {
+			started = true
+			key, value = c.Seek([]byte(afterID))
+			if key != nil && string(key) == afterID {
+				key, value = c.Next()
+			}
+		} else {
+			key, value = c.Next()
+		}
+		return key, value
+	}
+}
+
+// indexIDs merges the index prefixes of values into one ascending stream of
+// port IDs after afterID, yielding a port listed under several values once
+func indexIDs(bucket *bolt.Bucket, values []string, afterID string) idIterator {
+	type prefixCursor struct {
+		cursor *bolt.Cursor
+		prefix []byte
+		id     []byte
+	}
+	// next moves a cursor to its following key, ending it past its prefix
+	next := func(pc *prefixCursor, key []byte) {
+		if key == nil || !bytes.HasPrefix(key, pc.prefix) {
+			pc.id = nil
+			return
+		}
+		pc.id = key[len(pc.prefix):]
+	}
+
+	var cursors []*prefixCursor
+	seen := make(map[string]bool)
+	for _, value := range values {
+		prefix := indexPrefix(value)
+		if seen[string(prefix)] {
+			continue
+		}
+		seen[string(prefix)] = true
+
+		pc := &prefixCursor{cursor: bucket.Cursor(), prefix: prefix}
+		key, _ := pc.cursor.Seek(append(append([]byte(nil), prefix...), afterID...))
+		next(pc, key)
+		if pc.id != nil && string(pc.id) == afterID {
+			key, _ = pc.cursor.Next()
+			next(pc, key)
+		}
+		cursors = append(cursors, pc)
+	}
+
+	return func() ([]byte, []byte) {
+		var lowest []byte
+		for _, pc := range cursors {
+			if pc.id != nil && (lowest == nil || bytes.Compare(pc.id, lowest) < 0) {
+				lowest = pc.id
+			}
+		}
+		if lowest == nil {
+			return nil, nil
+		}
+		// Copy before advancing, since the key belongs to the cursor
+		id := append([]byte(nil), lowest...)
+		for _, pc := range cursors {
+			if pc.id != nil && bytes.Equal(pc.id, id) {
+				key, _ := pc.cursor.Next()
+				next(pc, key)
+			}
+		}
+		return id, nil
+	}
+}
+
+// GetStatistics returns the repository statistics, which are kept in the
+// database so counting ports needs no scan
+func (r *PortRepository) GetStatistics() out.RepositoryStats {
+	var stats out.RepositoryStats
+	var lastUpdate int64
+	err := r.db.View(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(metaBucket)
+		stats.TotalPorts = getCounter(meta, livePortsKey)
+		stats.TotalUpdates = getCounter(meta, totalUpdatesKey)
+		stats.TotalDeletes = getCounter(meta, totalDeletesKey)
+		lastUpdate = getCounter(meta, lastUpdateKey)
+		return nil
+	})
+	if err != nil {
+		return out.RepositoryStats{}
+	}
+	stats.LastUpdate = time.Unix(0, lastUpdate).Format(time.RFC3339)
+	return stats
+}
+
+// Close closes the database, waiting for open transactions to finish
+func (r *PortRepository) Close(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return r.db.Close()
+}
@@ -0,0 +1,111 @@
+package kv
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+
+	"portservice/internal/domain"
+	"portservice/internal/ports/out"
+	"portservice/internal/ports/out/outtest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// openRepository opens the database at path, failing the test on error
+func openRepository(t *testing.T, path string, opts ...Option) *PortRepository {
+	t.Helper()
+	repo, err := NewPortRepository(path, opts...)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { repo.Close(context.Background()) })
+	return repo.(*PortRepository)
+}
+
+// indexEntries returns the keys of an index bucket
+func indexEntries(t *testing.T, repo *PortRepository, bucket []byte) []string {
+	t.Helper()
+	var keys []string
+	assert.NoError(t, repo.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).ForEach(func(key, _ []byte) error {
+			keys = append(keys, string(key))
+			return nil
+		})
+	}))
+	return keys
+}
+
+// storage gives each test a database file of its own
+func storage(t *testing.T) outtest.Opener {
+	path := filepath.Join(t.TempDir(), "ports.db")
+	return func(softDelete bool) out.PortRepository {
+		if softDelete {
+			return openRepository(t, path, WithSoftDelete())
+		}
+		return openRepository(t, path)
+	}
+}
+
+func TestPortRepository(t *testing.T) {
+	outtest.TestPortRepository(t, storage)
+}
+
+func TestPortRepository_Persistence(t *testing.T) {
+	outtest.TestPersistence(t, storage)
+	outtest.TestPersistentStatistics(t, storage)
+}
+
+func TestPortRepository_Indexes(t *testing.T) {
+	repo := openRepository(t, filepath.Join(t.TempDir(), "ports.db"))
+	ctx := context.Background()
+
+	port, err := domain.NewPort("AEAJM", "Ajman", "Ajman", "United Arab Emirates",
+		[]float64{55.5136433, 25.4052165}, "Ajman", "Asia/Dubai", []string{"AEAJM", "AEAJX"}, "52000")
+	assert.NoError(t, err)
+	assert.NoError(t, repo.SavePort(ctx, port))
+	assert.Equal(t, []string{"united arab emirates\x00AEAJM"}, indexEntries(t, repo, countryBucket))
+	assert.Equal(t, []string{"aeajm\x00AEAJM", "aeajx\x00AEAJM"}, indexEntries(t, repo, unlocBucket))
+
+	// Updates move the port in the indexes
+	assert.NoError(t, repo.SavePort(ctx, outtest.NewPort("AEAJM", "Ajman Port")))
+	assert.Equal(t, []string{"test country\x00AEAJM"}, indexEntries(t, repo, countryBucket))
+	assert.Equal(t, []string{"aeajm\x00AEAJM"}, indexEntries(t, repo, unlocBucket))
+
+	// and deletes remove it
+	assert.NoError(t, repo.DeletePort(ctx, "AEAJM"))
+	assert.Empty(t, indexEntries(t, repo, countryBucket))
+	assert.Empty(t, indexEntries(t, repo, unlocBucket))
+}
+
+func TestPortRepository_ListPorts_IndexPagination(t *testing.T) {
+	repo := openRepository(t, filepath.Join(t.TempDir(), "ports.db"))
+	ctx := context.Background()
+
+	// Interleave the ports of two countries so a page merges both prefixes
+	var ports []*domain.Port
+	for i := 0; i < 10; i++ {
+		port := outtest.NewPort(fmt.Sprintf("P%02d", i), "Port")
+		port.Country = []string{"Netherlands", "Belgium", "Germany"}[i%3]
+		ports = append(ports, port)
+	}
+	_, err := repo.SavePorts(ctx, ports)
+	assert.NoError(t, err)
+
+	filter := domain.PortFilter{Countries: []string{"Netherlands", "Belgium"}}
+	var ids []string
+	cursor := ""
+	for {
+		page, err := repo.ListPorts(ctx, filter, cursor, 3)
+		assert.NoError(t, err)
+		ids = append(ids, outtest.PortIDs(page.Ports)...)
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	assert.Equal(t, []string{"P00", "P01", "P03", "P04", "P06", "P07", "P09"}, ids)
+}
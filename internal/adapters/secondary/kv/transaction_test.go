@@ -0,0 +1,31 @@
+package kv
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"portservice/internal/ports/out/outtest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPortTx_CommitFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ports.kv")
+	repo := openRepository(t, path)
+	ctx := context.Background()
+
+	tx, err := repo.BeginTx(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.SavePort(ctx, outtest.NewPort("AEAJM", "Ajman")))
+
+	// Committing after the database is closed fails and stores nothing
+	assert.NoError(t, repo.Close(ctx))
+	assert.Error(t, tx.Commit(ctx))
+
+	repo = openRepository(t, path)
+	port, err := repo.GetPort(ctx, "AEAJM")
+	assert.NoError(t, err)
+	assert.Nil(t, port)
+	assert.Zero(t, repo.GetStatistics().TotalUpdates)
+}
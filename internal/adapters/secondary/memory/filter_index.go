@@ -0,0 +1,140 @@
+package memory
+
+import (
+	"sort"
+
+	"portservice/internal/domain"
+)
+
+// idSet is a set of port IDs
+type idSet map[string]struct{}
+
+// fieldIndex maps a normalized field value to the IDs of ports holding it
+type fieldIndex map[string]idSet
+
+// add records that the port with the given ID holds value. Empty values
+// are indexed too, as PortFilter.Matches lets "" select ports without one.
+func (fi fieldIndex) add(value, id string) {
+	key := domain.NormalizeFilterValue(value)
+	ids, ok := fi[key]
+	if !ok {
+		ids = make(idSet)
+		fi[key] = ids
+	}
+	ids[id] = struct{}{}
+}
+
+// remove forgets that the port with the given ID holds value
+func (fi fieldIndex) remove(value, id string) {
+	key := domain.NormalizeFilterValue(value)
+	if ids, ok := fi[key]; ok {
+		delete(ids, id)
+		if len(ids) == 0 {
+			delete(fi, key)
+		}
+	}
+}
+
+// union returns the IDs of ports holding any of the values
+func (fi fieldIndex) union(values []string) idSet {
+	result := make(idSet)
+	for _, value := range values {
+		for id := range fi[domain.NormalizeFilterValue(value)] {
+			result[id] = struct{}{}
+		}
+	}
+	return result
+}
+
+// filterIndex holds the secondary indexes backing domain.PortFilter queries
+type filterIndex struct {
+	countries fieldIndex
+	provinces fieldIndex
+	timezones fieldIndex
+	codes     fieldIndex
+	unlocs    fieldIndex
+	regions   fieldIndex
+}
+
+// newFilterIndex creates an empty filterIndex
+func newFilterIndex() *filterIndex {
+	return &filterIndex{
+		countries: make(fieldIndex),
+		provinces: make(fieldIndex),
+		timezones: make(fieldIndex),
+		codes:     make(fieldIndex),
+		unlocs:    make(fieldIndex),
+		regions:   make(fieldIndex),
+	}
+}
+
+// add indexes every filterable field of the port
+func (idx *filterIndex) add(p *domain.Port) {
+	idx.countries.add(p.Country, p.ID)
+	idx.provinces.add(p.Province, p.ID)
+	idx.timezones.add(p.Timezone, p.ID)
+	idx.codes.add(p.Code, p.ID)
+	for _, unloc := range p.Unlocs {
+		idx.unlocs.add(unloc, p.ID)
+	}
+	for _, region := range p.Regions {
+		idx.regions.add(region, p.ID)
+	}
+}
+
+// remove drops every filterable field of the port from the indexes
+func (idx *filterIndex) remove(p *domain.Port) {
+	idx.countries.remove(p.Country, p.ID)
+	idx.provinces.remove(p.Province, p.ID)
+	idx.timezones.remove(p.Timezone, p.ID)
+	idx.codes.remove(p.Code, p.ID)
+	for _, unloc := range p.Unlocs {
+		idx.unlocs.remove(unloc, p.ID)
+	}
+	for _, region := range p.Regions {
+		idx.regions.remove(region, p.ID)
+	}
+}
+
+// candidates returns the sorted IDs of ports matching the field criteria of
+// the filter. Deleted ports are not excluded; callers still apply
+// filter.Matches to each candidate.
+func (idx *filterIndex) candidates(filter domain.PortFilter) []string {
+	var sets []idSet
+	for _, criterion := range []struct {
+		index  fieldIndex
+		values []string
+	}{
+		{idx.countries, filter.Countries},
+		{idx.provinces, filter.Provinces},
+		{idx.timezones, filter.Timezones},
+		{idx.codes, filter.Codes},
+		{idx.unlocs, filter.Unlocs},
+		{idx.regions, filter.Regions},
+	} {
+		if len(criterion.values) > 0 {
+			sets = append(sets, criterion.index.union(criterion.values))
+		}
+	}
+	if len(sets) == 0 {
+		return nil
+	}
+
+	// Intersect starting from the smallest set
+	sort.Slice(sets, func(i, j int) bool { return len(sets[i]) < len(sets[j]) })
+	ids := make([]string, 0, len(sets[0]))
+	for id := range sets[0] {
+		matched := true
+		for _, set := range sets[1:] {
+			if _, ok := set[id]; !ok {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
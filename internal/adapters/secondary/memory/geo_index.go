@@ -0,0 +1,162 @@
+package memory
+
+import (
+	"math"
+
+	"portservice/internal/domain"
+)
+
+// geoCellDegrees is the side length of a grid cell in degrees
+const geoCellDegrees = 1.0
+
+// kmPerDegreeLatitude is the great-circle length of one degree of latitude
+const kmPerDegreeLatitude = domain.EarthRadiusKm * math.Pi / 180
+
+// geoCell identifies a fixed-size latitude/longitude grid cell
+type geoCell struct {
+	lat int
+	lon int
+}
+
+// numLonCells is the number of grid cells around a parallel
+var numLonCells = int(360 / geoCellDegrees)
+
+// cellFor returns the grid cell containing the coordinate
+func cellFor(c *domain.Coordinate) geoCell {
+	lat := int(math.Floor((c.Latitude + 90) / geoCellDegrees))
+	if maxLat := int(180/geoCellDegrees) - 1; lat > maxLat {
+		lat = maxLat
+	}
+	lon := int(math.Floor((c.Longitude + 180) / geoCellDegrees))
+	return geoCell{lat: lat, lon: wrapLonCell(lon)}
+}
+
+// wrapLonCell maps any longitude cell index onto [0, numLonCells)
+func wrapLonCell(lon int) int {
+	lon %= numLonCells
+	if lon < 0 {
+		lon += numLonCells
+	}
+	return lon
+}
+
+// geoIndex is a grid-based spatial index over port coordinates. Each port ID
+// is bucketed into the cell containing its coordinate so radius queries only
+// visit the cells overlapping the search area.
+type geoIndex struct {
+	cells map[geoCell]idSet
+}
+
+// newGeoIndex creates an empty geoIndex
+func newGeoIndex() *geoIndex {
+	return &geoIndex{cells: make(map[geoCell]idSet)}
+}
+
+// add indexes the port's coordinate
+func (g *geoIndex) add(p *domain.Port) {
+	if p.Coordinates == nil {
+		return
+	}
+	cell := cellFor(p.Coordinates)
+	ids, ok := g.cells[cell]
+	if !ok {
+		ids = make(idSet)
+		g.cells[cell] = ids
+	}
+	ids[p.ID] = struct{}{}
+}
+
+// remove drops the port's coordinate from the index
+func (g *geoIndex) remove(p *domain.Port) {
+	if p.Coordinates == nil {
+		return
+	}
+	cell := cellFor(p.Coordinates)
+	if ids, ok := g.cells[cell]; ok {
+		delete(ids, p.ID)
+		if len(ids) == 0 {
+			delete(g.cells, cell)
+		}
+	}
+}
+
+// withinRadius calls fn for the ID of every port in cells that may lie
+// within radiusKm of center. Callers must still check the exact distance.
+func (g *geoIndex) withinRadius(center domain.Coordinate, radiusKm float64, fn func(id string)) {
+	if radiusKm >= domain.MaxDistanceKm {
+		for _, ids := range g.cells {
+			for id := range ids {
+				fn(id)
+			}
+		}
+		return
+	}
+
+	// Latitude extent of the search cap
+	dLat := radiusKm / kmPerDegreeLatitude
+	minLat := center.Latitude - dLat
+	maxLat := center.Latitude + dLat
+
+	// Longitude extent of the search cap; it spans every meridian when it
+	// contains a pole
+	allLon := minLat <= -90 || maxLat >= 90
+	var dLon float64
+	if !allLon {
+		ratio := math.Sin(radiusKm/domain.EarthRadiusKm) / math.Cos(domain.DegreesToRadians(center.Latitude))
+		if ratio >= 1 {
+			allLon = true
+		} else {
+			dLon = math.Asin(ratio) * 180 / math.Pi
+		}
+	}
+
+	minCell := cellFor(&domain.Coordinate{Latitude: math.Max(minLat, -90)})
+	maxCell := cellFor(&domain.Coordinate{Latitude: math.Min(maxLat, 90)})
+
+	var lonStart, lonCount int
+	if allLon || dLon >= 180 {
+		lonStart, lonCount = 0, numLonCells
+	} else {
+		lonStart = int(math.Floor((center.Longitude - dLon + 180) / geoCellDegrees))
+		lonEnd := int(math.Floor((center.Longitude + dLon + 180) / geoCellDegrees))
+		lonCount = lonEnd - lonStart + 1
+		if lonCount > numLonCells {
+			lonCount = numLonCells
+		}
+	}
+
+	for lat := minCell.lat; lat <= maxCell.lat; lat++ {
+		for i := 0; i < lonCount; i++ {
+			for id := range g.cells[geoCell{lat: lat, lon: wrapLonCell(lonStart + i)}] {
+				fn(id)
+			}
+		}
+	}
+}
+
+// withinBox calls fn for the ID of every port in cells overlapping the box.
+// Callers must still check that each port lies inside the box.
+func (g *geoIndex) withinBox(box domain.BoundingBox, fn func(id string)) {
+	minCell := cellFor(&domain.Coordinate{Longitude: box.MinLongitude, Latitude: box.MinLatitude})
+	maxCell := cellFor(&domain.Coordinate{Longitude: box.MaxLongitude, Latitude: box.MaxLatitude})
+
+	// Longitude cells run from the west edge eastwards, wrapping past the
+	// antimeridian when the box crosses it
+	lonStart := int(math.Floor((box.MinLongitude + 180) / geoCellDegrees))
+	lonEnd := int(math.Floor((box.MaxLongitude + 180) / geoCellDegrees))
+	if box.CrossesAntimeridian() {
+		lonEnd += numLonCells
+	}
+	lonCount := lonEnd - lonStart + 1
+	if lonCount > numLonCells {
+		lonCount = numLonCells
+	}
+
+	for lat := minCell.lat; lat <= maxCell.lat; lat++ {
+		for i := 0; i < lonCount; i++ {
+			for id := range g.cells[geoCell{lat: lat, lon: wrapLonCell(lonStart + i)}] {
+				fn(id)
+			}
+		}
+	}
+}
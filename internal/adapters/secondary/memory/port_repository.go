@@ -2,6 +2,9 @@ package memory
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -15,17 +18,54 @@ type PortRepository struct {
 	ports map[string]*domain.Port
 	mu    sync.RWMutex
 
+	// sortedIDs orders port IDs for listing; it is rebuilt lazily when
+	// idsDirty is set so bulk imports don't pay for repeated insertion
+	sortedIDs []string
+	idsDirty  bool
+
+	// filters indexes port fields for filtered listing
+	filters *filterIndex
+
+	// geo indexes port coordinates for spatial queries
+	geo *geoIndex
+
+	// search indexes port text for full-text search
+	search *searchIndex
+
+	// softDelete tombstones ports on delete instead of removing them
+	softDelete bool
+
 	// Statistics
 	totalPorts     atomic.Int64
 	totalUpdates   atomic.Int64
+	totalDeletes   atomic.Int64
 	lastUpdateTime atomic.Int64
 }
 
+// Option configures a PortRepository
+type Option func(*PortRepository)
+
+// WithSoftDelete makes DeletePort tombstone ports with a deletion timestamp
+// instead of removing them. Tombstoned ports are hidden from GetPort but can
+// still be retrieved with GetPortIncludingDeleted.
+func WithSoftDelete() Option {
+	return func(r *PortRepository) {
+		r.softDelete = true
+	}
+}
+
 // NewPortRepository creates a new instance of PortRepository
-func NewPortRepository() out.PortRepository {
-	return &PortRepository{
-		ports: make(map[string]*domain.Port),
+func NewPortRepository(opts ...Option) out.PortRepository {
+	r := &PortRepository{
+		ports:   make(map[string]*domain.Port),
+		filters: newFilterIndex(),
+		geo:     newGeoIndex(),
+		search:  newSearchIndex(),
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 // SavePort saves or updates a port in the repository
@@ -41,22 +81,97 @@ func (r *PortRepository) SavePort(ctx context.Context, port *domain.Port) error
 		r.mu.Lock()
 		defer r.mu.Unlock()
 
-		_, exists := r.ports[port.ID]
-		r.ports[port.ID] = port
+		// Store a copy so later changes by the caller can't desync the indexes
+		r.recordWrites(r.storeLocked(port.Clone()), 1)
+		return nil
+	}
+}
 
-		// Update statistics
-		if !exists {
-			r.totalPorts.Add(1)
-		}
-		r.totalUpdates.Add(1)
-		r.lastUpdateTime.Store(time.Now().UnixNano())
+// SavePorts saves or updates a batch of ports under a single lock
+// acquisition, skipping and reporting ports that fail validation
+func (r *PortRepository) SavePorts(ctx context.Context, ports []*domain.Port) (domain.BatchResult, error) {
+	if ctx.Err() != nil {
+		return domain.BatchResult{}, ctx.Err()
+	}
+	stored, result := domain.PrepareBatch(ports)
+	if err := r.store(ctx, stored); err != nil {
+		return domain.BatchResult{}, err
+	}
+	return result, nil
+}
 
-		return nil
+// store saves validated ports owned by the repository under a single lock
+// acquisition, in order
+func (r *PortRepository) store(ctx context.Context, ports []*domain.Port) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	liveDelta := int64(0)
+	for _, port := range ports {
+		liveDelta += r.storeLocked(port)
+	}
+	r.recordWrites(liveDelta, len(ports))
+	return nil
+}
+
+// storeLocked saves a port owned by the repository and updates the indexes,
+// returning the change in the number of live ports. The caller must hold
+// the write lock.
+func (r *PortRepository) storeLocked(stored *domain.Port) int64 {
+	existing, exists := r.ports[stored.ID]
+	r.ports[stored.ID] = stored
+	if exists {
+		r.filters.remove(existing)
+		r.geo.remove(existing)
+		r.search.remove(existing)
+	} else {
+		r.idsDirty = true
+	}
+	r.filters.add(stored)
+	r.geo.add(stored)
+	r.search.add(stored)
+
+	// Count only live ports
+	wasLive := exists && !existing.IsDeleted()
+	isLive := !stored.IsDeleted()
+	switch {
+	case isLive && !wasLive:
+		return 1
+	case !isLive && wasLive:
+		return -1
 	}
+	return 0
 }
 
-// GetPort retrieves a port by its ID
+// recordWrites updates the statistics after ports were stored
+func (r *PortRepository) recordWrites(liveDelta int64, writes int) {
+	if writes == 0 {
+		return
+	}
+	r.totalPorts.Add(liveDelta)
+	r.totalUpdates.Add(int64(writes))
+	r.lastUpdateTime.Store(time.Now().UnixNano())
+}
+
+// BeginTx starts a transaction that stages port writes in memory until Commit
+func (r *PortRepository) BeginTx(ctx context.Context) (out.PortTx, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return out.NewStagedTx(r.store), nil
+}
+
+// GetPort retrieves a port by its ID, hiding soft-deleted ports
 func (r *PortRepository) GetPort(ctx context.Context, id string) (*domain.Port, error) {
+	port, err := r.GetPortIncludingDeleted(ctx, id)
+	if err != nil || port == nil || port.IsDeleted() {
+		return nil, err
+	}
+	return port, nil
+}
+
+// GetPortIncludingDeleted retrieves a port by its ID, including soft-deleted ports
+func (r *PortRepository) GetPortIncludingDeleted(ctx context.Context, id string) (*domain.Port, error) {
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
@@ -65,18 +180,260 @@ func (r *PortRepository) GetPort(ctx context.Context, id string) (*domain.Port,
 		defer r.mu.RUnlock()
 
 		if port, exists := r.ports[id]; exists {
-			return port, nil
+			return port.Clone(), nil
 		}
 		return nil, nil
 	}
 }
 
+// DeletePort removes a port from the repository, or tombstones it when
+// soft delete is enabled
+func (r *PortRepository) DeletePort(ctx context.Context, id string) error {
+	return r.DeletePortAt(ctx, id, time.Now())
+}
+
+// DeletePortAt deletes a port like DeletePort, recording now as the deletion
+// time. Adapters that persist deletes use it to replay them unchanged.
+func (r *PortRepository) DeletePortAt(ctx context.Context, id string, now time.Time) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		port, exists := r.ports[id]
+		if !exists || port.IsDeleted() {
+			return fmt.Errorf("%w: %s", domain.ErrPortNotFound, id)
+		}
+
+		if r.softDelete {
+			// Store a copy so callers holding the live port are unaffected
+			tombstone := *port
+			deletedAt := now.UTC()
+			tombstone.DeletedAt = &deletedAt
+			r.ports[id] = &tombstone
+		} else {
+			delete(r.ports, id)
+			r.filters.remove(port)
+			r.geo.remove(port)
+			r.search.remove(port)
+			r.idsDirty = true
+		}
+
+		// Update statistics
+		r.totalPorts.Add(-1)
+		r.totalDeletes.Add(1)
+		r.lastUpdateTime.Store(now.UnixNano())
+
+		return nil
+	}
+}
+
+// ListPorts returns a page of ports matching the filter, ordered by port ID
+func (r *PortRepository) ListPorts(ctx context.Context, filter domain.PortFilter, cursor string, limit int) (domain.PortPage, error) {
+	select {
+	case <-ctx.Done():
+		return domain.PortPage{}, ctx.Err()
+	default:
+		afterID, err := domain.DecodeCursor(cursor)
+		if err != nil {
+			return domain.PortPage{}, err
+		}
+		limit = domain.NormalizeLimit(limit)
+
+		r.rlockIndexed()
+		defer r.mu.RUnlock()
+
+		// Use the secondary indexes to avoid scanning every port
+		ids := r.sortedIDs
+		if filter.HasFieldCriteria() {
+			ids = r.filters.candidates(filter)
+		}
+
+		page := domain.PortPage{Ports: make([]*domain.Port, 0, limit)}
+		start := sort.SearchStrings(ids, afterID)
+		if start < len(ids) && afterID != "" && ids[start] == afterID {
+			start++
+		}
+		for _, id := range ids[start:] {
+			port, exists := r.ports[id]
+			if !exists || !filter.Matches(port) {
+				continue
+			}
+			if len(page.Ports) == limit {
+				page.NextCursor = domain.EncodeCursor(page.Ports[limit-1].ID)
+				break
+			}
+			page.Ports = append(page.Ports, port.Clone())
+		}
+		return page, nil
+	}
+}
+
+// FindWithinRadius returns live ports within radiusKm of center, nearest first
+func (r *PortRepository) FindWithinRadius(ctx context.Context, center domain.Coordinate, radiusKm float64) ([]domain.PortDistance, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+
+		results := r.withinRadius(center, radiusKm)
+		domain.SortByDistance(results)
+		return clonePortDistances(results), nil
+	}
+}
+
+// FindNearest returns the k live ports closest to center, nearest first
+func (r *PortRepository) FindNearest(ctx context.Context, center domain.Coordinate, k int) ([]domain.PortDistance, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		if k <= 0 {
+			return []domain.PortDistance{}, nil
+		}
+
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+
+		// Widen the search radius until it holds at least k ports; every port
+		// outside the radius is farther than every port inside it
+		radius := nearestInitialRadiusKm
+		for {
+			results := r.withinRadius(center, radius)
+			if len(results) >= k || radius >= domain.MaxDistanceKm {
+				domain.SortByDistance(results)
+				if len(results) > k {
+					results = results[:k]
+				}
+				return clonePortDistances(results), nil
+			}
+			radius = math.Min(radius*4, domain.MaxDistanceKm)
+		}
+	}
+}
+
+// FindInBoundingBox returns live ports inside the box, ordered by port ID
+func (r *PortRepository) FindInBoundingBox(ctx context.Context, box domain.BoundingBox) ([]*domain.Port, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+
+		results := make([]*domain.Port, 0)
+		r.geo.withinBox(box, func(id string) {
+			port, exists := r.ports[id]
+			if exists && !port.IsDeleted() && box.Contains(*port.Coordinates) {
+				results = append(results, port.Clone())
+			}
+		})
+		sort.Slice(results, func(i, j int) bool { return results[i].ID < results[j].ID })
+		return results, nil
+	}
+}
+
+// nearestInitialRadiusKm is the first radius tried by FindNearest
+const nearestInitialRadiusKm = 50.0
+
+// withinRadius collects live ports within radiusKm of center. The caller
+// must hold r.mu.
+func (r *PortRepository) withinRadius(center domain.Coordinate, radiusKm float64) []domain.PortDistance {
+	results := make([]domain.PortDistance, 0)
+	r.geo.withinRadius(center, radiusKm, func(id string) {
+		port, exists := r.ports[id]
+		if !exists || port.IsDeleted() {
+			return
+		}
+		if distance := center.DistanceKm(*port.Coordinates); distance <= radiusKm {
+			results = append(results, domain.PortDistance{Port: port, DistanceKm: distance})
+		}
+	})
+	return results
+}
+
+// clonePortDistances replaces the stored ports in results with copies, so
+// callers cannot modify the repository through them
+func clonePortDistances(results []domain.PortDistance) []domain.PortDistance {
+	for i := range results {
+		results[i].Port = results[i].Port.Clone()
+	}
+	return results
+}
+
+// SearchPorts returns live ports matching the text query, most relevant first
+func (r *PortRepository) SearchPorts(ctx context.Context, query string, limit int) ([]domain.SearchResult, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		terms := domain.Tokenize(query)
+		limit = domain.NormalizeLimit(limit)
+
+		r.rlockIndexed()
+		defer r.mu.RUnlock()
+
+		results := make([]domain.SearchResult, 0)
+		for id := range r.search.candidates(terms) {
+			port, exists := r.ports[id]
+			if !exists || port.IsDeleted() {
+				continue
+			}
+			if score := domain.ScoreSearch(terms, port); score > 0 {
+				results = append(results, domain.SearchResult{Port: port, Score: score})
+			}
+		}
+		domain.SortSearchResults(results)
+		if len(results) > limit {
+			results = results[:limit]
+		}
+		for i := range results {
+			results[i].Port = results[i].Port.Clone()
+		}
+		return results, nil
+	}
+}
+
+// rlockIndexed acquires the read lock once the lazily rebuilt indexes are up
+// to date. Callers must release it with r.mu.RUnlock.
+func (r *PortRepository) rlockIndexed() {
+	r.mu.RLock()
+	for r.idsDirty || r.search.vocabDirty {
+		r.mu.RUnlock()
+		r.rebuildIndexes()
+		r.mu.RLock()
+	}
+}
+
+// rebuildIndexes refreshes the ordered ID index and search vocabulary if stale
+func (r *PortRepository) rebuildIndexes() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.idsDirty {
+		r.sortedIDs = r.sortedIDs[:0]
+		for id := range r.ports {
+			r.sortedIDs = append(r.sortedIDs, id)
+		}
+		sort.Strings(r.sortedIDs)
+		r.idsDirty = false
+	}
+	if r.search.vocabDirty {
+		r.search.rebuildVocab()
+	}
+}
+
 // GetStatistics returns current repository statistics
 func (r *PortRepository) GetStatistics() out.RepositoryStats {
 	lastUpdate := time.Unix(0, r.lastUpdateTime.Load())
 	return out.RepositoryStats{
 		TotalPorts:   r.totalPorts.Load(),
 		TotalUpdates: r.totalUpdates.Load(),
+		TotalDeletes: r.totalDeletes.Load(),
 		LastUpdate:   lastUpdate.Format(time.RFC3339),
 	}
 }
@@ -95,6 +452,11 @@ func (r *PortRepository) Close(ctx context.Context) error {
 			delete(r.ports, k)
 		}
 		r.ports = nil
+		r.sortedIDs = nil
+		r.idsDirty = false
+		r.filters = newFilterIndex()
+		r.geo = newGeoIndex()
+		r.search = newSearchIndex()
 		return nil
 	}
 }
@@ -3,89 +3,71 @@ package memory
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"testing"
+	"time"
 
 	"portservice/internal/domain"
+	"portservice/internal/ports/out"
+	"portservice/internal/ports/out/outtest"
 
 	"github.com/stretchr/testify/assert"
 )
 
-func TestPortRepository_SaveAndGet(t *testing.T) {
+func TestPortRepository(t *testing.T) {
+	outtest.TestPortRepository(t, func(t *testing.T) outtest.Opener {
+		return func(softDelete bool) out.PortRepository {
+			if softDelete {
+				return NewPortRepository(WithSoftDelete())
+			}
+			return NewPortRepository()
+		}
+	})
+}
+
+func TestPortRepository_ReturnsCopies(t *testing.T) {
 	repo := NewPortRepository()
 	ctx := context.Background()
 
-	// Create a test port
-	coords := []float64{55.5136433, 25.4052165}
-	port, err := domain.NewPort("TEST1", "Test Port", "Test City", "Test Country", coords, "Test Province", "UTC", nil, "TEST")
+	port, err := domain.NewPort("AEAJM", "Ajman", "Ajman", "United Arab Emirates",
+		[]float64{55.5136433, 25.4052165}, "Ajman", "Asia/Dubai", []string{"AEAJM"}, "52000")
 	assert.NoError(t, err)
+	assert.NoError(t, repo.SavePort(ctx, port))
 
-	// Test saving
-	err = repo.SavePort(ctx, port)
-	assert.NoError(t, err)
+	// Modifying returned ports leaves the stored port unchanged
+	modify := func(p *domain.Port) {
+		p.Name = "Modified"
+		p.Unlocs[0] = "MODIFIED"
+		p.Coordinates.Latitude = 0
+	}
+	center := domain.Coordinate{Longitude: 55.5, Latitude: 25.4}
+	spatial := repo.(out.SpatialRepository)
 
-	// Test retrieval
-	retrieved, err := repo.GetPort(ctx, "TEST1")
+	got, err := repo.GetPort(ctx, "AEAJM")
 	assert.NoError(t, err)
-	assert.NotNil(t, retrieved)
-	assert.Equal(t, port.ID, retrieved.ID)
-	assert.Equal(t, port.Name, retrieved.Name)
-
-	// Test updating
-	port.Name = "Updated Port"
-	err = repo.SavePort(ctx, port)
+	modify(got)
+	got, err = repo.GetPortIncludingDeleted(ctx, "AEAJM")
 	assert.NoError(t, err)
-
-	// Test retrieving updated
-	retrieved, err = repo.GetPort(ctx, "TEST1")
+	modify(got)
+	page, err := repo.ListPorts(ctx, domain.PortFilter{}, "", 0)
 	assert.NoError(t, err)
-	assert.Equal(t, "Updated Port", retrieved.Name)
-
-	// Test non-existent port
-	retrieved, err = repo.GetPort(ctx, "NONEXISTENT")
+	modify(page.Ports[0])
+	nearest, err := spatial.FindNearest(ctx, center, 1)
 	assert.NoError(t, err)
-	assert.Nil(t, retrieved)
-}
-
-func TestPortRepository_Statistics(t *testing.T) {
-	repo := NewPortRepository()
-	ctx := context.Background()
-
-	// Create test ports
-	coords := []float64{55.5136433, 25.4052165}
-	port1, _ := domain.NewPort("TEST1", "Test Port 1", "Test City", "Test Country", coords, "", "", nil, "")
-	port2, _ := domain.NewPort("TEST2", "Test Port 2", "Test City", "Test Country", coords, "", "", nil, "")
-
-	// Initial stats should be zero
-	stats := repo.GetStatistics()
-	assert.Equal(t, int64(0), stats.TotalPorts)
-	assert.Equal(t, int64(0), stats.TotalUpdates)
-
-	// Save first port
-	err := repo.SavePort(ctx, port1)
+	modify(nearest[0].Port)
+	within, err := spatial.FindWithinRadius(ctx, center, 100)
 	assert.NoError(t, err)
-
-	// Check stats after first save
-	stats = repo.GetStatistics()
-	assert.Equal(t, int64(1), stats.TotalPorts)
-	assert.Equal(t, int64(1), stats.TotalUpdates)
-
-	// Save second port
-	err = repo.SavePort(ctx, port2)
+	modify(within[0].Port)
+	boxed, err := spatial.FindInBoundingBox(ctx, domain.BoundingBox{MinLongitude: 55, MinLatitude: 25, MaxLongitude: 56, MaxLatitude: 26})
 	assert.NoError(t, err)
-
-	// Check stats after second save
-	stats = repo.GetStatistics()
-	assert.Equal(t, int64(2), stats.TotalPorts)
-	assert.Equal(t, int64(2), stats.TotalUpdates)
-
-	// Update first port
-	err = repo.SavePort(ctx, port1)
+	modify(boxed[0])
+	found, err := repo.(out.SearchRepository).SearchPorts(ctx, "ajman", 0)
 	assert.NoError(t, err)
+	modify(found[0].Port)
 
-	// Check stats after update
-	stats = repo.GetStatistics()
-	assert.Equal(t, int64(2), stats.TotalPorts)
-	assert.Equal(t, int64(3), stats.TotalUpdates)
+	got, err = repo.GetPort(ctx, "AEAJM")
+	assert.NoError(t, err)
+	assert.Equal(t, port, got)
 }
 
 func TestPortRepository_ConcurrentAccess(t *testing.T) {
@@ -181,3 +163,188 @@ func TestPortRepository_Close(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Nil(t, retrieved)
 }
+
+func TestPortRepository_DeletePortAt(t *testing.T) {
+	repo := NewPortRepository(WithSoftDelete()).(*PortRepository)
+	ctx := context.Background()
+	port, _ := domain.NewPort("TEST1", "Test Port", "Test City", "Test Country", []float64{55.5, 25.4}, "", "", nil, "")
+	assert.NoError(t, repo.SavePort(ctx, port))
+
+	deletedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, repo.DeletePortAt(ctx, "TEST1", deletedAt))
+
+	retrieved, err := repo.GetPortIncludingDeleted(ctx, "TEST1")
+	assert.NoError(t, err)
+	if assert.NotNil(t, retrieved) && assert.NotNil(t, retrieved.DeletedAt) {
+		assert.Equal(t, deletedAt, *retrieved.DeletedAt)
+	}
+	assert.Equal(t, deletedAt.Local().Format(time.RFC3339), repo.GetStatistics().LastUpdate)
+}
+
+func TestPortRepository_SpatialQueries(t *testing.T) {
+	repo := NewPortRepository()
+	spatial := repo.(out.SpatialRepository)
+	ctx := context.Background()
+
+	// Spread ports over the globe, including the poles and antimeridian
+	rng := rand.New(rand.NewSource(42))
+	var ports []*domain.Port
+	for i := 0; i < 2000; i++ {
+		coords := []float64{rng.Float64()*360 - 180, rng.Float64()*180 - 90}
+		switch i {
+		case 0:
+			coords = []float64{179.9, 10}
+		case 1:
+			coords = []float64{-179.9, 10}
+		case 2:
+			coords = []float64{0, 90}
+		case 3:
+			coords = []float64{180, -90}
+		}
+		port, err := domain.NewPort(fmt.Sprintf("P%04d", i), "Port", "", "", coords, "", "", nil, "")
+		assert.NoError(t, err)
+		assert.NoError(t, repo.SavePort(ctx, port))
+		ports = append(ports, port)
+	}
+
+	bruteForce := func(center domain.Coordinate) []domain.PortDistance {
+		results := make([]domain.PortDistance, 0, len(ports))
+		for _, p := range ports {
+			results = append(results, domain.PortDistance{Port: p, DistanceKm: center.DistanceKm(*p.Coordinates)})
+		}
+		domain.SortByDistance(results)
+		return results
+	}
+
+	centers := []domain.Coordinate{
+		{Longitude: 179.95, Latitude: 10},
+		{Longitude: 0, Latitude: 89.5},
+		{Longitude: -120, Latitude: -89},
+		{Longitude: 55.27, Latitude: 25.25},
+	}
+	for i := 0; i < 20; i++ {
+		centers = append(centers, domain.Coordinate{Longitude: rng.Float64()*360 - 180, Latitude: rng.Float64()*180 - 90})
+	}
+
+	for _, center := range centers {
+		expected := bruteForce(center)
+
+		nearest, err := spatial.FindNearest(ctx, center, 5)
+		assert.NoError(t, err)
+		assert.Equal(t, distanceIDs(expected[:5]), distanceIDs(nearest), "nearest to %v", center)
+
+		for _, radius := range []float64{25, 500, 3000} {
+			within, err := spatial.FindWithinRadius(ctx, center, radius)
+			assert.NoError(t, err)
+
+			var want []domain.PortDistance
+			for _, d := range expected {
+				if d.DistanceKm <= radius {
+					want = append(want, d)
+				}
+			}
+			assert.Equal(t, distanceIDs(want), distanceIDs(within), "within %vkm of %v", radius, center)
+		}
+	}
+
+	for _, box := range []domain.BoundingBox{
+		{MinLongitude: 170, MinLatitude: -30, MaxLongitude: -170, MaxLatitude: 30},
+		{MinLongitude: -10, MinLatitude: 80, MaxLongitude: 10, MaxLatitude: 90},
+		{MinLongitude: -180, MinLatitude: -90, MaxLongitude: 180, MaxLatitude: 90},
+		{MinLongitude: 55.5, MinLatitude: 25.2, MaxLongitude: 55.6, MaxLatitude: 25.3},
+	} {
+		var want []string
+		for _, p := range ports {
+			if box.Contains(*p.Coordinates) {
+				want = append(want, p.ID)
+			}
+		}
+		inBox, err := spatial.FindInBoundingBox(ctx, box)
+		assert.NoError(t, err)
+		assert.Equal(t, len(want), len(inBox), "box %+v", box)
+		assert.ElementsMatch(t, want, outtest.PortIDs(inBox), "box %+v", box)
+	}
+
+	// The index follows moves and deletes
+	moved := ports[0].Clone()
+	moved.Coordinates = &domain.Coordinate{Longitude: 10, Latitude: 10}
+	assert.NoError(t, repo.SavePort(ctx, moved))
+	assert.NoError(t, repo.DeletePort(ctx, ports[1].ID))
+
+	within, err := spatial.FindWithinRadius(ctx, domain.Coordinate{Longitude: 180, Latitude: 10}, 30)
+	assert.NoError(t, err)
+	assert.Empty(t, within)
+
+	nearest, err := spatial.FindNearest(ctx, domain.Coordinate{Longitude: 10, Latitude: 10}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{ports[0].ID}, distanceIDs(nearest))
+
+	// Asking for more ports than stored returns them all
+	nearest, err = spatial.FindNearest(ctx, domain.Coordinate{}, 5000)
+	assert.NoError(t, err)
+	assert.Len(t, nearest, len(ports)-1)
+}
+
+func TestPortRepository_SearchPorts(t *testing.T) {
+	repo := NewPortRepository()
+	search := repo.(out.SearchRepository)
+	ctx := context.Background()
+	coords := []float64{55.5136433, 25.4052165}
+
+	ports := []struct{ id, name, city, province string }{
+		{"AEAUH", "Abu Dhabi", "Abu Dhabi", "Abu Z¸aby [Abu Dhabi]"},
+		{"AEDXB", "Dubai", "Dubai", "Dubayy [Dubai]"},
+		{"AEJEA", "Jebel Ali", "Jebel Ali", "Dubayy [Dubai]"},
+		{"AEAMU", "Abu Musa", "Abu Musa", ""},
+		{"NOAES", "Ålesund", "Ålesund", "Møre og Romsdal"},
+	}
+	for _, p := range ports {
+		port, err := domain.NewPort(p.id, p.name, p.city, "", coords, p.province, "", nil, "")
+		assert.NoError(t, err)
+		assert.NoError(t, repo.SavePort(ctx, port))
+	}
+
+	searchIDs := func(query string) []string {
+		results, err := search.SearchPorts(ctx, query, 10)
+		assert.NoError(t, err)
+		ids := make([]string, len(results))
+		for i, r := range results {
+			ids[i] = r.Port.ID
+		}
+		return ids
+	}
+
+	// Typos, with the port named Dubai ranked above ports only in its province
+	assert.Equal(t, []string{"AEDXB", "AEJEA"}, searchIDs("dubay"))
+	// Multi-term queries rank ports matching every term first
+	assert.Equal(t, []string{"AEAUH", "AEAMU"}, searchIDs("abu dabi"))
+	// Diacritic folding and prefixes
+	assert.Equal(t, []string{"NOAES"}, searchIDs("ales"))
+	assert.Equal(t, []string{"NOAES"}, searchIDs("Ålesund"))
+	assert.Equal(t, []string{"AEAUH"}, searchIDs("zaby"))
+	assert.Empty(t, searchIDs("rotterdam"))
+
+	// The index follows updates and deletes
+	renamed, _ := repo.GetPort(ctx, "AEAMU")
+	renamed = renamed.Clone()
+	renamed.Name, renamed.City = "Musa Island", "Musa Island"
+	assert.NoError(t, repo.SavePort(ctx, renamed))
+	assert.Equal(t, []string{"AEAUH"}, searchIDs("abu dabi"))
+
+	assert.NoError(t, repo.DeletePort(ctx, "AEDXB"))
+	assert.Equal(t, []string{"AEJEA"}, searchIDs("dubai"))
+
+	// Aliases are searchable
+	rashid, err := domain.NewPort("AEPRA", "Port Rashid", "Dubai", "", coords, "", "", nil, "", domain.WithAlias("Mina Rashid"))
+	assert.NoError(t, err)
+	assert.NoError(t, repo.SavePort(ctx, rashid))
+	assert.Equal(t, []string{"AEPRA"}, searchIDs("mina"))
+}
+
+func distanceIDs(results []domain.PortDistance) []string {
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.Port.ID
+	}
+	return ids
+}
@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"sort"
+	"strings"
+
+	"portservice/internal/domain"
+)
+
+// searchIndex is an inverted index from folded text tokens to the IDs of the
+// ports containing them. A sorted vocabulary supports prefix and fuzzy term
+// lookups; it is rebuilt lazily when vocabDirty is set.
+type searchIndex struct {
+	postings   map[string]idSet
+	vocab      []string
+	vocabDirty bool
+}
+
+// newSearchIndex creates an empty searchIndex
+func newSearchIndex() *searchIndex {
+	return &searchIndex{postings: make(map[string]idSet)}
+}
+
+// add indexes the searchable text of the port
+func (s *searchIndex) add(p *domain.Port) {
+	for _, token := range p.SearchTokens() {
+		ids, ok := s.postings[token]
+		if !ok {
+			ids = make(idSet)
+			s.postings[token] = ids
+			s.vocabDirty = true
+		}
+		ids[p.ID] = struct{}{}
+	}
+}
+
+// remove drops the searchable text of the port from the index
+func (s *searchIndex) remove(p *domain.Port) {
+	for _, token := range p.SearchTokens() {
+		if ids, ok := s.postings[token]; ok {
+			delete(ids, p.ID)
+			if len(ids) == 0 {
+				delete(s.postings, token)
+				s.vocabDirty = true
+			}
+		}
+	}
+}
+
+// rebuildVocab re-sorts the vocabulary after tokens were added or removed
+func (s *searchIndex) rebuildVocab() {
+	s.vocab = s.vocab[:0]
+	for token := range s.postings {
+		s.vocab = append(s.vocab, token)
+	}
+	sort.Strings(s.vocab)
+	s.vocabDirty = false
+}
+
+// candidates returns the IDs of ports with a token matching any query term
+// exactly, by prefix or within the term's edit distance budget
+func (s *searchIndex) candidates(terms []string) idSet {
+	result := make(idSet)
+	for _, term := range terms {
+		for _, token := range s.matchingTokens(term) {
+			for id := range s.postings[token] {
+				result[id] = struct{}{}
+			}
+		}
+	}
+	return result
+}
+
+// matchingTokens returns the vocabulary tokens matched by a query term
+func (s *searchIndex) matchingTokens(term string) []string {
+	var tokens []string
+
+	// Exact and prefix matches form a contiguous range of the sorted vocabulary
+	start := sort.SearchStrings(s.vocab, term)
+	end := start
+	for end < len(s.vocab) && strings.HasPrefix(s.vocab[end], term) {
+		end++
+	}
+	tokens = append(tokens, s.vocab[start:end]...)
+
+	maxDistance := domain.MaxEditDistance(term)
+	if maxDistance == 0 {
+		return tokens
+	}
+	termLen := len([]rune(term))
+	for i, token := range s.vocab {
+		if i >= start && i < end {
+			continue
+		}
+		if diff := len([]rune(token)) - termLen; diff > maxDistance || -diff > maxDistance {
+			continue
+		}
+		if domain.EditDistance(term, token, maxDistance) <= maxDistance {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
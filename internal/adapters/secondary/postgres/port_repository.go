@@ -0,0 +1,414 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"portservice/internal/domain"
+	"portservice/internal/ports/out"
+)
+
+// portColumns selects a port, reading its coordinates back out of the
+// geography column
+const portColumns = `SELECT id, name, city, country,
+	ST_X(location::geometry), ST_Y(location::geometry),
+	province, timezone, code, alias, regions, unlocs, functions, deleted_at
+	FROM ports`
+
+// upsertPort inserts a port or replaces every field of the stored one
+const upsertPort = `INSERT INTO ports (id, name, city, country, country_key, location,
+	province, province_key, timezone, timezone_key, code, code_key,
+	alias, regions, region_keys, unlocs, unloc_keys, functions, deleted_at)
+	VALUES ($1, $2, $3, $4, $5, ST_SetSRID(ST_MakePoint($6, $7), 4326)::geography,
+	$8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+	ON CONFLICT (id) DO UPDATE SET name = excluded.name, city = excluded.city,
+	country = excluded.country, country_key = excluded.country_key, location = excluded.location,
+	province = excluded.province, province_key = excluded.province_key,
+	timezone = excluded.timezone, timezone_key = excluded.timezone_key,
+	code = excluded.code, code_key = excluded.code_key,
+	alias = excluded.alias, regions = excluded.regions, region_keys = excluded.region_keys,
+	unlocs = excluded.unlocs, unloc_keys = excluded.unloc_keys,
+	functions = excluded.functions, deleted_at = excluded.deleted_at`
+
+// PortRepository implements out.PortRepository on PostgreSQL with PostGIS.
+// pgx prepares and caches the statements it runs on each pooled connection.
+type PortRepository struct {
+	pool *pgxpool.Pool
+
+	// softDelete tombstones ports on delete instead of removing them
+	softDelete bool
+
+	// Connection pool settings; zero keeps the DSN's or pgx's default
+	maxConns        int32
+	minConns        int32
+	maxConnLifetime time.Duration
+	maxConnIdleTime time.Duration
+}
+
+// Option configures a PortRepository
+type Option func(*PortRepository)
+
+// WithSoftDelete makes DeletePort tombstone ports with a deletion timestamp
+// instead of removing them, as memory.WithSoftDelete
+func WithSoftDelete() Option {
+	return func(r *PortRepository) {
+		r.softDelete = true
+	}
+}
+
+// WithMaxConns sets the maximum size of the connection pool
+func WithMaxConns(n int32) Option {
+	return func(r *PortRepository) {
+		r.maxConns = n
+	}
+}
+
+// WithMinConns sets the number of connections the pool keeps open
+func WithMinConns(n int32) Option {
+	return func(r *PortRepository) {
+		r.minConns = n
+	}
+}
+
+// WithMaxConnLifetime sets how long a connection is used before it is replaced
+func WithMaxConnLifetime(d time.Duration) Option {
+	return func(r *PortRepository) {
+		r.maxConnLifetime = d
+	}
+}
+
+// WithMaxConnIdleTime sets how long an idle connection is kept open
+func WithMaxConnIdleTime(d time.Duration) Option {
+	return func(r *PortRepository) {
+		r.maxConnIdleTime = d
+	}
+}
+
+// NewPortRepository connects to the database at dsn, a PostgreSQL URL or
+// keyword/value connection string, and migrates its schema to the latest
+// version. The database needs the PostGIS extension available.
+func NewPortRepository(dsn string, opts ...Option) (out.PortRepository, error) {
+	r := &PortRepository{}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	config, err := r.poolConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connection pool: %w", err)
+	}
+	r.pool = pool
+
+	if err := migrate(ctx, pool); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// poolConfig parses dsn and applies the pool options to it
+func (r *PortRepository) poolConfig(dsn string) (*pgxpool.Config, error) {
+	config, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database DSN: %w", err)
+	}
+	if r.maxConns < 0 || r.minConns < 0 || r.maxConnLifetime < 0 || r.maxConnIdleTime < 0 {
+		return nil, errors.New("connection pool settings cannot be negative")
+	}
+	if r.maxConns > 0 {
+		config.MaxConns = r.maxConns
+	}
+	if r.minConns > 0 {
+		config.MinConns = r.minConns
+	}
+	if config.MinConns > config.MaxConns {
+		return nil, fmt.Errorf("minimum connections %d exceed the maximum %d", config.MinConns, config.MaxConns)
+	}
+	if r.maxConnLifetime > 0 {
+		config.MaxConnLifetime = r.maxConnLifetime
+	}
+	if r.maxConnIdleTime > 0 {
+		config.MaxConnIdleTime = r.maxConnIdleTime
+	}
+	return config, nil
+}
+
+// SavePort saves or updates a port in the repository
+func (r *PortRepository) SavePort(ctx context.Context, port *domain.Port) error {
+	result, err := r.SavePorts(ctx, []*domain.Port{port})
+	if err != nil {
+		return err
+	}
+	return result.FirstError()
+}
+
+// SavePorts saves the valid ports of a batch in one database transaction,
+// skipping and reporting ports that fail validation
+func (r *PortRepository) SavePorts(ctx context.Context, ports []*domain.Port) (domain.BatchResult, error) {
+	if ctx.Err() != nil {
+		return domain.BatchResult{}, ctx.Err()
+	}
+	stored, result := domain.PrepareBatch(ports)
+	if err := r.store(ctx, stored); err != nil {
+		return domain.BatchResult{}, err
+	}
+	return result, nil
+}
+
+// store upserts validated ports in one database transaction, sending them
+// in a single round trip. Later ports with the same ID win.
+func (r *PortRepository) store(ctx context.Context, ports []*domain.Port) error {
+	if len(ports) == 0 {
+		return nil
+	}
+	batch := &pgx.Batch{}
+	for _, port := range ports {
+		batch.Queue(upsertPort, portArgs(port)...)
+	}
+	batch.Queue("UPDATE port_statistics SET total_updates = total_updates + $1, last_update = $2",
+		len(ports), time.Now().UnixNano())
+
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		return tx.SendBatch(ctx, batch).Close()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save ports: %w", err)
+	}
+	return nil
+}
+
+// portArgs returns the upsertPort arguments for a port
+func portArgs(port *domain.Port) []any {
+	key := domain.NormalizeFilterValue
+	functions := make([]string, len(port.Functions))
+	for i, function := range port.Functions {
+		functions[i] = string(function)
+	}
+	return []any{
+		port.ID, port.Name, port.City, port.Country, key(port.Country),
+		port.Coordinates.Longitude, port.Coordinates.Latitude,
+		port.Province, key(port.Province), port.Timezone, key(port.Timezone),
+		port.Code, key(port.Code),
+		nonNil(port.Alias), nonNil(port.Regions), keys(port.Regions),
+		nonNil(port.Unlocs), keys(port.Unlocs), functions, port.DeletedAt,
+	}
+}
+
+// nonNil returns values, or an empty slice in place of nil so it is stored
+// as an empty array rather than NULL
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// keys returns the normalized filter keys of values
+func keys(values []string) []string {
+	normalized := make([]string, len(values))
+	for i, value := range values {
+		normalized[i] = domain.NormalizeFilterValue(value)
+	}
+	return normalized
+}
+
+// BeginTx starts a transaction that stages port writes in memory and saves
+// them in one database transaction on Commit, so imports don't hold a
+// pooled connection while they run
+func (r *PortRepository) BeginTx(ctx context.Context) (out.PortTx, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return out.NewStagedTx(r.store), nil
+}
+
+// GetPort retrieves a port by its ID, hiding soft-deleted ports
+func (r *PortRepository) GetPort(ctx context.Context, id string) (*domain.Port, error) {
+	port, err := r.GetPortIncludingDeleted(ctx, id)
+	if err != nil || port == nil || port.IsDeleted() {
+		return nil, err
+	}
+	return port, nil
+}
+
+// GetPortIncludingDeleted retrieves a port by its ID, including soft-deleted ports
+func (r *PortRepository) GetPortIncludingDeleted(ctx context.Context, id string) (*domain.Port, error) {
+	port, err := scanPort(r.pool.QueryRow(ctx, portColumns+" WHERE id = $1", id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get port %s: %w", id, err)
+	}
+	return port, nil
+}
+
+// DeletePort removes a port from the repository, or tombstones it when
+// soft delete is enabled
+func (r *PortRepository) DeletePort(ctx context.Context, id string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	now := time.Now()
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var deleted int64
+		if r.softDelete {
+			tag, err := tx.Exec(ctx, "UPDATE ports SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL", now, id)
+			if err != nil {
+				return fmt.Errorf("failed to delete port %s: %w", id, err)
+			}
+			deleted = tag.RowsAffected()
+		} else {
+			tag, err := tx.Exec(ctx, "DELETE FROM ports WHERE id = $1 AND deleted_at IS NULL", id)
+			if err != nil {
+				return fmt.Errorf("failed to delete port %s: %w", id, err)
+			}
+			deleted = tag.RowsAffected()
+		}
+		if deleted == 0 {
+			return fmt.Errorf("%w: %s", domain.ErrPortNotFound, id)
+		}
+		_, err := tx.Exec(ctx, "UPDATE port_statistics SET total_deletes = total_deletes + 1, last_update = $1", now.UnixNano())
+		return err
+	})
+}
+
+// ListPorts returns a page of ports matching the filter, ordered by port ID
+func (r *PortRepository) ListPorts(ctx context.Context, filter domain.PortFilter, cursor string, limit int) (domain.PortPage, error) {
+	afterID, err := domain.DecodeCursor(cursor)
+	if err != nil {
+		return domain.PortPage{}, err
+	}
+	limit = domain.NormalizeLimit(limit)
+
+	query, args := listQuery(filter, afterID, limit+1)
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return domain.PortPage{}, fmt.Errorf("failed to list ports: %w", err)
+	}
+	defer rows.Close()
+
+	page := domain.PortPage{Ports: make([]*domain.Port, 0, limit)}
+	for rows.Next() {
+		port, err := scanPort(rows)
+		if err != nil {
+			return domain.PortPage{}, fmt.Errorf("failed to list ports: %w", err)
+		}
+		if len(page.Ports) == limit {
+			page.NextCursor = domain.EncodeCursor(page.Ports[limit-1].ID)
+			break
+		}
+		page.Ports = append(page.Ports, port)
+	}
+	if err := rows.Err(); err != nil {
+		return domain.PortPage{}, fmt.Errorf("failed to list ports: %w", err)
+	}
+	return page, nil
+}
+
+// listQuery builds the query selecting up to limit ports after afterID that
+// match the filter, comparing the normalized key columns. Each criterion
+// takes its values as one array argument, so the statement text depends
+// only on which criteria are set and stays cacheable.
+func listQuery(filter domain.PortFilter, afterID string, limit int) (string, []any) {
+	var query strings.Builder
+	query.WriteString(portColumns)
+	args := []any{afterID}
+	query.WriteString(" WHERE id > $1")
+
+	arg := func(value any) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
+	if !filter.IncludeDeleted {
+		query.WriteString(" AND deleted_at IS NULL")
+	}
+	for _, criterion := range []struct {
+		column string
+		values []string
+	}{
+		{"country_key", filter.Countries},
+		{"province_key", filter.Provinces},
+		{"timezone_key", filter.Timezones},
+		{"code_key", filter.Codes},
+	} {
+		if len(criterion.values) > 0 {
+			query.WriteString(" AND " + criterion.column + " = ANY(" + arg(keys(criterion.values)) + ")")
+		}
+	}
+	// && is array overlap, which the GIN indexes serve
+	if len(filter.Unlocs) > 0 {
+		query.WriteString(" AND unloc_keys && " + arg(keys(filter.Unlocs)))
+	}
+	if len(filter.Regions) > 0 {
+		query.WriteString(" AND region_keys && " + arg(keys(filter.Regions)))
+	}
+
+	query.WriteString(" ORDER BY id LIMIT " + arg(limit))
+	return query.String(), args
+}
+
+// scanPort reads a port selected with portColumns
+func scanPort(row pgx.Row) (*domain.Port, error) {
+	var port domain.Port
+	var longitude, latitude float64
+	var functions []string
+	if err := row.Scan(&port.ID, &port.Name, &port.City, &port.Country, &longitude, &latitude,
+		&port.Province, &port.Timezone, &port.Code,
+		&port.Alias, &port.Regions, &port.Unlocs, &functions, &port.DeletedAt); err != nil {
+		return nil, err
+	}
+
+	port.Coordinates = &domain.Coordinate{Longitude: longitude, Latitude: latitude}
+	// Empty arrays are read back as nil, which domain.NewPort also uses
+	if len(port.Alias) == 0 {
+		port.Alias = nil
+	}
+	if len(port.Regions) == 0 {
+		port.Regions = nil
+	}
+	if len(port.Unlocs) == 0 {
+		port.Unlocs = nil
+	}
+	for _, function := range functions {
+		port.Functions = append(port.Functions, domain.PortFunction(function))
+	}
+	return &port, nil
+}
+
+// GetStatistics returns the repository statistics, which are kept in the
+// database and so persist across restarts and are shared between instances
+func (r *PortRepository) GetStatistics() out.RepositoryStats {
+	var stats out.RepositoryStats
+	var lastUpdate int64
+	err := r.pool.QueryRow(context.Background(), `SELECT
+		(SELECT count(*) FROM ports WHERE deleted_at IS NULL),
+		total_updates, total_deletes, last_update FROM port_statistics`,
+	).Scan(&stats.TotalPorts, &stats.TotalUpdates, &stats.TotalDeletes, &lastUpdate)
+	if err != nil {
+		return out.RepositoryStats{}
+	}
+	stats.LastUpdate = time.Unix(0, lastUpdate).Format(time.RFC3339)
+	return stats
+}
+
+// Close waits for queries in flight and closes the connection pool
+func (r *PortRepository) Close(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	r.pool.Close()
+	return nil
+}
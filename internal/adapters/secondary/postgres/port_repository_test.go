@@ -0,0 +1,192 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"portservice/internal/domain"
+	"portservice/internal/ports/out"
+	"portservice/internal/ports/out/outtest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testDSNEnv names the variable holding the DSN of a PostGIS database for
+// the integration tests, which are skipped when it is unset
+const testDSNEnv = "POSTGRES_TEST_DSN"
+
+// testDSN returns the integration test DSN, skipping the test without one
+func testDSN(t *testing.T) string {
+	t.Helper()
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDSNEnv)
+	}
+	return dsn
+}
+
+// schemaDSN creates a schema of its own for a test, dropped when the test
+// ends, and returns a DSN whose connections use it, so tests don't see each
+// other's ports
+func schemaDSN(t *testing.T) string {
+	t.Helper()
+	dsn := testDSN(t)
+	ctx := context.Background()
+
+	conn, err := pgx.Connect(ctx, dsn)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer conn.Close(ctx)
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	_, err = conn.Exec(ctx, "CREATE SCHEMA "+schema)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() {
+		conn, err := pgx.Connect(ctx, dsn)
+		if err == nil {
+			conn.Exec(ctx, "DROP SCHEMA "+schema+" CASCADE")
+			conn.Close(ctx)
+		}
+	})
+
+	// Unknown connection parameters are sent to the server as settings.
+	// PostGIS stays in public, where the shared extension lives.
+	if strings.Contains(dsn, "://") {
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+		return dsn + separator + "search_path=" + schema + ",public"
+	}
+	return dsn + " search_path=" + schema + ",public"
+}
+
+// openDSN opens a repository over dsn, closed when the test ends
+func openDSN(t *testing.T, dsn string, opts ...Option) *PortRepository {
+	t.Helper()
+	repo, err := NewPortRepository(dsn, opts...)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { repo.Close(context.Background()) })
+	return repo.(*PortRepository)
+}
+
+// openRepository opens a repository in a schema of its own
+func openRepository(t *testing.T, opts ...Option) *PortRepository {
+	t.Helper()
+	return openDSN(t, schemaDSN(t), opts...)
+}
+
+// storage gives each test a schema of its own
+func storage(t *testing.T) outtest.Opener {
+	dsn := schemaDSN(t)
+	return func(softDelete bool) out.PortRepository {
+		if softDelete {
+			return openDSN(t, dsn, WithSoftDelete())
+		}
+		return openDSN(t, dsn)
+	}
+}
+
+func TestPortRepository(t *testing.T) {
+	outtest.TestPortRepository(t, storage)
+}
+
+func TestPortRepository_Persistence(t *testing.T) {
+	outtest.TestPersistence(t, storage)
+	outtest.TestPersistentStatistics(t, storage)
+}
+
+func TestListQuery(t *testing.T) {
+	query, args := listQuery(domain.PortFilter{}, "", 11)
+	assert.Equal(t, portColumns+" WHERE id > $1 AND deleted_at IS NULL ORDER BY id LIMIT $2", query)
+	assert.Equal(t, []any{"", 11}, args)
+
+	query, args = listQuery(domain.PortFilter{
+		Countries:      []string{" United Arab Emirates ", "Netherlands"},
+		Codes:          []string{"52000"},
+		Unlocs:         []string{"aeajm"},
+		Regions:        []string{"Gulf"},
+		IncludeDeleted: true,
+	}, "AEAJM", 101)
+	assert.Equal(t, portColumns+" WHERE id > $1 AND country_key = ANY($2) AND code_key = ANY($3)"+
+		" AND unloc_keys && $4 AND region_keys && $5 ORDER BY id LIMIT $6", query)
+	assert.Equal(t, []any{
+		"AEAJM",
+		[]string{"united arab emirates", "netherlands"},
+		[]string{"52000"},
+		[]string{"aeajm"},
+		[]string{"gulf"},
+		101,
+	}, args)
+}
+
+func TestPortRepository_PoolConfig(t *testing.T) {
+	dsn := "postgres://ports@localhost:5432/ports?pool_max_conns=8"
+
+	config, err := (&PortRepository{}).poolConfig(dsn)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(8), config.MaxConns)
+
+	repo := &PortRepository{}
+	for _, opt := range []Option{WithMaxConns(20), WithMinConns(2), WithMaxConnLifetime(time.Hour), WithMaxConnIdleTime(time.Minute)} {
+		opt(repo)
+	}
+	config, err = repo.poolConfig(dsn)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(20), config.MaxConns)
+	assert.Equal(t, int32(2), config.MinConns)
+	assert.Equal(t, time.Hour, config.MaxConnLifetime)
+	assert.Equal(t, time.Minute, config.MaxConnIdleTime)
+
+	_, err = (&PortRepository{minConns: 10}).poolConfig(dsn)
+	assert.Error(t, err)
+	_, err = (&PortRepository{maxConns: -1}).poolConfig(dsn)
+	assert.Error(t, err)
+	_, err = NewPortRepository("postgres://ports@localhost:notaport/ports")
+	assert.Error(t, err)
+}
+
+func TestPortRepository_Location(t *testing.T) {
+	repo := openRepository(t)
+	ctx := context.Background()
+
+	assert.NoError(t, repo.SavePort(ctx, outtest.NewPort("AEAJM", "Ajman")))
+
+	// The geography column lets other services query ports spatially
+	var id string
+	err := repo.pool.QueryRow(ctx, `SELECT id FROM ports
+		WHERE ST_DWithin(location, ST_SetSRID(ST_MakePoint(55.5, 25.4), 4326)::geography, 5000)`).Scan(&id)
+	assert.NoError(t, err)
+	assert.Equal(t, "AEAJM", id)
+}
+
+func TestPortRepository_Migrate(t *testing.T) {
+	repo := openRepository(t)
+	ctx := context.Background()
+
+	assert.NoError(t, repo.SavePort(ctx, outtest.NewPort("AEAJM", "Ajman")))
+	var version int
+	assert.NoError(t, repo.pool.QueryRow(ctx, "SELECT max(version) FROM port_schema_migrations").Scan(&version))
+	assert.Equal(t, len(migrations), version)
+
+	// Migrating an up to date database changes nothing
+	assert.NoError(t, migrate(ctx, repo.pool))
+	port, err := repo.GetPort(ctx, "AEAJM")
+	assert.NoError(t, err)
+	assert.NotNil(t, port)
+
+	// A database from a newer release is refused rather than misread
+	_, err = repo.pool.Exec(ctx, "INSERT INTO port_schema_migrations (version) VALUES (1000)")
+	assert.NoError(t, err)
+	assert.Error(t, migrate(ctx, repo.pool))
+}
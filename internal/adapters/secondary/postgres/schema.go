@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationLock is the advisory lock key that serializes migrations when
+// several instances start at once
+const migrationLock = 0x706f727473 // "ports"
+
+// migrations upgrade the schema one version at a time; migrations[i] moves
+// a database from version i to i+1, the applied versions being recorded in
+// port_schema_migrations. Applied migrations must never change.
+var migrations = []string{
+	// Version 1: ports with their list fields as arrays and their coordinates
+	// as a PostGIS geography, so other services can join on location. The
+	// *_key columns hold domain.NormalizeFilterValue of the field they
+	// follow, so filters can use the indexes. IDs sort by byte value, as in
+	// the other repositories, so cursors page the same way.
+	`CREATE EXTENSION IF NOT EXISTS postgis;
+
+	CREATE TABLE ports (
+		id           text COLLATE "C" PRIMARY KEY,
+		name         text NOT NULL,
+		city         text NOT NULL,
+		country      text NOT NULL,
+		country_key  text NOT NULL,
+		location     geography(Point, 4326) NOT NULL,
+		province     text NOT NULL,
+		province_key text NOT NULL,
+		timezone     text NOT NULL,
+		timezone_key text NOT NULL,
+		code         text NOT NULL,
+		code_key     text NOT NULL,
+		alias        text[] NOT NULL,
+		regions      text[] NOT NULL,
+		region_keys  text[] NOT NULL,
+		unlocs       text[] NOT NULL,
+		unloc_keys   text[] NOT NULL,
+		functions    text[] NOT NULL,
+		deleted_at   timestamptz
+	);
+	CREATE INDEX ports_country ON ports (country_key);
+	CREATE INDEX ports_province ON ports (province_key);
+	CREATE INDEX ports_timezone ON ports (timezone_key);
+	CREATE INDEX ports_code ON ports (code_key);
+	CREATE INDEX ports_regions ON ports USING gin (region_keys);
+	CREATE INDEX ports_unlocs ON ports USING gin (unloc_keys);
+	CREATE INDEX ports_location ON ports USING gist (location);
+
+	CREATE TABLE port_statistics (
+		id            integer PRIMARY KEY CHECK (id = 1),
+		total_updates bigint NOT NULL,
+		total_deletes bigint NOT NULL,
+		last_update   bigint NOT NULL
+	);
+	INSERT INTO port_statistics VALUES (1, 0, 0, 0);`,
+
+	// Version 2: an index on the coordinates as a planar geometry, for
+	// bounding box and polygon queries whose edges follow lines of
+	// longitude and latitude rather than great circles
+	`CREATE INDEX ports_location_geometry ON ports USING gist ((location::geometry));`,
+}
+
+// errMigrated stops the migration loop once the schema is up to date
+var errMigrated = errors.New("schema is up to date")
+
+// migrate brings the schema up to the latest version, applying each pending
+// migration in its own transaction under an advisory lock
+func migrate(ctx context.Context, pool *pgxpool.Pool) error {
+	for {
+		var version int
+		err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", migrationLock); err != nil {
+				return fmt.Errorf("failed to lock schema: %w", err)
+			}
+			if _, err := tx.Exec(ctx, `CREATE TABLE IF NOT EXISTS port_schema_migrations (
+				version    integer PRIMARY KEY,
+				applied_at timestamptz NOT NULL DEFAULT now()
+			)`); err != nil {
+				return fmt.Errorf("failed to create migrations table: %w", err)
+			}
+			if err := tx.QueryRow(ctx, "SELECT coalesce(max(version), 0) FROM port_schema_migrations").Scan(&version); err != nil {
+				return fmt.Errorf("failed to read schema version: %w", err)
+			}
+			if version > len(migrations) {
+				return fmt.Errorf("schema version %d is newer than the latest known version %d", version, len(migrations))
+			}
+			if version == len(migrations) {
+				return errMigrated
+			}
+
+			// Without arguments, pgx runs the migration with the simple
+			// protocol, which allows several statements
+			if _, err := tx.Exec(ctx, migrations[version]); err != nil {
+				return fmt.Errorf("failed to apply migration %d: %w", version+1, err)
+			}
+			if _, err := tx.Exec(ctx, "INSERT INTO port_schema_migrations (version) VALUES ($1)", version+1); err != nil {
+				return fmt.Errorf("failed to record migration %d: %w", version+1, err)
+			}
+			return nil
+		})
+		if errors.Is(err, errMigrated) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
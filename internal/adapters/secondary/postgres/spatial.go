@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"portservice/internal/domain"
+)
+
+// queryPoint is the geography of a query coordinate passed as longitude and
+// latitude arguments $1 and $2
+const queryPoint = "ST_SetSRID(ST_MakePoint($1, $2), 4326)::geography"
+
+// FindNearest returns the k live ports closest to center, nearest first.
+// The GiST index on location orders ports by distance with <->, which
+// measures on the same sphere as domain.DistanceKm.
+func (r *PortRepository) FindNearest(ctx context.Context, center domain.Coordinate, k int) ([]domain.PortDistance, error) {
+	if k <= 0 {
+		return []domain.PortDistance{}, nil
+	}
+	ports, err := r.queryPorts(ctx, portColumns+" WHERE deleted_at IS NULL ORDER BY location <-> "+queryPoint+", id LIMIT $3",
+		center.Longitude, center.Latitude, k)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find nearest ports: %w", err)
+	}
+	return portDistances(center, ports, domain.MaxDistanceKm), nil
+}
+
+// FindWithinRadius returns live ports within radiusKm of center, nearest first
+func (r *PortRepository) FindWithinRadius(ctx context.Context, center domain.Coordinate, radiusKm float64) ([]domain.PortDistance, error) {
+	// use_spheroid is false to measure on the sphere, as domain.DistanceKm
+	ports, err := r.queryPorts(ctx, portColumns+" WHERE deleted_at IS NULL AND ST_DWithin(location, "+queryPoint+", $3, false)",
+		center.Longitude, center.Latitude, radiusKm*1000)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find ports within radius: %w", err)
+	}
+	return portDistances(center, ports, radiusKm), nil
+}
+
+// FindInBoundingBox returns live ports inside the box, ordered by port ID.
+// The box is compared with the coordinates as a planar geometry, so its
+// edges follow lines of longitude and latitude, and a box crossing the
+// antimeridian is split into the envelopes on either side of it.
+func (r *PortRepository) FindInBoundingBox(ctx context.Context, box domain.BoundingBox) ([]*domain.Port, error) {
+	envelope := "ST_MakeEnvelope($1, $2, $3, $4, 4326)"
+	args := []any{box.MinLongitude, box.MinLatitude, box.MaxLongitude, box.MaxLatitude}
+	condition := "ST_Intersects(location::geometry, " + envelope + ")"
+	if box.CrossesAntimeridian() {
+		args = []any{box.MinLongitude, box.MinLatitude, 180.0, box.MaxLatitude, -180.0, box.MaxLongitude}
+		condition = "(ST_Intersects(location::geometry, " + envelope + ")" +
+			" OR ST_Intersects(location::geometry, ST_MakeEnvelope($5, $2, $6, $4, 4326)))"
+	}
+
+	ports, err := r.queryPorts(ctx, portColumns+" WHERE deleted_at IS NULL AND "+condition+" ORDER BY id", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find ports in bounding box: %w", err)
+	}
+	return ports, nil
+}
+
+// FindInPolygon returns live ports inside the polygon or on its edges,
+// ordered by port ID. Like domain.Polygon, the polygon is planar in
+// longitude and latitude. ST_MakeValid repairs self-intersecting rings,
+// which ST_Covers would otherwise fail the query on.
+func (r *PortRepository) FindInPolygon(ctx context.Context, polygon domain.Polygon) ([]*domain.Port, error) {
+	query := "WITH area AS (SELECT ST_MakeValid(ST_GeomFromText($1, 4326)) AS shape) " +
+		portColumns + ", area WHERE deleted_at IS NULL AND ST_Covers(area.shape, location::geometry) ORDER BY id"
+	ports, err := r.queryPorts(ctx, query, polygonWKT(polygon))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find ports in polygon: %w", err)
+	}
+	return ports, nil
+}
+
+// queryPorts runs a query selecting ports with portColumns
+func (r *PortRepository) queryPorts(ctx context.Context, query string, args ...any) ([]*domain.Port, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ports := make([]*domain.Port, 0)
+	for rows.Next() {
+		port, err := scanPort(rows)
+		if err != nil {
+			return nil, err
+		}
+		ports = append(ports, port)
+	}
+	return ports, rows.Err()
+}
+
+// portDistances pairs ports with their domain.DistanceKm from center,
+// nearest first, dropping any the database placed within maxKm that are
+// just outside it by that measure
+func portDistances(center domain.Coordinate, ports []*domain.Port, maxKm float64) []domain.PortDistance {
+	results := make([]domain.PortDistance, 0, len(ports))
+	for _, port := range ports {
+		if distance := center.DistanceKm(*port.Coordinates); distance <= maxKm {
+			results = append(results, domain.PortDistance{Port: port, DistanceKm: distance})
+		}
+	}
+	domain.SortByDistance(results)
+	return results
+}
+
+// polygonWKT writes a polygon as well-known text, e.g.
+// POLYGON((55 25,56 25,55.5 26,55 25))
+func polygonWKT(polygon domain.Polygon) string {
+	var wkt strings.Builder
+	wkt.WriteString("POLYGON(")
+	for i, ring := range polygon.Rings {
+		if i > 0 {
+			wkt.WriteByte(',')
+		}
+		wkt.WriteByte('(')
+		for j, c := range ring {
+			if j > 0 {
+				wkt.WriteByte(',')
+			}
+			wkt.WriteString(strconv.FormatFloat(c.Longitude, 'g', -1, 64))
+			wkt.WriteByte(' ')
+			wkt.WriteString(strconv.FormatFloat(c.Latitude, 'g', -1, 64))
+		}
+		wkt.WriteByte(')')
+	}
+	wkt.WriteByte(')')
+	return wkt.String()
+}
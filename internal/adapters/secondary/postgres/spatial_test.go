@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"portservice/internal/domain"
+	"portservice/internal/ports/out/outtest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolygonWKT(t *testing.T) {
+	polygon, err := domain.NewPolygon([][][]float64{
+		{{55, 25}, {56, 25}, {55.5, 26.25}, {55, 25}},
+		{{55.4, 25.2}, {55.6, 25.2}, {55.5, 25.4}, {55.4, 25.2}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "POLYGON((55 25,56 25,55.5 26.25,55 25),(55.4 25.2,55.6 25.2,55.5 25.4,55.4 25.2))", polygonWKT(polygon))
+}
+
+func distanceIDs(results []domain.PortDistance) []string {
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.Port.ID
+	}
+	return ids
+}
+
+func TestPortRepository_SpatialQueries(t *testing.T) {
+	repo := openRepository(t)
+	ctx := context.Background()
+
+	// Spread ports over the globe, including the poles and antimeridian
+	rng := rand.New(rand.NewSource(42))
+	var ports []*domain.Port
+	for i := 0; i < 500; i++ {
+		coords := []float64{rng.Float64()*360 - 180, rng.Float64()*180 - 90}
+		switch i {
+		case 0:
+			coords = []float64{179.9, 10}
+		case 1:
+			coords = []float64{-179.9, 10}
+		case 2:
+			coords = []float64{0, 90}
+		}
+		port, err := domain.NewPort(fmt.Sprintf("P%04d", i), "Port", "", "", coords, "", "", nil, "")
+		assert.NoError(t, err)
+		ports = append(ports, port)
+	}
+	_, err := repo.SavePorts(ctx, ports)
+	assert.NoError(t, err)
+
+	// Deleted ports are never found
+	deleted, _ := domain.NewPort("DELETED", "Port", "", "", []float64{179.95, 10}, "", "", nil, "")
+	assert.NoError(t, repo.SavePort(ctx, deleted))
+	assert.NoError(t, repo.DeletePort(ctx, "DELETED"))
+
+	bruteForce := func(center domain.Coordinate) []domain.PortDistance {
+		results := make([]domain.PortDistance, 0, len(ports))
+		for _, p := range ports {
+			results = append(results, domain.PortDistance{Port: p, DistanceKm: center.DistanceKm(*p.Coordinates)})
+		}
+		domain.SortByDistance(results)
+		return results
+	}
+
+	centers := []domain.Coordinate{
+		{Longitude: 179.95, Latitude: 10},
+		{Longitude: 0, Latitude: 89.5},
+		{Longitude: 55.27, Latitude: 25.25},
+	}
+	for _, center := range centers {
+		expected := bruteForce(center)
+
+		nearest, err := repo.FindNearest(ctx, center, 5)
+		assert.NoError(t, err)
+		assert.Equal(t, distanceIDs(expected[:5]), distanceIDs(nearest), "nearest to %v", center)
+		assert.InDelta(t, expected[0].DistanceKm, nearest[0].DistanceKm, 1e-9)
+
+		within, err := repo.FindWithinRadius(ctx, center, 3000)
+		assert.NoError(t, err)
+		var want []domain.PortDistance
+		for _, d := range expected {
+			if d.DistanceKm <= 3000 {
+				want = append(want, d)
+			}
+		}
+		assert.Equal(t, distanceIDs(want), distanceIDs(within), "within 3000 km of %v", center)
+	}
+
+	boxes := []domain.BoundingBox{
+		{MinLongitude: -30, MinLatitude: -20, MaxLongitude: 40, MaxLatitude: 35},
+		{MinLongitude: 170, MinLatitude: -10, MaxLongitude: -170, MaxLatitude: 30},
+	}
+	for _, box := range boxes {
+		var want []string
+		for _, p := range ports {
+			if box.Contains(*p.Coordinates) {
+				want = append(want, p.ID)
+			}
+		}
+		inBox, err := repo.FindInBoundingBox(ctx, box)
+		assert.NoError(t, err)
+		assert.Equal(t, want, outtest.PortIDs(inBox), "in box %v", box)
+	}
+
+	// A triangle with a hole, checked against domain.Polygon.Contains
+	polygon, err := domain.NewPolygon([][][]float64{
+		{{-60, -40}, {60, -40}, {0, 60}, {-60, -40}},
+		{{-10, -10}, {10, -10}, {0, 10}, {-10, -10}},
+	})
+	assert.NoError(t, err)
+	var want []string
+	for _, p := range ports {
+		if polygon.Contains(*p.Coordinates) {
+			want = append(want, p.ID)
+		}
+	}
+	inPolygon, err := repo.FindInPolygon(ctx, polygon)
+	assert.NoError(t, err)
+	assert.Equal(t, want, outtest.PortIDs(inPolygon))
+}
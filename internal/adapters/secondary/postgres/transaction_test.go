@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"portservice/internal/ports/out/outtest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPortTx_CommitFailure(t *testing.T) {
+	repo := openRepository(t)
+	ctx := context.Background()
+
+	// Make the database reject one port partway through the commit
+	_, err := repo.pool.Exec(ctx, `CREATE FUNCTION reject_port() RETURNS trigger AS $$
+		BEGIN RAISE EXCEPTION 'rejected'; END $$ LANGUAGE plpgsql;
+		CREATE TRIGGER reject_port BEFORE INSERT ON ports
+		FOR EACH ROW WHEN (new.id = 'AEDXB') EXECUTE FUNCTION reject_port();`)
+	assert.NoError(t, err)
+
+	tx, err := repo.BeginTx(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.SavePort(ctx, outtest.NewPort("AEAJM", "Ajman")))
+	assert.NoError(t, tx.SavePort(ctx, outtest.NewPort("AEDXB", "Dubai")))
+	assert.Error(t, tx.Commit(ctx))
+
+	// The ports written before the failure are rolled back with it
+	port, err := repo.GetPort(ctx, "AEAJM")
+	assert.NoError(t, err)
+	assert.Nil(t, port)
+	stats := repo.GetStatistics()
+	assert.Zero(t, stats.TotalPorts)
+	assert.Zero(t, stats.TotalUpdates)
+}
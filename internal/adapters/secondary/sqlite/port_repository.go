@@ -0,0 +1,474 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	// Registers the pure Go "sqlite" driver, which needs no cgo
+	_ "modernc.org/sqlite"
+
+	"portservice/internal/domain"
+	"portservice/internal/ports/out"
+)
+
+// busyTimeout is how long a connection waits for another's write lock
+const busyTimeout = 5 * time.Second
+
+// portColumns selects a port from ports p, gathering its list fields from
+// the child tables as JSON arrays in their original order
+const portColumns = `SELECT p.id, p.name, p.city, p.country, p.longitude, p.latitude,
+	p.province, p.timezone, p.code, p.deleted_at,
+	(SELECT json_group_array(unloc ORDER BY position) FROM port_unlocs WHERE port_id = p.id),
+	(SELECT json_group_array(alias ORDER BY position) FROM port_aliases WHERE port_id = p.id),
+	(SELECT json_group_array(region ORDER BY position) FROM port_regions WHERE port_id = p.id),
+	(SELECT json_group_array(function ORDER BY position) FROM port_functions WHERE port_id = p.id)
+	FROM ports p`
+
+// statements are the prepared statements of a PortRepository
+type statements struct {
+	getPort         *sql.Stmt
+	upsertPort      *sql.Stmt
+	deleteUnlocs    *sql.Stmt
+	insertUnloc     *sql.Stmt
+	deleteAliases   *sql.Stmt
+	insertAlias     *sql.Stmt
+	deleteRegions   *sql.Stmt
+	insertRegion    *sql.Stmt
+	deleteFunctions *sql.Stmt
+	insertFunction  *sql.Stmt
+	softDeletePort  *sql.Stmt
+	hardDeletePort  *sql.Stmt
+	recordUpdates   *sql.Stmt
+	recordDelete    *sql.Stmt
+	statistics      *sql.Stmt
+}
+
+// PortRepository implements out.PortRepository on a SQLite database. Ports
+// are stored in a normalized schema, migrated on open, so the database can
+// be queried with plain SQL.
+type PortRepository struct {
+	db    *sql.DB
+	stmts statements
+
+	// softDelete tombstones ports on delete instead of removing them
+	softDelete bool
+}
+
+// Option configures a PortRepository
+type Option func(*PortRepository)
+
+// WithSoftDelete makes DeletePort tombstone ports with a deletion timestamp
+// instead of removing them, as memory.WithSoftDelete
+func WithSoftDelete() Option {
+	return func(r *PortRepository) {
+		r.softDelete = true
+	}
+}
+
+// NewPortRepository opens the SQLite database at path, creating it if
+// needed, and migrates its schema to the latest version
+func NewPortRepository(path string, opts ...Option) (out.PortRepository, error) {
+	r := &PortRepository{}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	// Pragmas are set per connection. WAL journaling lets reads proceed
+	// during writes, and immediate transactions take the write lock up
+	// front so concurrent writers wait instead of failing to upgrade.
+	query := url.Values{}
+	query.Add("_pragma", "foreign_keys(1)")
+	query.Add("_pragma", "journal_mode(WAL)")
+	query.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", busyTimeout.Milliseconds()))
+	query.Set("_txlock", "immediate")
+	db, err := sql.Open("sqlite", fileURI(path, query))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	r.db = db
+
+	ctx := context.Background()
+	if err := migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := r.prepare(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// fileURI returns the SQLite URI opening the database at path with the
+// given query parameters. SQLite decodes percent escapes in the path, so
+// escaping it keeps characters such as ?, # and % part of the file name.
+func fileURI(path string, query url.Values) string {
+	escaped := (&url.URL{Path: path}).EscapedPath()
+	return (&url.URL{Scheme: "file", Opaque: escaped, RawQuery: query.Encode()}).String()
+}
+
+// prepare prepares the statements the repository runs
+func (r *PortRepository) prepare(ctx context.Context) error {
+	for _, s := range []struct {
+		stmt  **sql.Stmt
+		query string
+	}{
+		{&r.stmts.getPort, portColumns + ` WHERE p.id = ?`},
+		{&r.stmts.upsertPort, `INSERT INTO ports (id, name, city, country, country_key, longitude, latitude,
+			province, province_key, timezone, timezone_key, code, code_key, deleted_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET name = excluded.name, city = excluded.city,
+			country = excluded.country, country_key = excluded.country_key,
+			longitude = excluded.longitude, latitude = excluded.latitude,
+			province = excluded.province, province_key = excluded.province_key,
+			timezone = excluded.timezone, timezone_key = excluded.timezone_key,
+			code = excluded.code, code_key = excluded.code_key, deleted_at = excluded.deleted_at`},
+		{&r.stmts.deleteUnlocs, `DELETE FROM port_unlocs WHERE port_id = ?`},
+		{&r.stmts.insertUnloc, `INSERT INTO port_unlocs (port_id, position, unloc, unloc_key) VALUES (?, ?, ?, ?)`},
+		{&r.stmts.deleteAliases, `DELETE FROM port_aliases WHERE port_id = ?`},
+		{&r.stmts.insertAlias, `INSERT INTO port_aliases (port_id, position, alias) VALUES (?, ?, ?)`},
+		{&r.stmts.deleteRegions, `DELETE FROM port_regions WHERE port_id = ?`},
+		{&r.stmts.insertRegion, `INSERT INTO port_regions (port_id, position, region, region_key) VALUES (?, ?, ?, ?)`},
+		{&r.stmts.deleteFunctions, `DELETE FROM port_functions WHERE port_id = ?`},
+		{&r.stmts.insertFunction, `INSERT INTO port_functions (port_id, position, function) VALUES (?, ?, ?)`},
+		{&r.stmts.softDeletePort, `UPDATE ports SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`},
+		{&r.stmts.hardDeletePort, `DELETE FROM ports WHERE id = ? AND deleted_at IS NULL`},
+		{&r.stmts.recordUpdates, `UPDATE statistics SET total_updates = total_updates + ?, last_update = ?`},
+		{&r.stmts.recordDelete, `UPDATE statistics SET total_deletes = total_deletes + 1, last_update = ?`},
+		{&r.stmts.statistics, `SELECT (SELECT COUNT(*) FROM ports WHERE deleted_at IS NULL),
+			total_updates, total_deletes, last_update FROM statistics`},
+	} {
+		stmt, err := r.db.PrepareContext(ctx, s.query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare statement: %w", err)
+		}
+		*s.stmt = stmt
+	}
+	return nil
+}
+
+// SavePort saves or updates a port in the repository
+func (r *PortRepository) SavePort(ctx context.Context, port *domain.Port) error {
+	result, err := r.SavePorts(ctx, []*domain.Port{port})
+	if err != nil {
+		return err
+	}
+	return result.FirstError()
+}
+
+// SavePorts saves the valid ports of a batch in one database transaction,
+// skipping and reporting ports that fail validation
+func (r *PortRepository) SavePorts(ctx context.Context, ports []*domain.Port) (domain.BatchResult, error) {
+	if ctx.Err() != nil {
+		return domain.BatchResult{}, ctx.Err()
+	}
+	stored, result := domain.PrepareBatch(ports)
+	if err := r.store(ctx, stored); err != nil {
+		return domain.BatchResult{}, err
+	}
+	return result, nil
+}
+
+// store writes validated ports in one database transaction, in order
+func (r *PortRepository) store(ctx context.Context, ports []*domain.Port) error {
+	if len(ports) == 0 {
+		return nil
+	}
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		for _, port := range ports {
+			if err := r.storePort(ctx, tx, port); err != nil {
+				return fmt.Errorf("failed to save port %s: %w", port.ID, err)
+			}
+		}
+		_, err := tx.StmtContext(ctx, r.stmts.recordUpdates).ExecContext(ctx, len(ports), time.Now().UnixNano())
+		return err
+	})
+}
+
+// storePort upserts a port and replaces its list fields within tx
+func (r *PortRepository) storePort(ctx context.Context, tx *sql.Tx, port *domain.Port) error {
+	var deletedAt sql.NullString
+	if port.DeletedAt != nil {
+		deletedAt = sql.NullString{String: port.DeletedAt.UTC().Format(time.RFC3339Nano), Valid: true}
+	}
+	key := domain.NormalizeFilterValue
+	if _, err := tx.StmtContext(ctx, r.stmts.upsertPort).ExecContext(ctx,
+		port.ID, port.Name, port.City, port.Country, key(port.Country),
+		port.Coordinates.Longitude, port.Coordinates.Latitude,
+		port.Province, key(port.Province), port.Timezone, key(port.Timezone),
+		port.Code, key(port.Code), deletedAt,
+	); err != nil {
+		return err
+	}
+
+	functions := make([]string, len(port.Functions))
+	for i, function := range port.Functions {
+		functions[i] = string(function)
+	}
+	for _, list := range []struct {
+		clear, insert *sql.Stmt
+		values        []string
+		keyed         bool
+	}{
+		{r.stmts.deleteUnlocs, r.stmts.insertUnloc, port.Unlocs, true},
+		{r.stmts.deleteAliases, r.stmts.insertAlias, port.Alias, false},
+		{r.stmts.deleteRegions, r.stmts.insertRegion, port.Regions, true},
+		{r.stmts.deleteFunctions, r.stmts.insertFunction, functions, false},
+	} {
+		if _, err := tx.StmtContext(ctx, list.clear).ExecContext(ctx, port.ID); err != nil {
+			return err
+		}
+		insert := tx.StmtContext(ctx, list.insert)
+		for i, value := range list.values {
+			args := []any{port.ID, i, value}
+			if list.keyed {
+				args = append(args, key(value))
+			}
+			if _, err := insert.ExecContext(ctx, args...); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// inTx runs fn in a database transaction, committing it if fn succeeds
+func (r *PortRepository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// BeginTx starts a transaction that stages port writes in memory and saves
+// them in one database transaction on Commit, so imports don't hold the
+// write lock while they run
+func (r *PortRepository) BeginTx(ctx context.Context) (out.PortTx, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return out.NewStagedTx(r.store), nil
+}
+
+// GetPort retrieves a port by its ID, hiding soft-deleted ports
+func (r *PortRepository) GetPort(ctx context.Context, id string) (*domain.Port, error) {
+	port, err := r.GetPortIncludingDeleted(ctx, id)
+	if err != nil || port == nil || port.IsDeleted() {
+		return nil, err
+	}
+	return port, nil
+}
+
+// GetPortIncludingDeleted retrieves a port by its ID, including soft-deleted ports
+func (r *PortRepository) GetPortIncludingDeleted(ctx context.Context, id string) (*domain.Port, error) {
+	port, err := scanPort(r.stmts.getPort.QueryRowContext(ctx, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get port %s: %w", id, err)
+	}
+	return port, nil
+}
+
+// DeletePort removes a port from the repository, or tombstones it when
+// soft delete is enabled
+func (r *PortRepository) DeletePort(ctx context.Context, id string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	now := time.Now()
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		var result sql.Result
+		var err error
+		if r.softDelete {
+			result, err = tx.StmtContext(ctx, r.stmts.softDeletePort).ExecContext(ctx, now.UTC().Format(time.RFC3339Nano), id)
+		} else {
+			result, err = tx.StmtContext(ctx, r.stmts.hardDeletePort).ExecContext(ctx, id)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete port %s: %w", id, err)
+		}
+		if deleted, err := result.RowsAffected(); err != nil {
+			return err
+		} else if deleted == 0 {
+			return fmt.Errorf("%w: %s", domain.ErrPortNotFound, id)
+		}
+		_, err = tx.StmtContext(ctx, r.stmts.recordDelete).ExecContext(ctx, now.UnixNano())
+		return err
+	})
+}
+
+// ListPorts returns a page of ports matching the filter, ordered by port ID
+func (r *PortRepository) ListPorts(ctx context.Context, filter domain.PortFilter, cursor string, limit int) (domain.PortPage, error) {
+	afterID, err := domain.DecodeCursor(cursor)
+	if err != nil {
+		return domain.PortPage{}, err
+	}
+	limit = domain.NormalizeLimit(limit)
+
+	query, args := listQuery(filter, afterID, limit+1)
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return domain.PortPage{}, fmt.Errorf("failed to list ports: %w", err)
+	}
+	defer rows.Close()
+
+	page := domain.PortPage{Ports: make([]*domain.Port, 0, limit)}
+	for rows.Next() {
+		port, err := scanPort(rows)
+		if err != nil {
+			return domain.PortPage{}, fmt.Errorf("failed to list ports: %w", err)
+		}
+		if len(page.Ports) == limit {
+			page.NextCursor = domain.EncodeCursor(page.Ports[limit-1].ID)
+			break
+		}
+		page.Ports = append(page.Ports, port)
+	}
+	if err := rows.Err(); err != nil {
+		return domain.PortPage{}, fmt.Errorf("failed to list ports: %w", err)
+	}
+	return page, nil
+}
+
+// listQuery builds the query selecting up to limit ports after afterID that
+// match the filter, comparing the normalized key columns
+func listQuery(filter domain.PortFilter, afterID string, limit int) (string, []any) {
+	var query strings.Builder
+	query.WriteString(portColumns)
+	query.WriteString(" WHERE p.id > ?")
+	args := []any{afterID}
+
+	in := func(values []string) string {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+		for _, value := range values {
+			args = append(args, domain.NormalizeFilterValue(value))
+		}
+		return "(" + placeholders + ")"
+	}
+	if !filter.IncludeDeleted {
+		query.WriteString(" AND p.deleted_at IS NULL")
+	}
+	for _, criterion := range []struct {
+		column string
+		values []string
+	}{
+		{"p.country_key", filter.Countries},
+		{"p.province_key", filter.Provinces},
+		{"p.timezone_key", filter.Timezones},
+		{"p.code_key", filter.Codes},
+	} {
+		if len(criterion.values) > 0 {
+			query.WriteString(" AND " + criterion.column + " IN " + in(criterion.values))
+		}
+	}
+	if len(filter.Unlocs) > 0 {
+		query.WriteString(" AND p.id IN (SELECT port_id FROM port_unlocs WHERE unloc_key IN " + in(filter.Unlocs) + ")")
+	}
+	if len(filter.Regions) > 0 {
+		query.WriteString(" AND p.id IN (SELECT port_id FROM port_regions WHERE region_key IN " + in(filter.Regions) + ")")
+	}
+
+	query.WriteString(" ORDER BY p.id LIMIT ?")
+	args = append(args, limit)
+	return query.String(), args
+}
+
+// scanner is implemented by *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanPort reads a port selected with portColumns
+func scanPort(row scanner) (*domain.Port, error) {
+	var port domain.Port
+	var longitude, latitude float64
+	var deletedAt sql.NullString
+	var unlocs, alias, regions, functions string
+	if err := row.Scan(&port.ID, &port.Name, &port.City, &port.Country, &longitude, &latitude,
+		&port.Province, &port.Timezone, &port.Code, &deletedAt,
+		&unlocs, &alias, &regions, &functions); err != nil {
+		return nil, err
+	}
+
+	port.Coordinates = &domain.Coordinate{Longitude: longitude, Latitude: latitude}
+	if deletedAt.Valid {
+		t, err := time.Parse(time.RFC3339Nano, deletedAt.String)
+		if err != nil {
+			return nil, fmt.Errorf("invalid deletion time of port %s: %w", port.ID, err)
+		}
+		port.DeletedAt = &t
+	}
+	for _, list := range []struct {
+		raw  string
+		dest any
+	}{
+		{unlocs, &port.Unlocs},
+		{alias, &port.Alias},
+		{regions, &port.Regions},
+		{functions, &port.Functions},
+	} {
+		if err := json.Unmarshal([]byte(list.raw), list.dest); err != nil {
+			return nil, fmt.Errorf("invalid list field of port %s: %w", port.ID, err)
+		}
+	}
+	// Empty lists are read back as nil, which domain.NewPort also uses
+	if len(port.Unlocs) == 0 {
+		port.Unlocs = nil
+	}
+	if len(port.Alias) == 0 {
+		port.Alias = nil
+	}
+	if len(port.Regions) == 0 {
+		port.Regions = nil
+	}
+	if len(port.Functions) == 0 {
+		port.Functions = nil
+	}
+	return &port, nil
+}
+
+// GetStatistics returns the repository statistics, which are kept in the
+// database and so persist across restarts
+func (r *PortRepository) GetStatistics() out.RepositoryStats {
+	var stats out.RepositoryStats
+	var lastUpdate int64
+	err := r.stmts.statistics.QueryRow().Scan(&stats.TotalPorts, &stats.TotalUpdates, &stats.TotalDeletes, &lastUpdate)
+	if err != nil {
+		return out.RepositoryStats{}
+	}
+	stats.LastUpdate = time.Unix(0, lastUpdate).Format(time.RFC3339)
+	return stats
+}
+
+// Close closes the prepared statements and the database
+func (r *PortRepository) Close(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	for _, stmt := range []*sql.Stmt{
+		r.stmts.getPort, r.stmts.upsertPort,
+		r.stmts.deleteUnlocs, r.stmts.insertUnloc, r.stmts.deleteAliases, r.stmts.insertAlias,
+		r.stmts.deleteRegions, r.stmts.insertRegion, r.stmts.deleteFunctions, r.stmts.insertFunction,
+		r.stmts.softDeletePort, r.stmts.hardDeletePort,
+		r.stmts.recordUpdates, r.stmts.recordDelete, r.stmts.statistics,
+	} {
+		stmt.Close()
+	}
+	return r.db.Close()
+}
@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"portservice/internal/domain"
+	"portservice/internal/ports/out"
+	"portservice/internal/ports/out/outtest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// openRepository opens the database at path, failing the test on error
+func openRepository(t *testing.T, path string, opts ...Option) *PortRepository {
+	t.Helper()
+	repo, err := NewPortRepository(path, opts...)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { repo.Close(context.Background()) })
+	return repo.(*PortRepository)
+}
+
+// storage gives each test a database file of its own
+func storage(t *testing.T) outtest.Opener {
+	path := filepath.Join(t.TempDir(), "ports.db")
+	return func(softDelete bool) out.PortRepository {
+		if softDelete {
+			return openRepository(t, path, WithSoftDelete())
+		}
+		return openRepository(t, path)
+	}
+}
+
+func TestPortRepository(t *testing.T) {
+	outtest.TestPortRepository(t, storage)
+}
+
+func TestPortRepository_Persistence(t *testing.T) {
+	outtest.TestPersistence(t, storage)
+	outtest.TestPersistentStatistics(t, storage)
+}
+
+func TestPortRepository_DeleteChildRows(t *testing.T) {
+	repo := openRepository(t, filepath.Join(t.TempDir(), "ports.db"))
+	ctx := context.Background()
+
+	port := outtest.NewPort("AEAJM", "Ajman")
+	port.Alias = []string{"ajman"}
+	port.Regions = []string{"Gulf"}
+	port.Functions = []domain.PortFunction{domain.FunctionPort}
+	assert.NoError(t, repo.SavePort(ctx, port))
+	assert.NoError(t, repo.DeletePort(ctx, "AEAJM"))
+
+	// The child rows go with the port
+	for _, table := range []string{"port_unlocs", "port_aliases", "port_regions", "port_functions"} {
+		var rows int
+		assert.NoError(t, repo.db.QueryRow("SELECT COUNT(*) FROM "+table).Scan(&rows))
+		assert.Zero(t, rows, table)
+	}
+}
+
+func TestPortRepository_Migrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ports.db")
+	ctx := context.Background()
+
+	repo := openRepository(t, path)
+	assert.NoError(t, repo.SavePort(ctx, outtest.NewPort("AEAJM", "Ajman")))
+	var version int
+	assert.NoError(t, repo.db.QueryRow("PRAGMA user_version").Scan(&version))
+	assert.Equal(t, len(migrations), version)
+
+	// Migrating an up to date database changes nothing
+	assert.NoError(t, migrate(ctx, repo.db))
+	port, err := repo.GetPort(ctx, "AEAJM")
+	assert.NoError(t, err)
+	assert.NotNil(t, port)
+	assert.NoError(t, repo.Close(ctx))
+
+	// A database from a newer release is refused rather than misread
+	db, err := sql.Open("sqlite", path)
+	assert.NoError(t, err)
+	_, err = db.Exec("PRAGMA user_version = 1000")
+	assert.NoError(t, err)
+	assert.NoError(t, db.Close())
+	_, err = NewPortRepository(path)
+	assert.Error(t, err)
+}
+
+func TestPortRepository_Path(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ports?mode=ro#1 100%.db")
+	ctx := context.Background()
+
+	// Characters special in URIs stay part of the file name
+	repo := openRepository(t, path)
+	assert.NoError(t, repo.SavePort(ctx, outtest.NewPort("AEAJM", "Ajman")))
+	assert.NoError(t, repo.Close(ctx))
+	_, err := os.Stat(path)
+	assert.NoError(t, err)
+
+	repo = openRepository(t, path)
+	port, err := repo.GetPort(ctx, "AEAJM")
+	assert.NoError(t, err)
+	assert.NotNil(t, port)
+}
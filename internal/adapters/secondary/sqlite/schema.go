@@ -0,0 +1,108 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// migrations upgrade the schema one version at a time; migrations[i] moves
+// a database from version i to i+1, the version being kept in the
+// user_version pragma. Applied migrations must never change.
+var migrations = []string{
+	// Version 1: ports with their list fields in child tables. The *_key
+	// columns hold domain.NormalizeFilterValue of the field they follow, so
+	// filters can use the indexes.
+	`CREATE TABLE ports (
+		id           TEXT PRIMARY KEY,
+		name         TEXT NOT NULL,
+		city         TEXT NOT NULL,
+		country      TEXT NOT NULL,
+		country_key  TEXT NOT NULL,
+		longitude    REAL NOT NULL,
+		latitude     REAL NOT NULL,
+		province     TEXT NOT NULL,
+		province_key TEXT NOT NULL,
+		timezone     TEXT NOT NULL,
+		timezone_key TEXT NOT NULL,
+		code         TEXT NOT NULL,
+		code_key     TEXT NOT NULL,
+		deleted_at   TEXT
+	) WITHOUT ROWID;
+	CREATE INDEX ports_country ON ports (country_key);
+	CREATE INDEX ports_province ON ports (province_key);
+	CREATE INDEX ports_timezone ON ports (timezone_key);
+	CREATE INDEX ports_code ON ports (code_key);
+
+	CREATE TABLE port_unlocs (
+		port_id   TEXT NOT NULL REFERENCES ports (id) ON DELETE CASCADE,
+		position  INTEGER NOT NULL,
+		unloc     TEXT NOT NULL,
+		unloc_key TEXT NOT NULL,
+		PRIMARY KEY (port_id, position)
+	) WITHOUT ROWID;
+	CREATE INDEX port_unlocs_unloc ON port_unlocs (unloc_key);
+
+	CREATE TABLE port_aliases (
+		port_id  TEXT NOT NULL REFERENCES ports (id) ON DELETE CASCADE,
+		position INTEGER NOT NULL,
+		alias    TEXT NOT NULL,
+		PRIMARY KEY (port_id, position)
+	) WITHOUT ROWID;
+
+	CREATE TABLE port_regions (
+		port_id    TEXT NOT NULL REFERENCES ports (id) ON DELETE CASCADE,
+		position   INTEGER NOT NULL,
+		region     TEXT NOT NULL,
+		region_key TEXT NOT NULL,
+		PRIMARY KEY (port_id, position)
+	) WITHOUT ROWID;
+	CREATE INDEX port_regions_region ON port_regions (region_key);
+
+	CREATE TABLE port_functions (
+		port_id  TEXT NOT NULL REFERENCES ports (id) ON DELETE CASCADE,
+		position INTEGER NOT NULL,
+		function TEXT NOT NULL,
+		PRIMARY KEY (port_id, position)
+	) WITHOUT ROWID;
+
+	CREATE TABLE statistics (
+		id            INTEGER PRIMARY KEY CHECK (id = 1),
+		total_updates INTEGER NOT NULL,
+		total_deletes INTEGER NOT NULL,
+		last_update   INTEGER NOT NULL
+	);
+	INSERT INTO statistics VALUES (1, 0, 0, 0);`,
+}
+
+// migrate brings the schema of db up to the latest version, applying each
+// pending migration in its own transaction
+func migrate(ctx context.Context, db *sql.DB) error {
+	var version int
+	if err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("schema version %d is newer than the latest known version %d", version, len(migrations))
+	}
+
+	for ; version < len(migrations); version++ {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin migration %d: %w", version+1, err)
+		}
+		if _, err := tx.ExecContext(ctx, migrations[version]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to apply migration %d: %w", version+1, err)
+		}
+		// Pragmas take no parameters
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", version+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %d: %w", version+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %d: %w", version+1, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"portservice/internal/ports/out/outtest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPortTx_CommitFailure(t *testing.T) {
+	repo := openRepository(t, filepath.Join(t.TempDir(), "ports.db"))
+	ctx := context.Background()
+
+	// Make the database reject one port partway through the commit
+	_, err := repo.db.Exec(`CREATE TRIGGER reject_port BEFORE INSERT ON ports
+		WHEN new.id = 'AEDXB' BEGIN SELECT RAISE(ABORT, 'rejected'); END`)
+	assert.NoError(t, err)
+
+	tx, err := repo.BeginTx(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.SavePort(ctx, outtest.NewPort("AEAJM", "Ajman")))
+	assert.NoError(t, tx.SavePort(ctx, outtest.NewPort("AEDXB", "Dubai")))
+	assert.Error(t, tx.Commit(ctx))
+
+	// The ports written before the failure are rolled back with it
+	port, err := repo.GetPort(ctx, "AEAJM")
+	assert.NoError(t, err)
+	assert.Nil(t, port)
+	stats := repo.GetStatistics()
+	assert.Zero(t, stats.TotalPorts)
+	assert.Zero(t, stats.TotalUpdates)
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Magic bytes identifying the compression formats imports accept
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	bzip2Magic = []byte("BZh")
+)
+
+// zstdMaxWindow bounds the memory a zstd stream may ask the decoder to hold
+const zstdMaxWindow = 32 << 20
+
+// decompress detects gzip, zstd and bzip2 input by its magic bytes and returns
+// a reader streaming the decompressed data. Other input is returned as it is.
+// The returned close function releases the decompressor and must be called
+// once reading is done.
+func decompress(r io.Reader) (io.Reader, func(), error) {
+	buffered := bufio.NewReader(r)
+	// A short or failed peek leaves too little input to be compressed; the
+	// JSON decoder reports any read error itself
+	magic, _ := buffered.Peek(len(zstdMagic))
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		reader, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read gzip header: %w", err)
+		}
+		return reader, func() { reader.Close() }, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		reader, err := zstd.NewReader(buffered,
+			zstd.WithDecoderConcurrency(1),
+			zstd.WithDecoderLowmem(true),
+			zstd.WithDecoderMaxWindow(zstdMaxWindow),
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read zstd stream: %w", err)
+		}
+		return reader, reader.Close, nil
+	case bytes.HasPrefix(magic, bzip2Magic):
+		return bzip2.NewReader(buffered), func() {}, nil
+	default:
+		return buffered, func() {}, nil
+	}
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"io"
+	"testing"
+
+	"portservice/internal/domain"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+// bzip2Ports is a bzip2-compressed ports file holding AEAJM; the standard
+// library can only decompress bzip2
+const bzip2Ports = "QlpoOTFBWSZTWdVQiUcAACCfgFAFfxAiEgAKLjOcCiAAVFTTEYE0wEbTQGqfqmTaRpp6TEABBgI417n6gL4qFKlzWrlJEZKtz0wAoaCsYT6LvDZBQgTsRyQEaeXWxdyRThQkNVQiUcA="
+
+// compressPorts compresses content with the compressor returned by newWriter
+func compressPorts(t *testing.T, content string, newWriter func(io.Writer) (io.WriteCloser, error)) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer, err := newWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(writer, content); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecompress(t *testing.T) {
+	content := `{"AEAJM": {"name": "Ajman", "coordinates": [55.5136433, 25.4052165]}}`
+	bzip2Data, err := base64.StdEncoding.DecodeString(bzip2Ports)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{name: "plain", input: []byte(content)},
+		{
+			name: "gzip",
+			input: compressPorts(t, content, func(w io.Writer) (io.WriteCloser, error) {
+				return gzip.NewWriter(w), nil
+			}),
+		},
+		{
+			name: "zstd",
+			input: compressPorts(t, content, func(w io.Writer) (io.WriteCloser, error) {
+				return zstd.NewWriter(w)
+			}),
+		},
+		{name: "bzip2", input: bzip2Data},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, closeReader, err := decompress(bytes.NewReader(tt.input))
+			assert.NoError(t, err)
+			defer closeReader()
+
+			data, err := io.ReadAll(reader)
+			assert.NoError(t, err)
+			assert.Equal(t, content, string(data))
+		})
+	}
+}
+
+func TestDecompress_ShortInput(t *testing.T) {
+	reader, closeReader, err := decompress(bytes.NewReader([]byte("{}")))
+	assert.NoError(t, err)
+	defer closeReader()
+
+	data, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", string(data))
+}
+
+func TestPortService_ProcessPorts_Compressed(t *testing.T) {
+	repo := newMockRepository()
+	service := NewPortService(repo)
+	ctx := context.Background()
+
+	// Compression is detected from the content, whatever the file is called
+	data := compressPorts(t, portsJSON(100), func(w io.Writer) (io.WriteCloser, error) {
+		return zstd.NewWriter(w)
+	})
+	report, err := service.ProcessPortsFile(ctx, writePortsFile(t, string(data)), domain.ImportOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 90, report.Created)
+	assert.Equal(t, 10, report.Updated)
+
+	// Corrupt streams fail the import
+	data = compressPorts(t, portsJSON(100), func(w io.Writer) (io.WriteCloser, error) {
+		return gzip.NewWriter(w), nil
+	})
+	data[len(data)/2] ^= 0xff
+	_, err = service.ProcessPorts(ctx, bytes.NewReader(data), domain.ImportOptions{Mode: domain.ImportStrict})
+	assert.Error(t, err)
+}
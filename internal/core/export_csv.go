@@ -0,0 +1,110 @@
+package core
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"portservice/internal/domain"
+)
+
+// csvEncoder writes ports as CSV rows under a header row naming the columns
+// chosen by its options
+type csvEncoder struct {
+	buffer  *bufio.Writer
+	writer  *csv.Writer
+	opts    domain.CSVOptions
+	fields  []domain.CSVField
+	row     []string
+	started bool
+}
+
+// newCSVEncoder creates a csvEncoder writing to w
+func newCSVEncoder(w io.Writer, opts domain.CSVOptions) *csvEncoder {
+	buffer := bufio.NewWriter(w)
+	writer := csv.NewWriter(buffer)
+	writer.Comma = opts.Delimiter()
+	fields := opts.ExportFields()
+	return &csvEncoder{buffer: buffer, writer: writer, opts: opts, fields: fields, row: make([]string, len(fields))}
+}
+
+// encode writes port as the next row
+func (e *csvEncoder) encode(port *domain.Port) error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	for i, field := range e.fields {
+		e.row[i] = e.value(port, field)
+	}
+	return e.writer.Write(e.row)
+}
+
+// close writes the header if no port was written and flushes the output
+func (e *csvEncoder) close() error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	e.writer.Flush()
+	if err := e.writer.Error(); err != nil {
+		return err
+	}
+	return e.buffer.Flush()
+}
+
+// start writes the optional byte order mark and the header row before the
+// first row
+func (e *csvEncoder) start() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+	if e.opts.BOM {
+		if _, err := e.buffer.WriteString(utf8BOM); err != nil {
+			return err
+		}
+	}
+	for i, field := range e.fields {
+		e.row[i] = e.opts.Column(field)
+	}
+	return e.writer.Write(e.row)
+}
+
+// value returns the column value of field for port, in the form csvSource
+// reads back
+func (e *csvEncoder) value(port *domain.Port, field domain.CSVField) string {
+	switch field {
+	case domain.CSVID:
+		return port.ID
+	case domain.CSVName:
+		return port.Name
+	case domain.CSVCity:
+		return port.City
+	case domain.CSVCountry:
+		return port.Country
+	case domain.CSVLongitude:
+		if port.Coordinates == nil {
+			return ""
+		}
+		return strconv.FormatFloat(port.Coordinates.Longitude, 'f', -1, 64)
+	case domain.CSVLatitude:
+		if port.Coordinates == nil {
+			return ""
+		}
+		return strconv.FormatFloat(port.Coordinates.Latitude, 'f', -1, 64)
+	case domain.CSVProvince:
+		return port.Province
+	case domain.CSVTimezone:
+		return port.Timezone
+	case domain.CSVUnlocs:
+		return e.opts.JoinList(port.Unlocs)
+	case domain.CSVCode:
+		return port.Code
+	case domain.CSVAlias:
+		return e.opts.JoinList(port.Alias)
+	case domain.CSVRegions:
+		return e.opts.JoinList(port.Regions)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"portservice/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPortService_ExportPorts_CSV(t *testing.T) {
+	service := NewPortService(newMockRepository())
+	ctx := context.Background()
+
+	content := `{
+		"AEAUH": {"name": "Abu Dhabi", "province": "Abu Z¸aby [Abu Dhabi]", "coordinates": [54.37, 24.47],
+			"alias": ["Abu Dhabi, UAE", "Said \"AD\""], "unlocs": ["AEAUH", "AEABU"]},
+		"NLRTM": {"name": "Rotterdam", "country": "Netherlands", "coordinates": [4.47, 51.92]}
+	}`
+	_, err := service.ProcessPorts(ctx, strings.NewReader(content), domain.ImportOptions{})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	count, err := service.ExportPorts(ctx, &buf, domain.ExportOptions{Format: domain.ExportCSV})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, `id,name,city,country,lon,lat,province,timezone,unlocs,code,alias,regions
+AEAUH,Abu Dhabi,,,54.37,24.47,Abu Z¸aby [Abu Dhabi],,AEAUH;AEABU,,"Abu Dhabi, UAE;Said ""AD""",
+NLRTM,Rotterdam,,Netherlands,4.47,51.92,,,,,,
+`, buf.String())
+
+	// Exports import back unchanged
+	report, err := service.ProcessPorts(ctx, &buf, domain.ImportOptions{Format: domain.ImportCSV})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Unchanged)
+	assert.Zero(t, report.Rejected)
+}
+
+func TestPortService_ExportPorts_CSVOptions(t *testing.T) {
+	service := NewPortService(newMockRepository())
+	ctx := context.Background()
+
+	content := `{"AEAUH": {"name": "Abu Dhabi", "coordinates": [54.37, 24.47], "unlocs": ["AEAUH", "AEABU"]}}`
+	_, err := service.ProcessPorts(ctx, strings.NewReader(content), domain.ImportOptions{})
+	assert.NoError(t, err)
+
+	csvOpts := domain.CSVOptions{
+		Columns:       map[domain.CSVField]string{domain.CSVID: "LOCODE"},
+		Fields:        []domain.CSVField{domain.CSVID, domain.CSVUnlocs, domain.CSVName},
+		BOM:           true,
+		Comma:         '\t',
+		ListSeparator: "|",
+	}
+	var buf bytes.Buffer
+	_, err = service.ExportPorts(ctx, &buf, domain.ExportOptions{Format: domain.ExportCSV, CSV: csvOpts})
+	assert.NoError(t, err)
+	assert.Equal(t, utf8BOM+"LOCODE\tunlocs\tname\nAEAUH\tAEAUH|AEABU\tAbu Dhabi\n", buf.String())
+
+	// The header is written even when no port matches
+	buf.Reset()
+	opts := domain.ExportOptions{Format: domain.ExportCSV, CSV: csvOpts, Filter: domain.PortFilter{Countries: []string{"nowhere"}}}
+	count, err := service.ExportPorts(ctx, &buf, opts)
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+	assert.Equal(t, utf8BOM+"LOCODE\tunlocs\tname\n", buf.String())
+
+	_, err = service.ExportPorts(ctx, &buf, domain.ExportOptions{Format: domain.ExportCSV, CSV: domain.CSVOptions{Fields: []domain.CSVField{"depth"}}})
+	assert.ErrorIs(t, err, domain.ErrInvalidExportOptions)
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"portservice/internal/domain"
+)
+
+// geoJSONCollectionStart opens an exported FeatureCollection
+const geoJSONCollectionStart = `{"type":"` + geoJSONFeatureCollection + `","features":[`
+
+// geojsonEncoder writes ports as the Point features of a single GeoJSON
+// FeatureCollection
+type geojsonEncoder struct {
+	writer  *bufio.Writer
+	encoder *json.Encoder
+	started bool
+}
+
+// geoJSONFeatureOut is an exported port feature
+type geoJSONFeatureOut struct {
+	Type       string             `json:"type"`
+	ID         string             `json:"id"`
+	Geometry   geoJSONPointOut    `json:"geometry"`
+	Properties geoJSONPropertyOut `json:"properties"`
+}
+
+// geoJSONPointOut is the geometry of an exported port feature
+type geoJSONPointOut struct {
+	Type        string            `json:"type"`
+	Coordinates domain.Coordinate `json:"coordinates"`
+}
+
+// geoJSONPropertyOut holds every port field but the coordinates, which are
+// left nil so they are omitted in favour of the feature geometry
+type geoJSONPropertyOut struct {
+	*domain.Port
+	Coordinates *domain.Coordinate `json:"coordinates,omitempty"`
+}
+
+// newGeoJSONEncoder creates a geojsonEncoder writing to w
+func newGeoJSONEncoder(w io.Writer) *geojsonEncoder {
+	writer := bufio.NewWriter(w)
+	encoder := json.NewEncoder(writer)
+	encoder.SetEscapeHTML(false)
+	return &geojsonEncoder{writer: writer, encoder: encoder}
+}
+
+// encode writes port as the next feature of the collection
+func (e *geojsonEncoder) encode(port *domain.Port) error {
+	if port.Coordinates == nil {
+		// Validated ports always have coordinates; never emit a feature
+		// without a geometry
+		return domain.ErrInvalidPort
+	}
+	separator := ","
+	if !e.started {
+		separator = geoJSONCollectionStart
+		e.started = true
+	}
+	if _, err := e.writer.WriteString(separator); err != nil {
+		return err
+	}
+	feature := geoJSONFeatureOut{
+		Type:       geoJSONFeature,
+		ID:         port.ID,
+		Geometry:   geoJSONPointOut{Type: geoJSONPoint, Coordinates: *port.Coordinates},
+		Properties: geoJSONPropertyOut{Port: port},
+	}
+	return e.encoder.Encode(feature)
+}
+
+// close ends the collection, which is written even when empty, and flushes
+// the output
+func (e *geojsonEncoder) close() error {
+	end := "]}\n"
+	if !e.started {
+		end = geoJSONCollectionStart + end
+	}
+	if _, err := e.writer.WriteString(end); err != nil {
+		return err
+	}
+	return e.writer.Flush()
+}
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"portservice/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPortService_ExportPorts_GeoJSON(t *testing.T) {
+	service := NewPortService(newMockRepository())
+	ctx := context.Background()
+
+	_, err := service.ProcessPorts(ctx, strings.NewReader(portsJSON(50)), domain.ImportOptions{})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	count, err := service.ExportPorts(ctx, &buf, domain.ExportOptions{Format: domain.ExportGeoJSON})
+	assert.NoError(t, err)
+	assert.Equal(t, 45, count)
+
+	var collection struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Type     string `json:"type"`
+			ID       string `json:"id"`
+			Geometry struct {
+				Type        string     `json:"type"`
+				Coordinates [2]float64 `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"features"`
+	}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &collection))
+	assert.Equal(t, "FeatureCollection", collection.Type)
+	if assert.Len(t, collection.Features, 45) {
+		feature := collection.Features[0]
+		assert.Equal(t, "Feature", feature.Type)
+		assert.Equal(t, "PORT0", feature.ID)
+		assert.Equal(t, "Point", feature.Geometry.Type)
+		assert.Equal(t, "Port 0", feature.Properties["name"])
+		assert.NotContains(t, feature.Properties, "coordinates")
+
+		port, err := service.GetPort(ctx, "PORT0")
+		assert.NoError(t, err)
+		assert.Equal(t, [2]float64{port.Coordinates.Longitude, port.Coordinates.Latitude}, feature.Geometry.Coordinates)
+	}
+
+	// Exports import back unchanged
+	report, err := service.ProcessPorts(ctx, &buf, domain.ImportOptions{Format: domain.ImportGeoJSON})
+	assert.NoError(t, err)
+	assert.Equal(t, 45, report.Unchanged)
+	assert.Zero(t, report.Rejected)
+}
+
+func TestPortService_ExportPorts_GeoJSONEmpty(t *testing.T) {
+	service := NewPortService(newMockRepository())
+
+	var buf bytes.Buffer
+	count, err := service.ExportPorts(context.Background(), &buf, domain.ExportOptions{Format: domain.ExportGeoJSON})
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+	assert.JSONEq(t, `{"type": "FeatureCollection", "features": []}`, buf.String())
+}
@@ -0,0 +1,108 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"portservice/internal/domain"
+)
+
+// jsonRecord is a port as written to a keyed ports file. Its fields are in
+// the order the original ports file lists them, and lists are never null,
+// so exports are stable byte for byte.
+type jsonRecord struct {
+	Name        string                `json:"name"`
+	City        string                `json:"city"`
+	Country     string                `json:"country"`
+	Alias       []string              `json:"alias"`
+	Regions     []string              `json:"regions"`
+	Coordinates *domain.Coordinate    `json:"coordinates"`
+	Province    string                `json:"province"`
+	Timezone    string                `json:"timezone"`
+	Unlocs      []string              `json:"unlocs"`
+	Code        string                `json:"code"`
+	Functions   []domain.PortFunction `json:"functions,omitempty"`
+}
+
+// newJSONRecord returns the ports file record of port
+func newJSONRecord(port *domain.Port) jsonRecord {
+	nonNil := func(values []string) []string {
+		if values == nil {
+			return []string{}
+		}
+		return values
+	}
+	return jsonRecord{
+		Name:        port.Name,
+		City:        port.City,
+		Country:     port.Country,
+		Alias:       nonNil(port.Alias),
+		Regions:     nonNil(port.Regions),
+		Coordinates: port.Coordinates,
+		Province:    port.Province,
+		Timezone:    port.Timezone,
+		Unlocs:      nonNil(port.Unlocs),
+		Code:        port.Code,
+		Functions:   port.Functions,
+	}
+}
+
+// jsonEncoder writes ports as the members of one JSON object keyed by port
+// ID, indented like the original ports file
+type jsonEncoder struct {
+	writer  *bufio.Writer
+	buffer  bytes.Buffer
+	encoder *json.Encoder
+	started bool
+}
+
+// newJSONEncoder creates a jsonEncoder writing to w
+func newJSONEncoder(w io.Writer) *jsonEncoder {
+	e := &jsonEncoder{writer: bufio.NewWriter(w)}
+	e.encoder = json.NewEncoder(&e.buffer)
+	e.encoder.SetEscapeHTML(false)
+	e.encoder.SetIndent("  ", "  ")
+	return e
+}
+
+// encode writes port as the next member of the object
+func (e *jsonEncoder) encode(port *domain.Port) error {
+	separator := ",\n  "
+	if !e.started {
+		separator = "{\n  "
+		e.started = true
+	}
+
+	e.buffer.Reset()
+	if err := e.encoder.Encode(port.ID); err != nil {
+		return err
+	}
+	e.buffer.Truncate(e.buffer.Len() - 1)
+	e.buffer.WriteString(": ")
+	if err := e.encoder.Encode(newJSONRecord(port)); err != nil {
+		return err
+	}
+	// Drop the newline the encoder ends each value with
+	e.buffer.Truncate(e.buffer.Len() - 1)
+
+	if _, err := e.writer.WriteString(separator); err != nil {
+		return err
+	}
+	_, err := e.writer.Write(e.buffer.Bytes())
+	return err
+}
+
+// close ends the object, which is written even when empty, and flushes the
+// output
+func (e *jsonEncoder) close() error {
+	end := "\n}\n"
+	if !e.started {
+		end = "{}\n"
+	}
+	if _, err := e.writer.WriteString(end); err != nil {
+		return err
+	}
+	return e.writer.Flush()
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"portservice/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPortService_ExportPorts_JSON(t *testing.T) {
+	service := NewPortService(newMockRepository())
+	ctx := context.Background()
+
+	// Fields are listed out of order, and a nil list comes from the API
+	content := `{"AEAUH": {"coordinates": [54.37, 24.47], "name": "Abu Dhabi", "unlocs": ["AEAUH"], "code": "52001"},
+		"AEAJM": {"name": "Ajman", "city": "Ajman", "country": "United Arab Emirates", "alias": ["<Ajman>"],
+			"coordinates": [55.5136433, 25.4052165], "functions": ["port", "road"]}}`
+	_, err := service.ProcessPorts(ctx, strings.NewReader(content), domain.ImportOptions{})
+	assert.NoError(t, err)
+	err = service.CreateOrUpdatePort(ctx, &domain.Port{ID: "NLRTM", Name: "Rotterdam", Coordinates: &domain.Coordinate{Longitude: 4.47, Latitude: 51.92}})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	count, err := service.ExportPorts(ctx, &buf, domain.ExportOptions{Format: domain.ExportJSON})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, `{
+  "AEAJM": {
+    "name": "Ajman",
+    "city": "Ajman",
+    "country": "United Arab Emirates",
+    "alias": [
+      "<Ajman>"
+    ],
+    "regions": [],
+    "coordinates": [
+      55.5136433,
+      25.4052165
+    ],
+    "province": "",
+    "timezone": "",
+    "unlocs": [],
+    "code": "",
+    "functions": [
+      "port",
+      "road"
+    ]
+  },
+  "AEAUH": {
+    "name": "Abu Dhabi",
+    "city": "",
+    "country": "",
+    "alias": [],
+    "regions": [],
+    "coordinates": [
+      54.37,
+      24.47
+    ],
+    "province": "",
+    "timezone": "",
+    "unlocs": [
+      "AEAUH"
+    ],
+    "code": "52001"
+  },
+  "NLRTM": {
+    "name": "Rotterdam",
+    "city": "",
+    "country": "",
+    "alias": [],
+    "regions": [],
+    "coordinates": [
+      4.47,
+      51.92
+    ],
+    "province": "",
+    "timezone": "",
+    "unlocs": [],
+    "code": ""
+  }
+}
+`, buf.String())
+
+	// Importing an export and exporting again yields the same bytes
+	exported := buf.String()
+	other := NewPortService(newMockRepository())
+	report, err := other.ProcessPorts(ctx, strings.NewReader(exported), domain.ImportOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, report.Created)
+
+	buf.Reset()
+	_, err = other.ExportPorts(ctx, &buf, domain.ExportOptions{Format: domain.ExportJSON})
+	assert.NoError(t, err)
+	assert.Equal(t, exported, buf.String())
+}
+
+func TestPortService_ExportPorts_JSONEmpty(t *testing.T) {
+	service := NewPortService(newMockRepository())
+
+	var buf bytes.Buffer
+	count, err := service.ExportPorts(context.Background(), &buf, domain.ExportOptions{Format: domain.ExportJSON})
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+	assert.Equal(t, "{}\n", buf.String())
+}
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"portservice/internal/domain"
+)
+
+// ExportPorts writes the ports matching opts.Filter to w in opts.Format,
+// ordered by port ID, and returns how many were written. Ports are read
+// from the repository a page at a time, so memory use stays bounded.
+func (s *portService) ExportPorts(ctx context.Context, w io.Writer, opts domain.ExportOptions) (int, error) {
+	if err := opts.Validate(); err != nil {
+		return 0, err
+	}
+	format, _ := domain.ParseExportFormat(string(opts.Format))
+	encoder := newPortEncoder(w, format, opts.CSV)
+
+	count := 0
+	err := s.forEachPort(ctx, opts.Filter, func(port *domain.Port) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := encoder.encode(port); err != nil {
+			return fmt.Errorf("failed to export port %v: %w", port.ID, err)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+	if err := encoder.close(); err != nil {
+		return count, fmt.Errorf("failed to finish export: %w", err)
+	}
+	return count, nil
+}
+
+// portEncoder writes ports to an export in one format
+type portEncoder interface {
+	// encode writes the next port
+	encode(port *domain.Port) error
+
+	// close writes anything the format ends with and flushes the output
+	close() error
+}
+
+// newPortEncoder returns a portEncoder writing format to w, laying out CSV
+// as csvOpts chooses
+func newPortEncoder(w io.Writer, format domain.ExportFormat, csvOpts domain.CSVOptions) portEncoder {
+	switch format {
+	case domain.ExportGeoJSON:
+		return newGeoJSONEncoder(w)
+	case domain.ExportJSON:
+		return newJSONEncoder(w)
+	case domain.ExportCSV:
+		return newCSVEncoder(w, csvOpts)
+	default:
+		return newNDJSONEncoder(w)
+	}
+}
+
+// ndjsonEncoder writes one JSON port per line
+type ndjsonEncoder struct {
+	writer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+// newNDJSONEncoder creates an ndjsonEncoder writing to w
+func newNDJSONEncoder(w io.Writer) *ndjsonEncoder {
+	writer := bufio.NewWriter(w)
+	encoder := json.NewEncoder(writer)
+	encoder.SetEscapeHTML(false)
+	return &ndjsonEncoder{writer: writer, encoder: encoder}
+}
+
+// encode writes port followed by a newline
+func (e *ndjsonEncoder) encode(port *domain.Port) error {
+	return e.encoder.Encode(port)
+}
+
+// close flushes the buffered lines
+func (e *ndjsonEncoder) close() error {
+	return e.writer.Flush()
+}
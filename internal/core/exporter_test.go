@@ -0,0 +1,79 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"portservice/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestPortService_ExportPorts_NDJSON(t *testing.T) {
+	service := NewPortService(newMockRepository())
+	ctx := context.Background()
+
+	_, err := service.ProcessPorts(ctx, strings.NewReader(portsJSON(50)), domain.ImportOptions{})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	count, err := service.ExportPorts(ctx, &buf, domain.ExportOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 45, count)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Len(t, lines, 45)
+	assert.True(t, strings.HasPrefix(lines[0], `{"id":"PORT0","name":"Port 0",`))
+
+	// Exports import back unchanged
+	report, err := service.ProcessPorts(ctx, &buf, domain.ImportOptions{Format: domain.ImportNDJSON})
+	assert.NoError(t, err)
+	assert.Equal(t, 45, report.Unchanged)
+	assert.Zero(t, report.Rejected)
+}
+
+func TestPortService_ExportPorts_Filter(t *testing.T) {
+	service := NewPortService(newMockRepository())
+	ctx := context.Background()
+
+	content := `{
+		"AEAJM": {"name": "Ajman", "country": "United Arab Emirates", "coordinates": [55.5136433, 25.4052165]},
+		"NLRTM": {"name": "Rotterdam", "country": "Netherlands", "coordinates": [4.47, 51.92]}
+	}`
+	_, err := service.ProcessPorts(ctx, strings.NewReader(content), domain.ImportOptions{})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	count, err := service.ExportPorts(ctx, &buf, domain.ExportOptions{Filter: domain.PortFilter{Countries: []string{"netherlands"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Contains(t, buf.String(), `"id":"NLRTM"`)
+}
+
+func TestPortService_ExportPorts_Errors(t *testing.T) {
+	service := NewPortService(newMockRepository())
+	ctx := context.Background()
+
+	_, err := service.ExportPorts(ctx, &bytes.Buffer{}, domain.ExportOptions{Format: "xml"})
+	assert.ErrorIs(t, err, domain.ErrInvalidExportOptions)
+
+	_, err = service.ProcessPorts(ctx, strings.NewReader(portsJSON(10)), domain.ImportOptions{})
+	assert.NoError(t, err)
+	_, err = service.ExportPorts(ctx, failingWriter{}, domain.ExportOptions{})
+	assert.ErrorContains(t, err, "disk full")
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = service.ExportPorts(canceled, &bytes.Buffer{}, domain.ExportOptions{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
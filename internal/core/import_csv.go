@@ -0,0 +1,148 @@
+package core
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"portservice/internal/domain"
+)
+
+// utf8BOM is the byte order mark some spreadsheets write before CSV data
+const utf8BOM = "\uFEFF"
+
+// requiredCSVFields are the fields a CSV header must have columns for
+var requiredCSVFields = []domain.CSVField{domain.CSVID, domain.CSVName, domain.CSVLongitude, domain.CSVLatitude}
+
+// csvSource reads port records from CSV rows, locating each field's column
+// by header name
+type csvSource struct {
+	reader *csv.Reader
+	opts   domain.CSVOptions
+
+	// columns holds the index of each field's column; fields without a
+	// column are absent
+	columns map[domain.CSVField]int
+}
+
+// newCSVSource creates a csvSource, reading the header row from input
+func newCSVSource(input io.Reader, opts domain.CSVOptions) (*csvSource, error) {
+	reader := csv.NewReader(input)
+	reader.Comma = opts.Delimiter()
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	header[0] = strings.TrimPrefix(header[0], utf8BOM)
+
+	indexes := make(map[string]int, len(header))
+	for i, name := range header {
+		name = domain.NormalizeFilterValue(name)
+		if _, ok := indexes[name]; !ok {
+			indexes[name] = i
+		}
+	}
+
+	columns := make(map[domain.CSVField]int)
+	for _, field := range domain.CSVFields {
+		if i, ok := indexes[domain.NormalizeFilterValue(opts.Column(field))]; ok {
+			columns[field] = i
+		}
+	}
+	for _, field := range requiredCSVFields {
+		if _, ok := columns[field]; !ok {
+			return nil, fmt.Errorf("%w: CSV header has no %q column for %s",
+				domain.ErrInvalidImportOptions, opts.Column(field), field)
+		}
+	}
+
+	return &csvSource{reader: reader, opts: opts, columns: columns}, nil
+}
+
+// read reads the next row into record
+func (s *csvSource) read(record *importRecord) error {
+	record.offset = s.reader.InputOffset()
+	fields, err := s.reader.Read()
+	if err == io.EOF {
+		return io.EOF
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read CSV record: %w", err)
+	}
+	record.fields = fields
+	record.line, _ = s.reader.FieldPos(0)
+	record.portID = s.value(fields, domain.CSVID)
+	return nil
+}
+
+// convert builds a port from a CSV row
+func (s *csvSource) convert(record *importRecord) (*domain.Port, *domain.ImportRejection) {
+	return portFromCSV(record.portID, record.fields, s)
+}
+
+// value returns the trimmed value of field in the row, or the empty string
+// when the row has no column for it
+func (s *csvSource) value(fields []string, field domain.CSVField) string {
+	i, ok := s.columns[field]
+	if !ok || i >= len(fields) {
+		return ""
+	}
+	return strings.TrimSpace(fields[i])
+}
+
+// portFromCSV builds a port from a CSV row, returning a rejection describing
+// the first invalid field instead when the row is unusable. Validation
+// matches portFromJSON.
+func portFromCSV(portID string, fields []string, s *csvSource) (*domain.Port, *domain.ImportRejection) {
+	reject := func(field, reason string) (*domain.Port, *domain.ImportRejection) {
+		return nil, &domain.ImportRejection{PortID: portID, Field: field, Reason: reason}
+	}
+
+	if portID == "" {
+		return reject("id", "empty port ID")
+	}
+	name := s.value(fields, domain.CSVName)
+	if name == "" {
+		return reject("name", "invalid or missing name")
+	}
+
+	// Extract and validate coordinates
+	rawLon, rawLat := s.value(fields, domain.CSVLongitude), s.value(fields, domain.CSVLatitude)
+	if rawLon == "" || rawLat == "" {
+		return reject("coordinates", "invalid coordinates format")
+	}
+	lon, lonErr := strconv.ParseFloat(rawLon, 64)
+	lat, latErr := strconv.ParseFloat(rawLat, 64)
+	if lonErr != nil || latErr != nil {
+		return reject("coordinates", "invalid coordinate types")
+	}
+	if lon < -180 || lon > 180 {
+		return reject("coordinates", fmt.Sprintf("invalid longitude: %v", lon))
+	}
+	if lat < -90 || lat > 90 {
+		return reject("coordinates", fmt.Sprintf("invalid latitude: %v", lat))
+	}
+
+	port, err := domain.NewPort(
+		portID,
+		name,
+		s.value(fields, domain.CSVCity),
+		s.value(fields, domain.CSVCountry),
+		[]float64{lon, lat},
+		s.value(fields, domain.CSVProvince),
+		s.value(fields, domain.CSVTimezone),
+		s.opts.SplitList(s.value(fields, domain.CSVUnlocs)),
+		s.value(fields, domain.CSVCode),
+		domain.WithAlias(s.opts.SplitList(s.value(fields, domain.CSVAlias))...),
+		domain.WithRegions(s.opts.SplitList(s.value(fields, domain.CSVRegions))...),
+	)
+	if err != nil {
+		return reject("", err.Error())
+	}
+	return port, nil
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"portservice/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPortService_ProcessPorts_CSV(t *testing.T) {
+	repo := newMockRepository()
+	service := NewPortService(repo)
+	ctx := context.Background()
+
+	content := utf8BOM + "id,name,city,country,lon,lat,province,timezone,unlocs,code\n" +
+		"AEAJM,Ajman,Ajman,United Arab Emirates,55.5136433,25.4052165,Ajman,Asia/Dubai,AEAJM; AEAJX,52000\n" +
+		"AEAUH,Abu Dhabi,Abu Dhabi,United Arab Emirates,54.37,24.47,,,,\n"
+	report, err := service.ProcessPorts(ctx, strings.NewReader(content), domain.ImportOptions{Format: domain.ImportCSV})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Created)
+	assert.Empty(t, report.Rejections)
+
+	port, err := service.GetPort(ctx, "AEAJM")
+	assert.NoError(t, err)
+	assert.Equal(t, "Ajman", port.Name)
+	assert.Equal(t, "Asia/Dubai", port.Timezone)
+	assert.Equal(t, []string{"AEAJM", "AEAJX"}, port.Unlocs)
+	assert.Equal(t, domain.Coordinate{Longitude: 55.5136433, Latitude: 25.4052165}, *port.Coordinates)
+
+	// Rows matching the JSON import are reported unchanged
+	jsonContent := `{
+		"AEAUH": {"name": "Abu Dhabi", "city": "Abu Dhabi", "country": "United Arab Emirates", "coordinates": [54.37, 24.47]}
+	}`
+	report, err = service.ProcessPorts(ctx, strings.NewReader(jsonContent), domain.ImportOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Unchanged)
+}
+
+func TestPortService_ProcessPorts_CSVColumns(t *testing.T) {
+	service := NewPortService(newMockRepository())
+	ctx := context.Background()
+
+	content := "Port Name;LOCODE;Latitude;Longitude;Aliases\n" +
+		"Ajman;AEAJM;25.4052165;55.5136433;Ajman Port|Ajman Harbour\n"
+	opts := domain.ImportOptions{
+		Format: domain.ImportCSV,
+		CSV: domain.CSVOptions{
+			Columns: map[domain.CSVField]string{
+				domain.CSVID:        "locode",
+				domain.CSVName:      "Port Name",
+				domain.CSVLongitude: "Longitude",
+				domain.CSVLatitude:  "Latitude",
+				domain.CSVAlias:     "Aliases",
+			},
+			Comma:         ';',
+			ListSeparator: "|",
+		},
+	}
+	report, err := service.ProcessPorts(ctx, strings.NewReader(content), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+
+	port, err := service.GetPort(ctx, "AEAJM")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Ajman Port", "Ajman Harbour"}, port.Alias)
+	assert.Equal(t, 55.5136433, port.Coordinates.Longitude)
+
+	// Headers missing a required column fail before anything is read
+	_, err = service.ProcessPorts(ctx, strings.NewReader("id,name,lon\nX,X,1\n"), domain.ImportOptions{Format: domain.ImportCSV})
+	assert.ErrorIs(t, err, domain.ErrInvalidImportOptions)
+}
+
+func TestPortService_ProcessPorts_CSVRejections(t *testing.T) {
+	service := NewPortService(newMockRepository())
+
+	content := "id,name,lon,lat\n" +
+		",No ID,55.5,25.4\n" +
+		"NONAME,,55.5,25.4\n" +
+		"NOCOORDS,No Coordinates,,25.4\n" +
+		"BADTYPES,Bad Types,east,25.4\n" +
+		"BADLON,Bad Longitude,200,25.4\n" +
+		"BADLAT,Bad Latitude,55.5,-95\n" +
+		"SHORT,Short Row\n" +
+		"VALID,Valid Port,55.5,25.4\n"
+	report, err := service.ProcessPorts(context.Background(), strings.NewReader(content), domain.ImportOptions{Format: domain.ImportCSV})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+
+	want := []domain.ImportRejection{
+		{PortID: "", Field: "id", Reason: "empty port ID", Offset: 16, Line: 2},
+		{PortID: "NONAME", Field: "name", Reason: "invalid or missing name", Offset: 33, Line: 3},
+		{PortID: "NOCOORDS", Field: "coordinates", Reason: "invalid coordinates format", Offset: 51, Line: 4},
+		{PortID: "BADTYPES", Field: "coordinates", Reason: "invalid coordinate types", Offset: 81, Line: 5},
+		{PortID: "BADLON", Field: "coordinates", Reason: "invalid longitude: 200", Offset: 110, Line: 6},
+		{PortID: "BADLAT", Field: "coordinates", Reason: "invalid latitude: -95", Offset: 140, Line: 7},
+		{PortID: "SHORT", Field: "coordinates", Reason: "invalid coordinates format", Offset: 169, Line: 8},
+	}
+	assert.Equal(t, want, report.Rejections)
+
+	// Malformed CSV aborts the import
+	_, err = service.ProcessPorts(context.Background(), strings.NewReader("id,name,lon,lat\n\"X,X,1,2\n"),
+		domain.ImportOptions{Format: domain.ImportCSV})
+	assert.ErrorContains(t, err, "failed to read CSV record")
+}
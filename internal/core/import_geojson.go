@@ -0,0 +1,199 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"portservice/internal/domain"
+)
+
+// GeoJSON object types ports are imported from and exported as
+const (
+	geoJSONFeatureCollection = "FeatureCollection"
+	geoJSONFeature           = "Feature"
+	geoJSONPoint             = "Point"
+)
+
+// geojsonSource reads the features of a GeoJSON FeatureCollection, taking
+// each port's ID from the feature ID or else its id property
+type geojsonSource struct {
+	decoder *json.Decoder
+
+	// done is set once the closing delimiters and the end of the input are
+	// read
+	done bool
+}
+
+// newGeoJSONSource creates a geojsonSource positioned inside the input's
+// features array
+func newGeoJSONSource(input io.Reader) (*geojsonSource, error) {
+	decoder := json.NewDecoder(input)
+	if token, err := decoder.Token(); err != nil {
+		return nil, fmt.Errorf("failed to read GeoJSON start: %w", err)
+	} else if token != json.Delim('{') {
+		return nil, fmt.Errorf("%w: GeoJSON must be a %s object", domain.ErrInvalidPort, geoJSONFeatureCollection)
+	}
+
+	// Skip members up to the features array, checking the type on the way
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GeoJSON member: %w", err)
+		}
+		if token != "features" {
+			if err := readGeoJSONMember(decoder, token); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if token, err := decoder.Token(); err != nil {
+			return nil, fmt.Errorf("failed to read GeoJSON features: %w", err)
+		} else if token != json.Delim('[') {
+			return nil, fmt.Errorf("%w: GeoJSON features must be an array", domain.ErrInvalidPort)
+		}
+		return &geojsonSource{decoder: decoder}, nil
+	}
+	return nil, fmt.Errorf("%w: GeoJSON has no features", domain.ErrInvalidPort)
+}
+
+// readGeoJSONMember reads the value of a FeatureCollection member other
+// than features, checking the type and skipping anything else
+func readGeoJSONMember(decoder *json.Decoder, name json.Token) error {
+	if name != "type" {
+		var skipped json.RawMessage
+		if err := decoder.Decode(&skipped); err != nil {
+			return fmt.Errorf("failed to read GeoJSON member: %w", err)
+		}
+		return nil
+	}
+	var objectType string
+	if err := decoder.Decode(&objectType); err != nil {
+		return fmt.Errorf("failed to read GeoJSON type: %w", err)
+	}
+	if objectType != geoJSONFeatureCollection {
+		return fmt.Errorf("%w: unsupported GeoJSON type %q, expected %s",
+			domain.ErrInvalidPort, objectType, geoJSONFeatureCollection)
+	}
+	return nil
+}
+
+// read reads the next feature into record
+func (s *geojsonSource) read(record *importRecord) error {
+	if s.done {
+		return io.EOF
+	}
+	if !s.decoder.More() {
+		return s.readEnd()
+	}
+	record.offset = s.decoder.InputOffset()
+	if err := s.decoder.Decode(&record.raw); err != nil {
+		return fmt.Errorf("failed to decode GeoJSON feature: %w", err)
+	}
+	return nil
+}
+
+// readEnd reads the closing bracket of the features array, any members
+// after it and the closing brace of the collection, so input cut off
+// between two features fails the import rather than committing part of
+// it, and checks that only whitespace follows
+func (s *geojsonSource) readEnd() error {
+	if err := s.expect(json.Delim(']')); err != nil {
+		return err
+	}
+	for s.decoder.More() {
+		token, err := s.decoder.Token()
+		if err != nil {
+			return fmt.Errorf("failed to read GeoJSON member: %w", err)
+		}
+		if token == "features" {
+			return fmt.Errorf("%w: GeoJSON has more than one features member", domain.ErrInvalidPort)
+		}
+		if err := readGeoJSONMember(s.decoder, token); err != nil {
+			return err
+		}
+	}
+	if err := s.expect(json.Delim('}')); err != nil {
+		return err
+	}
+	if _, err := s.decoder.Token(); err != io.EOF {
+		if err == nil {
+			err = errors.New("unexpected data after GeoJSON object")
+		}
+		return fmt.Errorf("failed to read GeoJSON end: %w", err)
+	}
+	s.done = true
+	return io.EOF
+}
+
+// expect reads the next token, failing unless it is delim
+func (s *geojsonSource) expect(delim json.Delim) error {
+	token, err := s.decoder.Token()
+	if err == io.EOF {
+		return fmt.Errorf("failed to read GeoJSON end: %w", io.ErrUnexpectedEOF)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read GeoJSON end: %w", err)
+	}
+	if token != delim {
+		return fmt.Errorf("failed to read GeoJSON end: unexpected %v", token)
+	}
+	return nil
+}
+
+// convert builds a port from a GeoJSON feature
+func (s *geojsonSource) convert(record *importRecord) (*domain.Port, *domain.ImportRejection) {
+	return portFromGeoJSON(record.raw)
+}
+
+// portFromGeoJSON builds a port from a GeoJSON Point feature, returning a
+// rejection describing the first invalid field instead when the feature is
+// unusable. Properties are validated like ports file records.
+func portFromGeoJSON(raw json.RawMessage) (*domain.Port, *domain.ImportRejection) {
+	var feature struct {
+		Type     string      `json:"type"`
+		ID       interface{} `json:"id"`
+		Geometry *struct {
+			Type        string          `json:"type"`
+			Coordinates json.RawMessage `json:"coordinates"`
+		} `json:"geometry"`
+		Properties map[string]interface{} `json:"properties"`
+	}
+	if err := json.Unmarshal(raw, &feature); err != nil || feature.Type != geoJSONFeature {
+		return nil, &domain.ImportRejection{Reason: "record must be a GeoJSON Feature"}
+	}
+
+	portID, ok := feature.ID.(string)
+	if feature.ID == nil {
+		portID, ok = feature.Properties["id"].(string)
+	}
+	reject := func(field, reason string) (*domain.Port, *domain.ImportRejection) {
+		return nil, &domain.ImportRejection{PortID: portID, Field: field, Reason: reason}
+	}
+	if !ok || portID == "" {
+		return reject("id", "feature ID must be a non-empty string")
+	}
+
+	if feature.Geometry == nil {
+		return reject("coordinates", "feature has no geometry")
+	}
+	if feature.Geometry.Type != geoJSONPoint {
+		return reject("coordinates", fmt.Sprintf("unsupported geometry type %q, expected %s", feature.Geometry.Type, geoJSONPoint))
+	}
+	// Positions are [longitude, latitude], optionally followed by altitude
+	var position []float64
+	if err := json.Unmarshal(feature.Geometry.Coordinates, &position); err != nil {
+		return reject("coordinates", "invalid coordinate types")
+	}
+	if len(position) != 2 && len(position) != 3 {
+		return reject("coordinates", "invalid coordinates format")
+	}
+
+	properties := feature.Properties
+	if properties == nil {
+		properties = make(map[string]interface{})
+	}
+	properties["coordinates"] = []interface{}{position[0], position[1]}
+	return portFromMap(portID, properties)
+}
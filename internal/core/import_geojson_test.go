@@ -0,0 +1,113 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"portservice/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPortService_ProcessPorts_GeoJSON(t *testing.T) {
+	service := NewPortService(newMockRepository())
+	ctx := context.Background()
+
+	content := `{"type": "FeatureCollection", "name": "ports", "features": [
+		{"type": "Feature", "id": "AEAJM", "geometry": {"type": "Point", "coordinates": [55.5136433, 25.4052165]},
+			"properties": {"name": "Ajman", "country": "United Arab Emirates", "functions": ["port"]}},
+		{"type": "Feature", "geometry": {"type": "Point", "coordinates": [54.37, 24.47, 3]},
+			"properties": {"id": "AEAUH", "name": "Abu Dhabi"}},
+		{"type": "Feature", "id": "AEDXB", "geometry": {"type": "LineString", "coordinates": [[55.27, 25.25], [55.3, 25.3]]},
+			"properties": {"name": "Dubai"}},
+		{"type": "Feature", "id": "AEFJR", "geometry": {"type": "Point", "coordinates": [25.12, 156.33]},
+			"properties": {"name": "Fujairah"}},
+		{"type": "Feature", "id": "AEKLF", "geometry": null, "properties": {"name": "Khor Fakkan"}},
+		{"type": "Feature", "id": "AEPRA", "geometry": {"type": "Point", "coordinates": [56.35]},
+			"properties": {"name": "Port Rashid"}},
+		{"type": "Point", "coordinates": [55.27, 25.25]},
+		{"type": "Feature", "geometry": {"type": "Point", "coordinates": [55.27, 25.25]}, "properties": {"name": "No ID"}}
+	]}`
+	report, err := service.ProcessPorts(ctx, strings.NewReader(content), domain.ImportOptions{Format: domain.ImportGeoJSON})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Created)
+	assert.Equal(t, 6, report.Rejected)
+
+	reasons := make(map[string]string)
+	for _, rejection := range report.Rejections {
+		assert.Positive(t, rejection.Offset)
+		reasons[rejection.PortID] = rejection.Field + ": " + rejection.Reason
+	}
+	assert.Equal(t, `coordinates: unsupported geometry type "LineString", expected Point`, reasons["AEDXB"])
+	assert.Contains(t, reasons["AEFJR"], "coordinates: ")
+	assert.Equal(t, "coordinates: feature has no geometry", reasons["AEKLF"])
+	assert.Equal(t, "coordinates: invalid coordinates format", reasons["AEPRA"])
+
+	port, err := service.GetPort(ctx, "AEAJM")
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.Coordinate{Longitude: 55.5136433, Latitude: 25.4052165}, port.Coordinates)
+	assert.Equal(t, []domain.PortFunction{domain.FunctionPort}, port.Functions)
+
+	port, err = service.GetPort(ctx, "AEAUH")
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.Coordinate{Longitude: 54.37, Latitude: 24.47}, port.Coordinates)
+}
+
+func TestPortService_ProcessPorts_GeoJSONErrors(t *testing.T) {
+	service := NewPortService(newMockRepository())
+	opts := domain.ImportOptions{Format: domain.ImportGeoJSON}
+
+	for _, content := range []string{
+		`[]`,
+		`{"type": "Feature", "geometry": {"type": "Point", "coordinates": [0, 0]}}`,
+		`{"type": "FeatureCollection"}`,
+		`{"type": "FeatureCollection", "features": {}}`,
+	} {
+		_, err := service.ProcessPorts(context.Background(), strings.NewReader(content), opts)
+		assert.ErrorIs(t, err, domain.ErrInvalidPort, content)
+	}
+
+	_, err := service.ProcessPorts(context.Background(), strings.NewReader(`{"type": "FeatureCollection", "features": [{`), opts)
+	assert.Error(t, err)
+}
+
+func TestPortService_ProcessPorts_GeoJSONTruncated(t *testing.T) {
+	ctx := context.Background()
+	opts := domain.ImportOptions{Format: domain.ImportGeoJSON}
+	feature := `{"type": "Feature", "id": "AEAJM", "geometry": {"type": "Point", "coordinates": [55.5136433, 25.4052165]},
+		"properties": {"name": "Ajman"}}`
+	start := `{"type": "FeatureCollection", "features": [` + feature
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{name: "complete", content: start + "]}\n\t "},
+		{name: "members after features", content: start + `], "name": "ports", "type": "FeatureCollection"}`},
+		{name: "truncated after feature", content: start + ",", wantErr: true},
+		{name: "missing closing bracket", content: start, wantErr: true},
+		{name: "missing closing brace", content: start + "]", wantErr: true},
+		{name: "truncated member after features", content: start + `], "name": `, wantErr: true},
+		{name: "wrong type after features", content: start + `], "type": "Feature"}`, wantErr: true},
+		{name: "second features member", content: start + `], "features": []}`, wantErr: true},
+		{name: "trailing data", content: start + "]} {}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMockRepository()
+			service := NewPortService(repo)
+
+			_, err := service.ProcessPorts(ctx, strings.NewReader(tt.content), opts)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, int64(0), repo.GetStatistics().TotalPorts, "nothing is committed")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, int64(1), repo.GetStatistics().TotalPorts)
+		})
+	}
+}
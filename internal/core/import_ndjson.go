@@ -0,0 +1,76 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"portservice/internal/domain"
+)
+
+// ndjsonSource reads newline-delimited JSON, one port record per line with
+// its ID in the id field. Lines are independent, so a malformed line is
+// rejected rather than aborting the import.
+type ndjsonSource struct {
+	reader *bufio.Reader
+
+	// offset and line locate the next line to read
+	offset int64
+	line   int
+
+	// startLine is the first line imported; earlier lines are skipped
+	startLine int
+}
+
+// newNDJSONSource creates an ndjsonSource reading input from startLine on
+func newNDJSONSource(input io.Reader, startLine int) *ndjsonSource {
+	return &ndjsonSource{reader: bufio.NewReader(input), startLine: startLine}
+}
+
+// read reads the next non-blank line into record
+func (s *ndjsonSource) read(record *importRecord) error {
+	for {
+		data, err := s.reader.ReadBytes('\n')
+		if len(data) == 0 && err == io.EOF {
+			return io.EOF
+		}
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("failed to read line %d: %w", s.line+1, err)
+		}
+
+		offset := s.offset
+		s.offset += int64(len(data))
+		s.line++
+		if s.line < s.startLine {
+			continue
+		}
+		if data = bytes.TrimSpace(data); len(data) == 0 {
+			continue
+		}
+
+		record.offset, record.line, record.raw = offset, s.line, data
+		return nil
+	}
+}
+
+// convert builds a port from an NDJSON line
+func (s *ndjsonSource) convert(record *importRecord) (*domain.Port, *domain.ImportRejection) {
+	var portData map[string]interface{}
+	err := json.Unmarshal(record.raw, &portData)
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return nil, &domain.ImportRejection{Reason: fmt.Sprintf("invalid JSON: %v", err)}
+	}
+
+	portID, _ := portData["id"].(string)
+	if err != nil || portData == nil {
+		return nil, &domain.ImportRejection{PortID: portID, Reason: "record must be a JSON object"}
+	}
+	if portID == "" {
+		return nil, &domain.ImportRejection{Field: "id", Reason: "empty port ID"}
+	}
+	return portFromMap(portID, portData)
+}
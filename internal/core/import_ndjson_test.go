@@ -0,0 +1,67 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"portservice/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPortService_ProcessPorts_NDJSON(t *testing.T) {
+	service := NewPortService(newMockRepository())
+	ctx := context.Background()
+
+	content := `{"id": "AEAJM", "name": "Ajman", "coordinates": [55.5136433, 25.4052165]}
+
+{"id": "AEAUH", "name": "Abu Dhabi", "coordinates": [54.37, 24.47]
+{"name": "No ID", "coordinates": [54.37, 24.47]}
+[1, 2]
+{"id": "AEDXB", "name": "Dubai", "coordinates": [200, 25.25]}
+{"id": "AEFJR", "name": "Fujairah", "coordinates": [56.33, 25.12]}`
+	opts := domain.ImportOptions{Format: domain.ImportNDJSON}
+	report, err := service.ProcessPorts(ctx, strings.NewReader(content), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Created)
+	assert.Equal(t, 4, report.Rejected)
+
+	// Malformed lines are rejected without stopping the import
+	lines := make([]int, 0, len(report.Rejections))
+	for _, rejection := range report.Rejections {
+		lines = append(lines, rejection.Line)
+	}
+	assert.Equal(t, []int{3, 4, 5, 6}, lines)
+	assert.Contains(t, report.Rejections[0].Reason, "invalid JSON")
+	assert.Equal(t, domain.ImportRejection{Field: "id", Reason: "empty port ID", Offset: 142, Line: 4}, report.Rejections[1])
+	assert.Equal(t, "AEDXB", report.Rejections[3].PortID)
+
+	port, err := service.GetPort(ctx, "AEFJR")
+	assert.NoError(t, err)
+	assert.Equal(t, "Fujairah", port.Name)
+}
+
+func TestPortService_ProcessPorts_NDJSONStartLine(t *testing.T) {
+	repo := newMockRepository()
+	service := NewPortService(repo)
+
+	content := `{"id": "AEAJM", "name": "Ajman", "coordinates": [55.5136433, 25.4052165]}
+{"id": "AEAUH", "name": "Abu Dhabi", "coordinates": [54.37, 24.47]}
+{"id": "AEDXB", "name": "Dubai", "coordinates": [55.27, 25.25]}
+`
+	report, err := service.ProcessPorts(context.Background(), strings.NewReader(content),
+		domain.ImportOptions{Format: domain.ImportNDJSON, StartLine: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Created)
+
+	port, err := repo.GetPort(context.Background(), "AEAJM")
+	assert.NoError(t, err)
+	assert.Nil(t, port)
+
+	// Starting past the end imports nothing
+	report, err = service.ProcessPorts(context.Background(), strings.NewReader(content),
+		domain.ImportOptions{Format: domain.ImportNDJSON, StartLine: 10})
+	assert.NoError(t, err)
+	assert.Zero(t, report.Total())
+}
@@ -0,0 +1,166 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"portservice/internal/domain"
+)
+
+// inFlightPerWorker bounds how many records each worker may have decoded
+// ahead of the writer, capping the memory held by the reorder buffer
+const inFlightPerWorker = 4
+
+// importRecord is a ports file record moving through the import pipeline
+type importRecord struct {
+	// seq is the record's position in the file
+	seq    int
+	portID string
+	offset int64
+
+	// line is the record's line number in line-based formats, else zero
+	line int
+
+	// The undecoded record, as read by the recordSource
+	raw    json.RawMessage
+	fields []string
+
+	// Set by the workers: exactly one of port, rejection and err
+	port      *domain.Port
+	rejection *domain.ImportRejection
+	err       error
+}
+
+// recordSource splits an import's input into records
+type recordSource interface {
+	// read reads the next record, returning io.EOF itself, unwrapped, at the
+	// end of the input.
+	// It is only called from the pipeline's decoder goroutine.
+	read(record *importRecord) error
+
+	// convert builds a port from a record returned by read, or a rejection
+	// describing why the record is unusable. It is called concurrently.
+	convert(record *importRecord) (*domain.Port, *domain.ImportRejection)
+}
+
+// importPipeline streams records from a single decoder goroutine through a
+// pool of conversion workers and hands them back in file order, so that
+// duplicate IDs resolve to the record appearing last in the file. The
+// decoder blocks once too many records are in flight, applying
+// back-pressure when the writer falls behind.
+type importPipeline struct {
+	cancel   context.CancelFunc
+	inFlight chan struct{}
+	results  chan *importRecord
+
+	// pending buffers records that finished ahead of the next one in order
+	pending map[int]*importRecord
+	nextSeq int
+}
+
+// startImportPipeline starts reading records from source, converting them
+// with the given number of workers
+func startImportPipeline(ctx context.Context, source recordSource, workers int) *importPipeline {
+	ctx, cancel := context.WithCancel(ctx)
+	p := &importPipeline{
+		cancel:   cancel,
+		inFlight: make(chan struct{}, workers*inFlightPerWorker),
+		results:  make(chan *importRecord, workers),
+		pending:  make(map[int]*importRecord),
+	}
+
+	records := make(chan *importRecord, workers)
+	go p.decode(ctx, source, records)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			p.convert(ctx, source, records)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(p.results)
+	}()
+
+	return p
+}
+
+// decode reads records until the end of the input, a decode error or
+// cancellation. A decode error is passed on as the final record.
+func (p *importPipeline) decode(ctx context.Context, source recordSource, records chan<- *importRecord) {
+	defer close(records)
+	for seq := 0; ; seq++ {
+		select {
+		case p.inFlight <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+
+		record := &importRecord{seq: seq}
+		// Compare io.EOF directly: wrapped EOFs mean truncated input
+		if err := source.read(record); err == io.EOF {
+			<-p.inFlight
+			return
+		} else if err != nil {
+			record.err = err
+		}
+
+		select {
+		case records <- record:
+		case <-ctx.Done():
+			return
+		}
+		if record.err != nil {
+			return
+		}
+	}
+}
+
+// convert builds ports from decoded records until records is closed
+func (p *importPipeline) convert(ctx context.Context, source recordSource, records <-chan *importRecord) {
+	for record := range records {
+		if record.err == nil {
+			record.port, record.rejection = source.convert(record)
+			if record.rejection != nil {
+				record.rejection.Offset, record.rejection.Line = record.offset, record.line
+			}
+			record.raw, record.fields = nil, nil
+		}
+
+		select {
+		case p.results <- record:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// next returns the next record in file order, or nil once every record has
+// been returned or the pipeline has been canceled
+func (p *importPipeline) next() *importRecord {
+	for {
+		if record, ok := p.pending[p.nextSeq]; ok {
+			delete(p.pending, p.nextSeq)
+			p.nextSeq++
+			<-p.inFlight
+			return record
+		}
+		record, ok := <-p.results
+		if !ok {
+			return nil
+		}
+		p.pending[record.seq] = record
+	}
+}
+
+// stop cancels the pipeline and waits for its goroutines to exit
+func (p *importPipeline) stop() {
+	p.cancel()
+	for range p.results {
+	}
+}
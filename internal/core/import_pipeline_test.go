@@ -0,0 +1,113 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"portservice/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// portsJSON builds a ports file with n valid records, every tenth of which
+// reuses the ID of the record before it
+func portsJSON(n int) string {
+	var content strings.Builder
+	content.WriteString("{")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			content.WriteString(",")
+		}
+		id := i
+		if i%10 == 9 {
+			id = i - 1
+		}
+		fmt.Fprintf(&content, `"PORT%d": {"name": "Port %d", "coordinates": [%.4f, %.4f]}`,
+			id, i, -180+float64(i%360), -90+float64(i%180))
+	}
+	content.WriteString("}")
+	return content.String()
+}
+
+func TestImportPipeline_Order(t *testing.T) {
+	source, err := newJSONSource(strings.NewReader(portsJSON(500)))
+	assert.NoError(t, err)
+
+	pipeline := startImportPipeline(context.Background(), source, 8)
+	defer pipeline.stop()
+
+	seq := 0
+	for record := pipeline.next(); record != nil; record = pipeline.next() {
+		assert.Equal(t, seq, record.seq)
+		assert.NoError(t, record.err)
+		if assert.NotNil(t, record.port) {
+			assert.Equal(t, fmt.Sprintf("Port %d", seq), record.port.Name)
+		}
+		assert.LessOrEqual(t, len(pipeline.pending), 8*inFlightPerWorker)
+		seq++
+	}
+	assert.Equal(t, 500, seq)
+}
+
+func TestImportPipeline_DecodeError(t *testing.T) {
+	source, err := newJSONSource(strings.NewReader(`{"AEAJM": {"name": "Ajman", "coordinates": [55.5, 25.4]}, "AEAUH": {`))
+	assert.NoError(t, err)
+
+	pipeline := startImportPipeline(context.Background(), source, 4)
+	defer pipeline.stop()
+
+	record := pipeline.next()
+	if assert.NotNil(t, record) {
+		assert.NotNil(t, record.port)
+	}
+	record = pipeline.next()
+	if assert.NotNil(t, record) {
+		assert.ErrorContains(t, record.err, "failed to decode port data")
+	}
+	assert.Nil(t, pipeline.next())
+}
+
+func TestImportPipeline_Cancel(t *testing.T) {
+	source, err := newJSONSource(strings.NewReader(portsJSON(5000)))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pipeline := startImportPipeline(ctx, source, 4)
+	assert.NotNil(t, pipeline.next())
+	cancel()
+
+	// The pipeline winds down without delivering every record
+	count := 1
+	for record := pipeline.next(); record != nil; record = pipeline.next() {
+		count++
+	}
+	assert.Less(t, count, 5000)
+	pipeline.stop()
+}
+
+func TestPortService_ProcessFile_Pipelined(t *testing.T) {
+	ctx := context.Background()
+	path := writePortsFile(t, portsJSON(2000))
+
+	for _, opts := range []domain.ImportOptions{
+		{Workers: 1, BatchSize: 1},
+		{Workers: 8, BatchSize: 7},
+		{},
+	} {
+		repo := newMockRepository()
+		service := NewPortService(repo)
+
+		report, err := service.ProcessPortsFile(ctx, path, opts)
+		assert.NoError(t, err)
+		assert.Equal(t, 1800, report.Created)
+		assert.Equal(t, 200, report.Updated)
+		assert.Equal(t, int64(1800), repo.GetStatistics().TotalPorts)
+
+		// Duplicate IDs resolve to the record appearing last in the file
+		port, err := service.GetPort(ctx, "PORT8")
+		assert.NoError(t, err)
+		assert.Equal(t, "Port 9", port.Name)
+	}
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"portservice/internal/domain"
+)
+
+// newRecordSource returns a recordSource reading input in the format chosen
+// by opts, after reading any header the format starts with
+func newRecordSource(input io.Reader, opts domain.ImportOptions) (recordSource, error) {
+	format, err := domain.ParseImportFormat(string(opts.Format))
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case domain.ImportCSV:
+		return newCSVSource(input, opts.CSV)
+	case domain.ImportNDJSON:
+		return newNDJSONSource(input, opts.StartLine), nil
+	case domain.ImportGeoJSON:
+		return newGeoJSONSource(input)
+	case domain.ImportUNLOCODE:
+		return newUNLOCODESource(input), nil
+	default:
+		return newJSONSource(input)
+	}
+}
+
+// jsonSource reads the entries of a JSON object mapping port IDs to records
+type jsonSource struct {
+	decoder *json.Decoder
+
+	// done is set once the closing brace and the end of the input are read
+	done bool
+}
+
+// newJSONSource creates a jsonSource positioned inside the input's object
+func newJSONSource(input io.Reader) (*jsonSource, error) {
+	decoder := json.NewDecoder(input)
+
+	// Read opening brace
+	if _, err := decoder.Token(); err != nil {
+		return nil, fmt.Errorf("failed to read JSON start: %w", err)
+	}
+	return &jsonSource{decoder: decoder}, nil
+}
+
+// read reads the next key and value of the object into record
+func (s *jsonSource) read(record *importRecord) error {
+	if s.done {
+		return io.EOF
+	}
+	if !s.decoder.More() {
+		return s.readEnd()
+	}
+
+	// Read port ID (key); object keys are always strings
+	token, err := s.decoder.Token()
+	if err != nil {
+		return fmt.Errorf("failed to read port ID: %w", err)
+	}
+	record.portID, _ = token.(string)
+
+	// The decoder sits just past the closing quote of the key; step back
+	// over it, assuming the key contains no escape sequences
+	record.offset = s.decoder.InputOffset() - int64(len(record.portID)+2)
+
+	// Read port data; syntax errors abort the import while well-formed
+	// records of the wrong shape are rejected by the workers
+	if err := s.decoder.Decode(&record.raw); err != nil {
+		return fmt.Errorf("failed to decode port data: %w", err)
+	}
+	return nil
+}
+
+// readEnd reads the closing brace of the object, so input cut off between
+// two records fails the import rather than committing part of it, and
+// checks that only whitespace follows
+func (s *jsonSource) readEnd() error {
+	token, err := s.decoder.Token()
+	if err == io.EOF {
+		return fmt.Errorf("failed to read JSON end: %w", io.ErrUnexpectedEOF)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read JSON end: %w", err)
+	}
+	if token != json.Delim('}') {
+		return fmt.Errorf("failed to read JSON end: unexpected %v", token)
+	}
+	if _, err := s.decoder.Token(); err != io.EOF {
+		if err == nil {
+			err = errors.New("unexpected data after JSON object")
+		}
+		return fmt.Errorf("failed to read JSON end: %w", err)
+	}
+	s.done = true
+	return io.EOF
+}
+
+// convert builds a port from a JSON record
+func (s *jsonSource) convert(record *importRecord) (*domain.Port, *domain.ImportRejection) {
+	return portFromJSON(record.portID, record.raw)
+}
@@ -0,0 +1,168 @@
+package core
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+
+	"portservice/internal/domain"
+)
+
+// Columns of the UN/LOCODE code list CSV, which has no header row
+const (
+	unlocodeChange = iota
+	unlocodeCountry
+	unlocodeLocation
+	unlocodeName
+	unlocodeNameASCII
+	unlocodeSubdivision
+	unlocodeStatus
+	unlocodeFunction
+	unlocodeDate
+	unlocodeIATA
+	unlocodeCoordinates
+)
+
+// unlocodeDeleted is the change indicator of entries marked for deletion
+const unlocodeDeleted = "X"
+
+// unlocodeSource reads entries of the UNECE UN/LOCODE code list. Entries
+// are merged into the existing port listing their UN/LOCODE, so it
+// implements portMerger.
+type unlocodeSource struct {
+	reader *csv.Reader
+}
+
+// newUNLOCODESource creates an unlocodeSource reading input
+func newUNLOCODESource(input io.Reader) *unlocodeSource {
+	reader := csv.NewReader(input)
+	reader.FieldsPerRecord = -1
+	return &unlocodeSource{reader: reader}
+}
+
+// read reads the next location entry into record, skipping the rows that
+// name countries and any header row
+func (s *unlocodeSource) read(record *importRecord) error {
+	for {
+		record.offset = s.reader.InputOffset()
+		fields, err := s.reader.Read()
+		if err == io.EOF {
+			return io.EOF
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read UN/LOCODE entry: %w", err)
+		}
+		if len(fields) <= unlocodeLocation || strings.TrimSpace(fields[unlocodeLocation]) == "" ||
+			strings.EqualFold(fields[unlocodeCountry], "country") {
+			continue
+		}
+
+		// The code list is published in ISO 8859-1 as well as UTF-8
+		for i, field := range fields {
+			if !utf8.ValidString(field) {
+				fields[i], _ = charmap.ISO8859_1.NewDecoder().String(field)
+			}
+		}
+		record.fields = fields
+		record.line, _ = s.reader.FieldPos(0)
+		record.portID = strings.ToUpper(strings.TrimSpace(fields[unlocodeCountry]) + strings.TrimSpace(fields[unlocodeLocation]))
+		return nil
+	}
+}
+
+// convert builds a port from a UN/LOCODE entry
+func (s *unlocodeSource) convert(record *importRecord) (*domain.Port, *domain.ImportRejection) {
+	return portFromUNLOCODE(record.portID, record.fields)
+}
+
+// merge adds a UN/LOCODE entry to the existing port listing its UN/LOCODE.
+// The entry's functions replace the port's, and its country, subdivision
+// and coordinates fill in those the port lacks; the port keeps its ID, name
+// and other data. Without an existing port the entry becomes a new port,
+// which needs coordinates.
+func (s *unlocodeSource) merge(existing, port *domain.Port) (*domain.Port, *domain.ImportRejection) {
+	if existing == nil {
+		if port.Coordinates == nil {
+			return nil, &domain.ImportRejection{PortID: port.ID, Field: "coordinates", Reason: "new port has no coordinates"}
+		}
+		return port, nil
+	}
+
+	merged := existing.Clone()
+	merged.Functions = port.Functions
+	if merged.Country == "" {
+		merged.Country = port.Country
+	}
+	if merged.Province == "" {
+		merged.Province = port.Province
+	}
+	if merged.Coordinates == nil {
+		merged.Coordinates = port.Coordinates
+	}
+	if !slices.Contains(merged.Unlocs, port.ID) {
+		merged.Unlocs = append(merged.Unlocs, port.ID)
+	}
+	return merged, nil
+}
+
+// portFromUNLOCODE builds a port from a UN/LOCODE entry, returning a
+// rejection describing the first invalid field instead when the entry is
+// unusable. Coordinates are optional since entries without them can still
+// be merged into existing ports.
+func portFromUNLOCODE(unloc string, fields []string) (*domain.Port, *domain.ImportRejection) {
+	reject := func(field, reason string) (*domain.Port, *domain.ImportRejection) {
+		return nil, &domain.ImportRejection{PortID: unloc, Field: field, Reason: reason}
+	}
+
+	if len(fields) <= unlocodeCoordinates {
+		return reject("", fmt.Sprintf("entry has %d columns, want at least %d", len(fields), unlocodeCoordinates+1))
+	}
+	value := func(column int) string {
+		return strings.TrimSpace(fields[column])
+	}
+
+	if value(unlocodeChange) == unlocodeDeleted {
+		return reject("", "entry is marked for deletion")
+	}
+	if len(value(unlocodeCountry)) != 2 || len(value(unlocodeLocation)) != 3 {
+		return reject("id", "invalid UN/LOCODE")
+	}
+	name := value(unlocodeName)
+	if name == "" {
+		return reject("name", "invalid or missing name")
+	}
+
+	functions, err := domain.ParseFunctionClassifier(value(unlocodeFunction))
+	if err != nil {
+		return reject("functions", err.Error())
+	}
+
+	var coordinates *domain.Coordinate
+	if raw := value(unlocodeCoordinates); raw != "" {
+		if coordinates, err = domain.ParseDegreeMinutes(raw); err != nil {
+			return reject("coordinates", err.Error())
+		}
+	}
+
+	var alias []string
+	if nameASCII := value(unlocodeNameASCII); nameASCII != "" && nameASCII != name {
+		alias = []string{nameASCII}
+	}
+
+	return &domain.Port{
+		ID:          unloc,
+		Name:        name,
+		City:        name,
+		Country:     value(unlocodeCountry),
+		Alias:       alias,
+		Coordinates: coordinates,
+		Province:    value(unlocodeSubdivision),
+		Unlocs:      []string{unloc},
+		Functions:   functions,
+	}, nil
+}
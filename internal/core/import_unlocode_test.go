@@ -0,0 +1,87 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"portservice/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPortService_ProcessPorts_UNLOCODE(t *testing.T) {
+	repo := newMockRepository()
+	service := NewPortService(repo)
+	ctx := context.Background()
+
+	existing := `{
+		"AEAJM": {"name": "Ajman", "province": "Ajman", "coordinates": [55.5136433, 25.4052165], "unlocs": ["AEAJM"]},
+		"DUBAI": {"name": "Dubai", "coordinates": [55.27, 25.25], "unlocs": ["AEDXB"]}
+	}`
+	_, err := service.ProcessPorts(ctx, strings.NewReader(existing), domain.ImportOptions{})
+	assert.NoError(t, err)
+
+	content := `,"AE",,".UNITED ARAB EMIRATES",,,,,,,,
+,"AE","AJM","Ajman","Ajman","AJ","AI","1-3-----","0701",,"2524N 05530E",
+,"AE","DXB","Dubai","Dubai","DU","AI","1-345---","0701","DXB","2516N 05518E",
++,"AE","KLF","Khor Fakkan","Khor Fakkan","SH","RL","1-------","0701",,"2520N 05621E",
+,"AE","NCO","No Coordinates","No Coordinates",,"RL","1-------","0701",,,
+X,"AE","OLD","Old Port","Old Port",,"RL","1-------","0701",,,
+,"AE","BAD","Bad Coordinates","Bad Coordinates",,"RL","1-------","0701",,"9999N 05530E",
+,"AE","ZRO","Z` + "\xe9" + `ro","Zero",,"RL","-2------","0701",,"2500N 05500E",
+`
+	opts := domain.ImportOptions{Format: domain.ImportUNLOCODE}
+	report, err := service.ProcessPorts(ctx, strings.NewReader(content), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Created)
+	assert.Equal(t, 2, report.Updated)
+	assert.Equal(t, 3, report.Rejected)
+
+	want := []domain.ImportRejection{
+		{PortID: "AEOLD", Reason: "entry is marked for deletion", Offset: 349, Line: 6},
+		{PortID: "AEBAD", Field: "coordinates", Reason: `invalid latitude: invalid minutes in "9999N"`, Offset: 411, Line: 7},
+		{PortID: "AENCO", Field: "coordinates", Reason: "new port has no coordinates", Offset: 276, Line: 5},
+	}
+	assert.Equal(t, want, report.Rejections)
+
+	// Entries merge into the port listing their UN/LOCODE, filling in
+	// missing fields without overwriting existing ones
+	port, err := service.GetPort(ctx, "AEAJM")
+	assert.NoError(t, err)
+	assert.Equal(t, "Ajman", port.Province)
+	assert.Equal(t, 55.5136433, port.Coordinates.Longitude)
+	assert.Equal(t, []domain.PortFunction{domain.FunctionPort, domain.FunctionRoad}, port.Functions)
+
+	port, err = service.GetPort(ctx, "DUBAI")
+	assert.NoError(t, err)
+	assert.Equal(t, "DU", port.Province)
+	assert.Equal(t, "AE", port.Country)
+	assert.Equal(t, []string{"AEDXB"}, port.Unlocs)
+	assert.Equal(t, []domain.PortFunction{domain.FunctionPort, domain.FunctionRoad, domain.FunctionAirport, domain.FunctionPostal}, port.Functions)
+
+	port, err = service.GetPort(ctx, "AEDXB")
+	assert.NoError(t, err)
+	assert.Nil(t, port)
+
+	// Entries without a port become new ports
+	port, err = service.GetPort(ctx, "AEKLF")
+	assert.NoError(t, err)
+	assert.Equal(t, "Khor Fakkan", port.Name)
+	assert.Equal(t, "SH", port.Province)
+	assert.Equal(t, []string{"AEKLF"}, port.Unlocs)
+	assert.InDelta(t, 25+20.0/60, port.Coordinates.Latitude, 1e-9)
+	assert.InDelta(t, 56+21.0/60, port.Coordinates.Longitude, 1e-9)
+
+	// ISO 8859-1 names are decoded
+	port, err = service.GetPort(ctx, "AEZRO")
+	assert.NoError(t, err)
+	assert.Equal(t, "Zéro", port.Name)
+	assert.Equal(t, []string{"Zero"}, port.Alias)
+
+	// Importing the list again changes nothing
+	report, err = service.ProcessPorts(ctx, strings.NewReader(content), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, report.Unchanged)
+	assert.Zero(t, report.Created+report.Updated)
+}
@@ -0,0 +1,374 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"portservice/internal/domain"
+	"portservice/internal/ports/out"
+)
+
+// ProcessPortsFile imports a file containing port data; see ProcessPorts
+func (s *portService) ProcessPortsFile(ctx context.Context, filePath string, opts domain.ImportOptions) (*domain.ImportReport, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	return s.ProcessPorts(ctx, file, opts)
+}
+
+// ProcessPorts imports port data read from r as a JSON object or in the
+// format opts.Format selects. Gzip, zstd and bzip2 input is detected by
+// its magic bytes and decompressed as it is read. Records are decoded on one
+// goroutine, converted by a pool of opts.Workers workers and written in file
+// order. Invalid records are listed in the returned report and handled
+// according to opts.Mode. The valid records are staged in a repository
+// transaction and committed together only once the whole input has been
+// read, so an import that fails or is canceled leaves the repository
+// untouched. Strict imports stop at the first invalid record. When an error
+// aborts the import the report covers the records processed so far.
+func (s *portService) ProcessPorts(ctx context.Context, r io.Reader, opts domain.ImportOptions) (*domain.ImportReport, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	report := domain.NewImportReport()
+	input, closeInput, err := decompress(r)
+	if err != nil {
+		return report, err
+	}
+	defer closeInput()
+	source, err := newRecordSource(input, opts)
+	if err != nil {
+		return report, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return report, err
+	}
+	tx, err := s.repository.BeginTx(ctx)
+	if err != nil {
+		return report, fmt.Errorf("failed to begin import: %w", err)
+	}
+	batch := newImportBatch(s.repository, tx, report)
+	batch.merger, _ = source.(portMerger)
+	// Discard the staged ports unless they were committed
+	defer tx.Rollback(context.Background())
+
+	pipeline := startImportPipeline(ctx, source, opts.WorkerCount())
+	defer pipeline.stop()
+
+	// stage adds records to the transaction, failing a strict import as soon
+	// as a record is rejected while merging
+	strict := opts.Mode == domain.ImportStrict
+	stage := func(records []*importRecord) error {
+		if err := batch.stage(ctx, records); err != nil {
+			return err
+		}
+		if strict && report.Rejected > 0 {
+			return opts.Check(report)
+		}
+		return nil
+	}
+
+	// Stage valid ports in batches as records come back in file order
+	records := make([]*importRecord, 0, opts.BatchLimit())
+	for record := pipeline.next(); record != nil; record = pipeline.next() {
+		switch {
+		case record.err != nil:
+			return report, record.err
+		case record.rejection != nil:
+			report.Reject(*record.rejection)
+			if strict {
+				return report, opts.Check(report)
+			}
+			continue
+		}
+		records = append(records, record)
+		report.Pending++
+		if len(records) == cap(records) {
+			if err := stage(records); err != nil {
+				return report, err
+			}
+			records = records[:0]
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return report, err
+	}
+	if err := stage(records); err != nil {
+		return report, err
+	}
+
+	if err := opts.Check(report); err != nil {
+		return report, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return report, fmt.Errorf("failed to commit import: %w", err)
+	}
+	report.Created, report.Updated, report.Unchanged = batch.created, batch.updated, batch.unchanged
+	report.Pending = 0
+
+	return report, nil
+}
+
+// portMerger is implemented by record sources whose records update the
+// existing port listing their UN/LOCODE rather than replacing the port with
+// their ID. Such records carry their UN/LOCODE as port ID.
+type portMerger interface {
+	// merge combines a record's port with the existing port, which is nil
+	// when there is none, returning a rejection if the result is unusable
+	merge(existing, port *domain.Port) (*domain.Port, *domain.ImportRejection)
+}
+
+// importBatch stages the ports of one import in a repository transaction,
+// classifying how each would change the repository
+type importBatch struct {
+	repository out.PortRepository
+	tx         out.PortTx
+	report     *domain.ImportReport
+
+	// merger merges records into existing ports when the source supports it
+	merger portMerger
+
+	// staged holds the latest port staged for each ID so duplicate records
+	// are compared against what the import has already written
+	staged map[string]*domain.Port
+
+	// unlocs maps the UN/LOCODEs of staged ports to their IDs when merging
+	unlocs map[string]string
+
+	created   int
+	updated   int
+	unchanged int
+}
+
+// newImportBatch creates an importBatch writing to tx and reporting ports
+// rejected while merging to report
+func newImportBatch(repository out.PortRepository, tx out.PortTx, report *domain.ImportReport) *importBatch {
+	return &importBatch{
+		repository: repository,
+		tx:         tx,
+		report:     report,
+		staged:     make(map[string]*domain.Port),
+		unlocs:     make(map[string]string),
+	}
+}
+
+// stage adds the records' ports to the transaction in one batch, skipping
+// those that would not change the repository
+func (b *importBatch) stage(ctx context.Context, records []*importRecord) error {
+	changed := make([]*domain.Port, 0, len(records))
+	for _, record := range records {
+		port := record.port
+		previous, err := b.previous(ctx, port.ID)
+		if err != nil {
+			return err
+		}
+		if b.merger != nil {
+			var rejection *domain.ImportRejection
+			if port, rejection = b.merger.merge(previous, port); rejection != nil {
+				rejection.Offset, rejection.Line = record.offset, record.line
+				b.report.Reject(*rejection)
+				b.report.Pending--
+				continue
+			}
+		}
+		if previous != nil && previous.Equal(port) {
+			b.unchanged++
+			continue
+		}
+
+		changed = append(changed, port)
+		b.staged[port.ID] = port
+		if b.merger != nil {
+			for _, unloc := range port.Unlocs {
+				b.unlocs[unloc] = port.ID
+			}
+		}
+		if previous == nil {
+			b.created++
+		} else {
+			b.updated++
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	result, err := b.tx.SavePorts(ctx, changed)
+	if err != nil {
+		return fmt.Errorf("failed to save ports: %w", err)
+	}
+	for _, item := range result.Items {
+		if item.Err != nil {
+			return fmt.Errorf("failed to save port %v: %w", item.ID, item.Err)
+		}
+	}
+	return nil
+}
+
+// previous returns the port a record with the given ID would change, or nil
+// when the record adds a port
+func (b *importBatch) previous(ctx context.Context, id string) (*domain.Port, error) {
+	if b.merger != nil {
+		return b.lookupUnloc(ctx, id)
+	}
+	return b.lookup(ctx, id)
+}
+
+// lookup returns the port the import would replace for id, preferring the
+// port staged by this import, or nil when the ID is new
+func (b *importBatch) lookup(ctx context.Context, id string) (*domain.Port, error) {
+	if previous, ok := b.staged[id]; ok {
+		return previous, nil
+	}
+	existing, err := b.repository.GetPortIncludingDeleted(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up port %v: %w", id, err)
+	}
+	if existing == nil || existing.IsDeleted() {
+		return nil, nil
+	}
+	return existing, nil
+}
+
+// lookupUnloc returns the port a merged record with the given UN/LOCODE
+// applies to: the port with that ID, else the first port listing it
+func (b *importBatch) lookupUnloc(ctx context.Context, unloc string) (*domain.Port, error) {
+	if id, ok := b.unlocs[unloc]; ok {
+		return b.staged[id], nil
+	}
+	existing, err := b.lookup(ctx, unloc)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+
+	page, err := b.repository.ListPorts(ctx, domain.PortFilter{Unlocs: []string{unloc}}, "", 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up UN/LOCODE %v: %w", unloc, err)
+	}
+	if len(page.Ports) == 0 {
+		return nil, nil
+	}
+	return b.lookup(ctx, page.Ports[0].ID)
+}
+
+// portFromJSON builds a port from a ports file record, returning a rejection
+// describing the first invalid field instead when the record is unusable
+func portFromJSON(portID string, raw json.RawMessage) (*domain.Port, *domain.ImportRejection) {
+	reject := func(field, reason string) (*domain.Port, *domain.ImportRejection) {
+		return nil, &domain.ImportRejection{PortID: portID, Field: field, Reason: reason}
+	}
+
+	if portID == "" {
+		return reject("id", "empty port ID")
+	}
+
+	var portData map[string]interface{}
+	if err := json.Unmarshal(raw, &portData); err != nil || portData == nil {
+		return reject("", "record must be a JSON object")
+	}
+	return portFromMap(portID, portData)
+}
+
+// portFromMap builds a port from a decoded JSON record like portFromJSON
+func portFromMap(portID string, portData map[string]interface{}) (*domain.Port, *domain.ImportRejection) {
+	reject := func(field, reason string) (*domain.Port, *domain.ImportRejection) {
+		return nil, &domain.ImportRejection{PortID: portID, Field: field, Reason: reason}
+	}
+
+	// Extract and validate required fields
+	name, ok := portData["name"].(string)
+	if !ok || name == "" {
+		return reject("name", "invalid or missing name")
+	}
+
+	// Extract optional fields with defaults
+	city := getStringOrDefault(portData, "city", "")
+	country := getStringOrDefault(portData, "country", "")
+	province := getStringOrDefault(portData, "province", "")
+	timezone := getStringOrDefault(portData, "timezone", "")
+	code := getStringOrDefault(portData, "code", "")
+
+	// Extract and validate coordinates
+	coords, ok := portData["coordinates"].([]interface{})
+	if !ok || len(coords) != 2 {
+		return reject("coordinates", "invalid coordinates format")
+	}
+	lon, lonOk := coords[0].(float64)
+	lat, latOk := coords[1].(float64)
+	if !lonOk || !latOk {
+		return reject("coordinates", "invalid coordinate types")
+	}
+	if lon < -180 || lon > 180 {
+		return reject("coordinates", fmt.Sprintf("invalid longitude: %v", lon))
+	}
+	if lat < -90 || lat > 90 {
+		return reject("coordinates", fmt.Sprintf("invalid latitude: %v", lat))
+	}
+
+	// Extract multi-value fields
+	unlocs := getStringSlice(portData, "unlocs")
+	alias := getStringSlice(portData, "alias")
+	regions := getStringSlice(portData, "regions")
+
+	var functions []domain.PortFunction
+	for _, name := range getStringSlice(portData, "functions") {
+		function, err := domain.ParsePortFunction(name)
+		if err != nil {
+			return reject("functions", err.Error())
+		}
+		functions = append(functions, function)
+	}
+
+	// Create port entity
+	port, err := domain.NewPort(
+		portID,
+		name,
+		city,
+		country,
+		[]float64{lon, lat},
+		province,
+		timezone,
+		unlocs,
+		code,
+		domain.WithAlias(alias...),
+		domain.WithRegions(regions...),
+		domain.WithFunctions(functions...),
+	)
+	if err != nil {
+		return reject("", err.Error())
+	}
+	return port, nil
+}
+
+// getStringOrDefault safely extracts a string value from a map with a default value
+func getStringOrDefault(data map[string]interface{}, key, defaultValue string) string {
+	if val, ok := data[key].(string); ok {
+		return val
+	}
+	return defaultValue
+}
+
+// getStringSlice safely extracts the string elements of an array value from a map
+func getStringSlice(data map[string]interface{}, key string) []string {
+	values := make([]string, 0)
+	if raw, ok := data[key].([]interface{}); ok {
+		for _, v := range raw {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,289 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"portservice/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writePortsFile writes content to a temporary ports file and returns its path
+func writePortsFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ports.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPortService_ProcessFile_Report(t *testing.T) {
+	repo := newMockRepository()
+	service := NewPortService(repo)
+	ctx := context.Background()
+
+	content := `{
+		"AEAJM": {"name": "Ajman", "coordinates": [55.5136433, 25.4052165]},
+		"AEAUH": {"name": "Abu Dhabi", "coordinates": [54.37, 24.47]}
+	}`
+	report, err := service.ProcessPortsFile(ctx, writePortsFile(t, content), domain.ImportOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Created)
+	assert.Equal(t, 2, report.Total())
+	assert.Empty(t, report.Rejections)
+
+	// Re-importing reports unchanged and updated records without rewriting
+	// unchanged ones
+	content = `{
+		"AEAJM": {"name": "Ajman", "coordinates": [55.5136433, 25.4052165]},
+		"AEAUH": {"name": "Abu Dhabi Port", "coordinates": [54.37, 24.47]},
+		"AEDXB": {"name": "Dubai", "coordinates": [55.27, 25.25]}
+	}`
+	report, err = service.ProcessPortsFile(ctx, writePortsFile(t, content), domain.ImportOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Updated)
+	assert.Equal(t, 1, report.Unchanged)
+	assert.Equal(t, 0, report.Rejected)
+	assert.Equal(t, int64(4), repo.GetStatistics().TotalUpdates)
+}
+
+func TestPortService_ProcessFile_Rejections(t *testing.T) {
+	service := NewPortService(newMockRepository())
+	ctx := context.Background()
+
+	content := `{
+		"NONAME": {"coordinates": [55.5, 25.4]},
+		"NOCOORDS": {"name": "No Coordinates"},
+		"BADTYPES": {"name": "Bad Types", "coordinates": ["55.5", 25.4]},
+		"BADLON": {"name": "Bad Longitude", "coordinates": [200, 25.4]},
+		"BADLAT": {"name": "Bad Latitude", "coordinates": [55.5, -95]},
+		"NOTOBJECT": 42,
+		"VALID": {"name": "Valid Port", "coordinates": [55.5, 25.4]}
+	}`
+	report, err := service.ProcessPortsFile(ctx, writePortsFile(t, content), domain.ImportOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 6, report.Rejected)
+
+	want := []domain.ImportRejection{
+		{PortID: "NONAME", Field: "name", Reason: "invalid or missing name"},
+		{PortID: "NOCOORDS", Field: "coordinates", Reason: "invalid coordinates format"},
+		{PortID: "BADTYPES", Field: "coordinates", Reason: "invalid coordinate types"},
+		{PortID: "BADLON", Field: "coordinates", Reason: "invalid longitude: 200"},
+		{PortID: "BADLAT", Field: "coordinates", Reason: "invalid latitude: -95"},
+		{PortID: "NOTOBJECT", Reason: "record must be a JSON object"},
+	}
+	if assert.Len(t, report.Rejections, len(want)) {
+		for i, rejection := range report.Rejections {
+			want[i].Offset = int64(strings.Index(content, `"`+want[i].PortID+`"`))
+			assert.Equal(t, want[i], rejection)
+		}
+	}
+}
+
+func TestPortService_ProcessFile_PartialReport(t *testing.T) {
+	service := NewPortService(newMockRepository())
+	ctx := context.Background()
+
+	// A syntax error aborts the import but the report covers earlier
+	// records, which are left uncommitted
+	content := `{
+		"AEAJM": {"name": "Ajman", "coordinates": [55.5136433, 25.4052165]},
+		"AEAUH": {`
+	report, err := service.ProcessPortsFile(ctx, writePortsFile(t, content), domain.ImportOptions{})
+	assert.Error(t, err)
+	if assert.NotNil(t, report) {
+		assert.Equal(t, 0, report.Created)
+		assert.Equal(t, 1, report.Pending)
+	}
+
+	report, err = service.ProcessPortsFile(ctx, "nonexistent.json", domain.ImportOptions{})
+	assert.Error(t, err)
+	assert.Nil(t, report)
+}
+
+func TestPortService_ProcessFile_Modes(t *testing.T) {
+	ctx := context.Background()
+	content := `{
+		"AEAJM": {"name": "Ajman", "coordinates": [55.5136433, 25.4052165]},
+		"AEAUH": {"name": "Abu Dhabi", "coordinates": [54.37, 24.47]},
+		"AEDXB": {"name": "Dubai", "coordinates": [55.27, 25.25]},
+		"BAD": {"name": "Bad"}
+	}`
+	path := writePortsFile(t, content)
+
+	tests := []struct {
+		name        string
+		opts        domain.ImportOptions
+		wantErr     error
+		wantCreated int
+	}{
+		{name: "lenient", opts: domain.ImportOptions{Mode: domain.ImportLenient}, wantCreated: 3},
+		{name: "strict", opts: domain.ImportOptions{Mode: domain.ImportStrict}, wantErr: domain.ErrImportRejected},
+		{name: "within threshold", opts: domain.ImportOptions{Mode: domain.ImportThreshold, MaxRejectedPercent: 25}, wantCreated: 3},
+		{name: "over threshold", opts: domain.ImportOptions{Mode: domain.ImportThreshold, MaxRejectedPercent: 10}, wantErr: domain.ErrImportRejected},
+		{name: "invalid options", opts: domain.ImportOptions{Mode: "careful"}, wantErr: domain.ErrInvalidImportOptions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMockRepository()
+			service := NewPortService(repo)
+
+			report, err := service.ProcessPortsFile(ctx, path, tt.opts)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Equal(t, int64(0), repo.GetStatistics().TotalPorts, "nothing is committed")
+				if report != nil {
+					assert.Equal(t, 1, report.Rejected)
+					assert.Equal(t, 3, report.Pending)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantCreated, report.Created)
+			assert.Equal(t, 1, report.Rejected)
+			assert.Zero(t, report.Pending)
+			assert.Equal(t, int64(tt.wantCreated), repo.GetStatistics().TotalPorts)
+		})
+	}
+}
+
+func TestPortService_ProcessFile_StrictDecodeError(t *testing.T) {
+	repo := newMockRepository()
+	service := NewPortService(repo)
+
+	// Unlike lenient imports, strict imports commit nothing before a
+	// syntax error
+	content := `{
+		"AEAJM": {"name": "Ajman", "coordinates": [55.5136433, 25.4052165]},
+		"AEAUH": {`
+	_, err := service.ProcessPortsFile(context.Background(), writePortsFile(t, content), domain.ImportOptions{Mode: domain.ImportStrict})
+	assert.Error(t, err)
+	assert.Equal(t, int64(0), repo.GetStatistics().TotalPorts)
+}
+
+func TestPortService_ProcessFile_StrictStopsAtRejection(t *testing.T) {
+	repo := newMockRepository()
+	service := NewPortService(repo)
+
+	// The import stops at the invalid record rather than reading on to the
+	// syntax error after it
+	content := `{
+		"AEAJM": {"name": "Ajman", "coordinates": [55.5136433, 25.4052165]},
+		"BAD": {"name": "Bad"},
+		"AEAUH": {"name": "Abu Dhabi", "coordinates": [54.37, 24.47]},
+		"AEDXB": {`
+	report, err := service.ProcessPortsFile(context.Background(), writePortsFile(t, content), domain.ImportOptions{Mode: domain.ImportStrict})
+	assert.ErrorIs(t, err, domain.ErrImportRejected)
+	if assert.NotNil(t, report) {
+		assert.Equal(t, 1, report.Rejected)
+		assert.Equal(t, 1, report.Pending)
+	}
+	assert.Equal(t, int64(0), repo.GetStatistics().TotalPorts)
+}
+
+func TestPortService_ProcessPorts_Truncated(t *testing.T) {
+	ctx := context.Background()
+	record := `"AEAJM": {"name": "Ajman", "coordinates": [55.5136433, 25.4052165]}`
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{name: "complete", content: "{" + record + "}\n\t "},
+		{name: "truncated after record", content: "{" + record + ",", wantErr: true},
+		{name: "missing closing brace", content: "{" + record, wantErr: true},
+		{name: "trailing data", content: "{" + record + "} {}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMockRepository()
+			service := NewPortService(repo)
+
+			_, err := service.ProcessPorts(ctx, strings.NewReader(tt.content), domain.ImportOptions{})
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, int64(0), repo.GetStatistics().TotalPorts, "nothing is committed")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, int64(1), repo.GetStatistics().TotalPorts)
+		})
+	}
+}
+
+func TestPortService_ProcessFile_DuplicateRecords(t *testing.T) {
+	repo := newMockRepository()
+	service := NewPortService(repo)
+	ctx := context.Background()
+
+	// Later records win and are compared against earlier ones in the file
+	content := `{
+		"AEAJM": {"name": "Ajman", "coordinates": [55.5136433, 25.4052165]},
+		"AEAJM": {"name": "Ajman Port", "coordinates": [55.5136433, 25.4052165]},
+		"AEAJM": {"name": "Ajman Port", "coordinates": [55.5136433, 25.4052165]}
+	}`
+	report, err := service.ProcessPortsFile(ctx, writePortsFile(t, content), domain.ImportOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Updated)
+	assert.Equal(t, 1, report.Unchanged)
+
+	port, err := service.GetPort(ctx, "AEAJM")
+	assert.NoError(t, err)
+	assert.Equal(t, "Ajman Port", port.Name)
+}
+
+func TestPortService_ProcessPorts_Reader(t *testing.T) {
+	repo := newMockRepository()
+	service := NewPortService(repo)
+	ctx := context.Background()
+
+	content := `{
+		"AEAJM": {"name": "Ajman", "coordinates": [55.5136433, 25.4052165]},
+		"AEAUH": {"name": "Abu Dhabi", "coordinates": [54.37, 24.47]}
+	}`
+	report, err := service.ProcessPorts(ctx, strings.NewReader(content), domain.ImportOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Created)
+	assert.Equal(t, int64(2), repo.GetStatistics().TotalPorts)
+
+	_, err = service.ProcessPorts(ctx, strings.NewReader(""), domain.ImportOptions{})
+	assert.Error(t, err)
+}
+
+func TestPortService_ProcessFile_Missing(t *testing.T) {
+	service := NewPortService(newMockRepository())
+
+	_, err := service.ProcessPortsFile(context.Background(), filepath.Join(t.TempDir(), "missing.json"), domain.ImportOptions{})
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestPortService_ProcessPorts_Functions(t *testing.T) {
+	service := NewPortService(newMockRepository())
+	ctx := context.Background()
+
+	content := `{
+		"AEAJM": {"name": "Ajman", "coordinates": [55.5136433, 25.4052165], "functions": ["port", "Rail"]},
+		"AEAUH": {"name": "Abu Dhabi", "coordinates": [54.37, 24.47], "functions": ["ferry"]}
+	}`
+	report, err := service.ProcessPorts(ctx, strings.NewReader(content), domain.ImportOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+	if assert.Len(t, report.Rejections, 1) {
+		assert.Equal(t, "functions", report.Rejections[0].Field)
+	}
+
+	port, err := service.GetPort(ctx, "AEAJM")
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.PortFunction{domain.FunctionPort, domain.FunctionRail}, port.Functions)
+}
@@ -2,11 +2,7 @@ package core
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"log"
-	"os"
 
 	"portservice/internal/domain"
 	"portservice/internal/ports/in"
@@ -30,13 +26,63 @@ func (s *portService) CreateOrUpdatePort(ctx context.Context, port *domain.Port)
 	if ctx.Err() != nil {
 		return ctx.Err()
 	}
+	if err := validatePort(port); err != nil {
+		return err
+	}
+	return s.repository.SavePort(ctx, port)
+}
+
+// CreateOrUpdatePorts creates or updates a batch of ports in one repository
+// write, reporting the outcome of each port
+func (s *portService) CreateOrUpdatePorts(ctx context.Context, ports []*domain.Port) (domain.BatchResult, error) {
+	if ctx.Err() != nil {
+		return domain.BatchResult{}, ctx.Err()
+	}
+
+	// Only valid ports reach the repository; remember where each came from
+	result := domain.BatchResult{Items: make([]domain.BatchItemResult, len(ports))}
+	valid := make([]*domain.Port, 0, len(ports))
+	positions := make([]int, 0, len(ports))
+	for i, port := range ports {
+		if port != nil {
+			result.Items[i].ID = port.ID
+		}
+		if err := validatePort(port); err != nil {
+			result.Items[i].Err = err
+			continue
+		}
+		valid = append(valid, port)
+		positions = append(positions, i)
+	}
+	if len(valid) == 0 {
+		return result, nil
+	}
+
+	saved, err := s.repository.SavePorts(ctx, valid)
+	if err != nil {
+		return domain.BatchResult{}, err
+	}
+	for i, item := range saved.Items {
+		result.Items[positions[i]].Err = item.Err
+	}
+	return result, nil
+}
+
+// validatePort checks a port supplied by a caller, wrapping failures in
+// domain.ErrInvalidPort
+func validatePort(port *domain.Port) error {
 	if port == nil {
-		return fmt.Errorf("nil port")
+		return fmt.Errorf("%w: nil port", domain.ErrInvalidPort)
 	}
 	if err := port.Validate(); err != nil {
-		return fmt.Errorf("invalid port: %w", err)
+		return fmt.Errorf("%w: %w", domain.ErrInvalidPort, err)
 	}
-	return s.repository.SavePort(ctx, port)
+	// Only DeletePort tombstones ports; a saved tombstone could be neither
+	// fetched nor deleted
+	if port.IsDeleted() {
+		return fmt.Errorf("%w: deleted_at cannot be set, delete the port instead", domain.ErrInvalidPort)
+	}
+	return nil
 }
 
 // GetPort retrieves a port by its ID
@@ -45,125 +91,58 @@ func (s *portService) GetPort(ctx context.Context, id string) (*domain.Port, err
 		return nil, ctx.Err()
 	}
 	if id == "" {
-		return nil, fmt.Errorf("empty port ID")
+		return nil, fmt.Errorf("%w: empty port ID", domain.ErrInvalidPort)
 	}
 	return s.repository.GetPort(ctx, id)
 }
 
-// ProcessPortsFile processes a JSON file containing port data
-func (s *portService) ProcessPortsFile(ctx context.Context, filePath string) error {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
+// GetPortIncludingDeleted retrieves a port by its ID, including soft-deleted ports
+func (s *portService) GetPortIncludingDeleted(ctx context.Context, id string) (*domain.Port, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
 	}
-	defer file.Close()
-
-	decoder := json.NewDecoder(file)
-
-	// Read opening brace
-	if _, err := decoder.Token(); err != nil {
-		return fmt.Errorf("failed to read JSON start: %w", err)
+	if id == "" {
+		return nil, fmt.Errorf("%w: empty port ID", domain.ErrInvalidPort)
 	}
+	return s.repository.GetPortIncludingDeleted(ctx, id)
+}
 
-	// Read key-value pairs
-	for decoder.More() {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			// Read port ID (key)
-			portID, err := decoder.Token()
-			if err != nil {
-				if err == io.EOF {
-					break
-				}
-				return fmt.Errorf("failed to read port ID: %w", err)
-			}
-
-			// Read port data into map
-			var portData map[string]interface{}
-			if err := decoder.Decode(&portData); err != nil {
-				return fmt.Errorf("failed to decode port data: %w", err)
-			}
-
-			// Extract and validate required fields
-			name, ok := portData["name"].(string)
-			if !ok {
-				log.Printf("Warning: port %v has invalid or missing name", portID)
-				continue
-			}
-
-			// Extract optional fields with defaults
-			city := getStringOrDefault(portData, "city", "")
-			country := getStringOrDefault(portData, "country", "")
-			province := getStringOrDefault(portData, "province", "")
-			timezone := getStringOrDefault(portData, "timezone", "")
-			code := getStringOrDefault(portData, "code", "")
-
-			// Extract and validate coordinates
-			var coordinates []float64
-			if coords, ok := portData["coordinates"].([]interface{}); ok && len(coords) == 2 {
-				lon, lonOk := coords[0].(float64)
-				lat, latOk := coords[1].(float64)
-				if !lonOk || !latOk {
-					log.Printf("Warning: port %v has invalid coordinate types", portID)
-					continue
-				}
-				if lon < -180 || lon > 180 {
-					log.Printf("Warning: port %v has invalid longitude: %v", portID, lon)
-					continue
-				}
-				if lat < -90 || lat > 90 {
-					log.Printf("Warning: port %v has invalid latitude: %v", portID, lat)
-					continue
-				}
-				coordinates = []float64{lon, lat}
-			} else {
-				log.Printf("Warning: port %v has invalid coordinates format", portID)
-				continue
-			}
-
-			// Extract unlocs
-			unlocs := make([]string, 0)
-			if unlocsRaw, ok := portData["unlocs"].([]interface{}); ok {
-				for _, u := range unlocsRaw {
-					if s, ok := u.(string); ok {
-						unlocs = append(unlocs, s)
-					}
-				}
-			}
-
-			// Create port entity
-			port, err := domain.NewPort(
-				portID.(string),
-				name,
-				city,
-				country,
-				coordinates,
-				province,
-				timezone,
-				unlocs,
-				code,
-			)
-			if err != nil {
-				log.Printf("Warning: failed to create port entity for %v: %v", portID, err)
-				continue
-			}
-
-			// Save port
-			if err := s.repository.SavePort(ctx, port); err != nil {
-				return fmt.Errorf("failed to save port %v: %w", portID, err)
-			}
-		}
+// DeletePort removes a port by its ID
+func (s *portService) DeletePort(ctx context.Context, id string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
 	}
+	if id == "" {
+		return fmt.Errorf("%w: empty port ID", domain.ErrInvalidPort)
+	}
+	return s.repository.DeletePort(ctx, id)
+}
 
-	return nil
+// ListPorts returns a page of ports matching the filter, ordered by port ID
+func (s *portService) ListPorts(ctx context.Context, filter domain.PortFilter, cursor string, limit int) (domain.PortPage, error) {
+	if ctx.Err() != nil {
+		return domain.PortPage{}, ctx.Err()
+	}
+	return s.repository.ListPorts(ctx, filter, cursor, domain.NormalizeLimit(limit))
 }
 
-// getStringOrDefault safely extracts a string value from a map with a default value
-func getStringOrDefault(data map[string]interface{}, key, defaultValue string) string {
-	if val, ok := data[key].(string); ok {
-		return val
+// forEachPort calls fn for every port matching the filter in port ID order,
+// paging through the repository so memory use stays bounded
+func (s *portService) forEachPort(ctx context.Context, filter domain.PortFilter, fn func(*domain.Port) error) error {
+	cursor := ""
+	for {
+		page, err := s.repository.ListPorts(ctx, filter, cursor, domain.MaxPageLimit)
+		if err != nil {
+			return err
+		}
+		for _, port := range page.Ports {
+			if err := fn(port); err != nil {
+				return err
+			}
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		cursor = page.NextCursor
 	}
-	return defaultValue
 }
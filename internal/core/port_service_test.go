@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"portservice/internal/domain"
 	"portservice/internal/ports/out"
@@ -67,6 +69,8 @@ func TestPortService_ProcessFile(t *testing.T) {
 			"country": "United Arab Emirates",
 			"timezone": "Asia/Dubai",
 			"unlocs": ["AEAUH"],
+			"alias": ["Abu Zaby"],
+			"regions": ["Middle East"],
 			"code": "52001"
 		}
 	}`
@@ -89,7 +93,7 @@ func TestPortService_ProcessFile(t *testing.T) {
 	ctx := context.Background()
 
 	// Test processing the file
-	err = service.ProcessPortsFile(ctx, tmpfile.Name())
+	_, err = service.ProcessPortsFile(ctx, tmpfile.Name(), domain.ImportOptions{})
 	assert.NoError(t, err)
 
 	// Verify first port was saved
@@ -103,6 +107,8 @@ func TestPortService_ProcessFile(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, port2)
 	assert.Equal(t, "Abu Dhabi", port2.Name)
+	assert.Equal(t, []string{"Abu Zaby"}, port2.Alias)
+	assert.Equal(t, []string{"Middle East"}, port2.Regions)
 }
 
 func TestPortService_ProcessFile_Errors(t *testing.T) {
@@ -111,7 +117,7 @@ func TestPortService_ProcessFile_Errors(t *testing.T) {
 	ctx := context.Background()
 
 	// Test non-existent file
-	err := service.ProcessPortsFile(ctx, "nonexistent.json")
+	_, err := service.ProcessPortsFile(ctx, "nonexistent.json", domain.ImportOptions{})
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "no such file")
 
@@ -129,14 +135,14 @@ func TestPortService_ProcessFile_Errors(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	err = service.ProcessPortsFile(ctx, tmpfile.Name())
+	_, err = service.ProcessPortsFile(ctx, tmpfile.Name(), domain.ImportOptions{})
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid")
 
 	// Test context cancellation
 	cancelCtx, cancel := context.WithCancel(context.Background())
 	cancel()
-	err = service.ProcessPortsFile(cancelCtx, tmpfile.Name())
+	_, err = service.ProcessPortsFile(cancelCtx, tmpfile.Name(), domain.ImportOptions{})
 	assert.Equal(t, context.Canceled, err)
 }
 
@@ -159,9 +165,21 @@ func TestPortService_CreateOrUpdatePort_Errors(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "port ID cannot be empty")
 
-	// Test context cancellation
+	// Only DeletePort creates tombstones
 	coords := []float64{55.5136433, 25.4052165}
 	validPort, _ := domain.NewPort("TEST1", "Test Port", "Test City", "Test Country", coords, "", "", nil, "")
+	deleted := validPort.Clone()
+	deletedAt := time.Now()
+	deleted.DeletedAt = &deletedAt
+	err = service.CreateOrUpdatePort(ctx, deleted)
+	assert.ErrorIs(t, err, domain.ErrInvalidPort)
+	result, err := service.CreateOrUpdatePorts(ctx, []*domain.Port{deleted, validPort})
+	assert.NoError(t, err)
+	assert.ErrorIs(t, result.Items[0].Err, domain.ErrInvalidPort)
+	assert.NoError(t, result.Items[1].Err)
+	assert.Equal(t, int64(1), repo.GetStatistics().TotalUpdates)
+
+	// Test context cancellation
 	cancelCtx, cancel := context.WithCancel(context.Background())
 	cancel()
 	err = service.CreateOrUpdatePort(cancelCtx, validPort)
@@ -187,6 +205,196 @@ func TestPortService_GetPort_Errors(t *testing.T) {
 	assert.Nil(t, port)
 }
 
+func TestPortService_DeletePort(t *testing.T) {
+	repo := newMockRepository()
+	service := NewPortService(repo)
+	ctx := context.Background()
+
+	coords := []float64{55.5136433, 25.4052165}
+	port, err := domain.NewPort("TEST1", "Test Port", "Test City", "Test Country", coords, "", "", nil, "")
+	assert.NoError(t, err)
+	assert.NoError(t, service.CreateOrUpdatePort(ctx, port))
+
+	// Test deleting
+	err = service.DeletePort(ctx, "TEST1")
+	assert.NoError(t, err)
+
+	retrieved, err := service.GetPort(ctx, "TEST1")
+	assert.NoError(t, err)
+	assert.Nil(t, retrieved)
+
+	// Test deleting unknown port
+	err = service.DeletePort(ctx, "TEST1")
+	assert.ErrorIs(t, err, domain.ErrPortNotFound)
+
+	// Test empty ID
+	err = service.DeletePort(ctx, "")
+	assert.ErrorIs(t, err, domain.ErrInvalidPort)
+
+	// Test context cancellation
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = service.DeletePort(cancelCtx, "TEST1")
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestPortService_ListPorts(t *testing.T) {
+	repo := newMockRepository()
+	service := NewPortService(repo)
+	ctx := context.Background()
+
+	coords := []float64{55.5136433, 25.4052165}
+	for i := 0; i < 25; i++ {
+		port, err := domain.NewPort(fmt.Sprintf("PORT%02d", i), fmt.Sprintf("Port %d", i), "", "", coords, "", "", nil, "")
+		assert.NoError(t, err)
+		assert.NoError(t, service.CreateOrUpdatePort(ctx, port))
+	}
+
+	// Page through all ports
+	var ids []string
+	cursor := ""
+	for {
+		page, err := service.ListPorts(ctx, domain.PortFilter{}, cursor, 10)
+		assert.NoError(t, err)
+		for _, p := range page.Ports {
+			ids = append(ids, p.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	assert.Len(t, ids, 25)
+	assert.True(t, sort.StringsAreSorted(ids))
+
+	// Test invalid cursor
+	_, err := service.ListPorts(ctx, domain.PortFilter{}, "not-a-cursor", 10)
+	assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+
+	// Test context cancellation
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = service.ListPorts(cancelCtx, domain.PortFilter{}, "", 10)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestPortService_SpatialQueries(t *testing.T) {
+	// The mock repository has no spatial index, exercising the scan fallback
+	repo := newMockRepository()
+	service := NewPortService(repo)
+	ctx := context.Background()
+
+	ports := map[string][]float64{
+		"AEAJM": {55.5136433, 25.4052165},
+		"AEAUH": {54.37, 24.47},
+		"AEDXB": {55.27, 25.25},
+		"NOOSL": {10.75, 59.91},
+	}
+	for id, coords := range ports {
+		port, err := domain.NewPort(id, id, "", "", coords, "", "", nil, "")
+		assert.NoError(t, err)
+		assert.NoError(t, service.CreateOrUpdatePort(ctx, port))
+	}
+
+	dubai := domain.Coordinate{Longitude: 55.27, Latitude: 25.25}
+
+	nearest, err := service.FindNearest(ctx, dubai, 2)
+	assert.NoError(t, err)
+	assert.Len(t, nearest, 2)
+	assert.Equal(t, "AEDXB", nearest[0].Port.ID)
+	assert.Equal(t, "AEAJM", nearest[1].Port.ID)
+	assert.InDelta(t, 0, nearest[0].DistanceKm, 0.001)
+
+	within, err := service.FindWithinRadius(ctx, dubai, 200)
+	assert.NoError(t, err)
+	assert.Len(t, within, 3)
+	assert.Equal(t, "AEAUH", within[2].Port.ID)
+
+	// Test invalid arguments
+	_, err = service.FindNearest(ctx, dubai, 0)
+	assert.ErrorIs(t, err, domain.ErrInvalidQuery)
+	_, err = service.FindNearest(ctx, domain.Coordinate{Longitude: 200}, 1)
+	assert.ErrorIs(t, err, domain.ErrInvalidQuery)
+	_, err = service.FindWithinRadius(ctx, dubai, -1)
+	assert.ErrorIs(t, err, domain.ErrInvalidQuery)
+
+	// Bounding boxes and polygons
+	box, err := domain.NewBoundingBox(54, 24, 56, 26)
+	assert.NoError(t, err)
+	inBox, err := service.FindInBoundingBox(ctx, box)
+	assert.NoError(t, err)
+	assert.Len(t, inBox, 3)
+	assert.Equal(t, "AEAJM", inBox[0].ID)
+
+	_, err = service.FindInBoundingBox(ctx, domain.BoundingBox{MinLatitude: 10, MaxLatitude: 0})
+	assert.ErrorIs(t, err, domain.ErrInvalidQuery)
+
+	// Triangle covering Dubai and Ajman but not Abu Dhabi
+	polygon, err := domain.NewPolygon([][][]float64{{{55, 25}, {56, 25}, {55.5, 26}, {55, 25}}})
+	assert.NoError(t, err)
+	inPolygon, err := service.FindInPolygon(ctx, polygon)
+	assert.NoError(t, err)
+	assert.Len(t, inPolygon, 2)
+
+	_, err = service.FindInPolygon(ctx, domain.Polygon{})
+	assert.ErrorIs(t, err, domain.ErrInvalidQuery)
+}
+
+// polygonRepository is a mockRepository that answers polygon queries itself
+type polygonRepository struct {
+	*mockRepository
+	polygons []domain.Polygon
+}
+
+func (m *polygonRepository) FindInPolygon(ctx context.Context, polygon domain.Polygon) ([]*domain.Port, error) {
+	m.polygons = append(m.polygons, polygon)
+	return []*domain.Port{}, nil
+}
+
+func TestPortService_FindInPolygon_Repository(t *testing.T) {
+	repo := &polygonRepository{mockRepository: newMockRepository()}
+	service := NewPortService(repo)
+	ctx := context.Background()
+
+	polygon, err := domain.NewPolygon([][][]float64{{{55, 25}, {56, 25}, {55.5, 26}, {55, 25}}})
+	assert.NoError(t, err)
+	ports, err := service.FindInPolygon(ctx, polygon)
+	assert.NoError(t, err)
+	assert.Empty(t, ports)
+	assert.Equal(t, []domain.Polygon{polygon}, repo.polygons)
+
+	// Invalid polygons are rejected before reaching the repository
+	_, err = service.FindInPolygon(ctx, domain.Polygon{})
+	assert.ErrorIs(t, err, domain.ErrInvalidQuery)
+	assert.Len(t, repo.polygons, 1)
+}
+
+func TestPortService_SearchPorts(t *testing.T) {
+	// The mock repository has no search index, exercising the scan fallback
+	repo := newMockRepository()
+	service := NewPortService(repo)
+	ctx := context.Background()
+
+	coords := []float64{55.5136433, 25.4052165}
+	abuDhabi, _ := domain.NewPort("AEAUH", "Abu Dhabi", "Abu Dhabi", "United Arab Emirates", coords, "Abu Z¸aby [Abu Dhabi]", "", nil, "")
+	dubai, _ := domain.NewPort("AEDXB", "Dubai", "Dubai", "United Arab Emirates", coords, "Dubayy [Dubai]", "", nil, "")
+	assert.NoError(t, service.CreateOrUpdatePort(ctx, abuDhabi))
+	assert.NoError(t, service.CreateOrUpdatePort(ctx, dubai))
+
+	results, err := service.SearchPorts(ctx, "dubay", 10)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "AEDXB", results[0].Port.ID)
+
+	results, err = service.SearchPorts(ctx, "abu dabi", 10)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "AEAUH", results[0].Port.ID)
+
+	_, err = service.SearchPorts(ctx, " ,", 10)
+	assert.ErrorIs(t, err, domain.ErrInvalidQuery)
+}
+
 func TestPortService_ProcessFile_MalformedData(t *testing.T) {
 	content := `{
 		"INVALID1": {
@@ -225,7 +433,7 @@ func TestPortService_ProcessFile_MalformedData(t *testing.T) {
 	ctx := context.Background()
 
 	// Process file should not fail on malformed data
-	err = service.ProcessPortsFile(ctx, tmpfile.Name())
+	_, err = service.ProcessPortsFile(ctx, tmpfile.Name(), domain.ImportOptions{})
 	assert.NoError(t, err)
 
 	// Invalid ports should be skipped
@@ -285,7 +493,7 @@ func TestPortService_ProcessFile_LargeFile(t *testing.T) {
 	ctx := context.Background()
 
 	// Process file
-	err = service.ProcessPortsFile(ctx, tmpfile.Name())
+	_, err = service.ProcessPortsFile(ctx, tmpfile.Name(), domain.ImportOptions{})
 	assert.NoError(t, err)
 
 	// Verify random samples
@@ -331,15 +539,14 @@ func TestPortService_ProcessFile_EOF(t *testing.T) {
 	ctx := context.Background()
 
 	// Test processing the file
-	err = service.ProcessPortsFile(ctx, tmpfile.Name())
+	_, err = service.ProcessPortsFile(ctx, tmpfile.Name(), domain.ImportOptions{})
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to decode port data")
 
-	// Verify first port was saved
+	// Verify the import was rolled back, leaving the first port unsaved
 	port, err := service.GetPort(ctx, "AEAJM")
 	assert.NoError(t, err)
-	assert.NotNil(t, port)
-	assert.Equal(t, "Ajman", port.Name)
+	assert.Nil(t, port)
 
 	// Verify second port was not saved
 	port, err = service.GetPort(ctx, "AEAUH")
@@ -360,7 +567,7 @@ func TestPortService_ProcessFile_EmptyFile(t *testing.T) {
 	ctx := context.Background()
 
 	// Test processing the empty file
-	err = service.ProcessPortsFile(ctx, tmpfile.Name())
+	_, err = service.ProcessPortsFile(ctx, tmpfile.Name(), domain.ImportOptions{})
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to read JSON start")
 
@@ -420,7 +627,7 @@ func TestPortService_ProcessFile_LargePortData(t *testing.T) {
 	ctx := context.Background()
 
 	// Process file
-	err = service.ProcessPortsFile(ctx, tmpfile.Name())
+	_, err = service.ProcessPortsFile(ctx, tmpfile.Name(), domain.ImportOptions{})
 	assert.NoError(t, err)
 
 	// Verify port was saved
@@ -464,7 +671,7 @@ func TestPortService_ProcessFile_DuplicateIDs(t *testing.T) {
 	}
 
 	// Process first file
-	err = service.ProcessPortsFile(ctx, tmpfile1.Name())
+	_, err = service.ProcessPortsFile(ctx, tmpfile1.Name(), domain.ImportOptions{})
 	assert.NoError(t, err)
 
 	// Second file with updated port
@@ -495,7 +702,7 @@ func TestPortService_ProcessFile_DuplicateIDs(t *testing.T) {
 	}
 
 	// Process second file
-	err = service.ProcessPortsFile(ctx, tmpfile2.Name())
+	_, err = service.ProcessPortsFile(ctx, tmpfile2.Name(), domain.ImportOptions{})
 	assert.NoError(t, err)
 
 	// Verify the last version of the port was saved
@@ -534,6 +741,14 @@ func (m *mockRepository) SavePort(ctx context.Context, port *domain.Port) error
 	return nil
 }
 
+func (m *mockRepository) SavePorts(ctx context.Context, ports []*domain.Port) (domain.BatchResult, error) {
+	result := domain.BatchResult{Items: make([]domain.BatchItemResult, len(ports))}
+	for i, port := range ports {
+		result.Items[i] = domain.BatchItemResult{ID: port.ID, Err: m.SavePort(ctx, port)}
+	}
+	return result, nil
+}
+
 func (m *mockRepository) GetPort(ctx context.Context, id string) (*domain.Port, error) {
 	if ctx.Err() != nil {
 		return nil, ctx.Err()
@@ -543,6 +758,58 @@ func (m *mockRepository) GetPort(ctx context.Context, id string) (*domain.Port,
 	return m.ports[id], nil
 }
 
+func (m *mockRepository) GetPortIncludingDeleted(ctx context.Context, id string) (*domain.Port, error) {
+	return m.GetPort(ctx, id)
+}
+
+func (m *mockRepository) DeletePort(ctx context.Context, id string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.ports[id]; !ok {
+		return domain.ErrPortNotFound
+	}
+	delete(m.ports, id)
+	return nil
+}
+
+func (m *mockRepository) ListPorts(ctx context.Context, filter domain.PortFilter, cursor string, limit int) (domain.PortPage, error) {
+	if ctx.Err() != nil {
+		return domain.PortPage{}, ctx.Err()
+	}
+	afterID, err := domain.DecodeCursor(cursor)
+	if err != nil {
+		return domain.PortPage{}, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ids := make([]string, 0, len(m.ports))
+	for id, port := range m.ports {
+		if id > afterID && filter.Matches(port) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	page := domain.PortPage{}
+	for _, id := range ids {
+		if len(page.Ports) == limit {
+			page.NextCursor = domain.EncodeCursor(page.Ports[limit-1].ID)
+			break
+		}
+		page.Ports = append(page.Ports, m.ports[id])
+	}
+	return page, nil
+}
+
+func (m *mockRepository) BeginTx(ctx context.Context) (out.PortTx, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return &mockTx{repo: m}, nil
+}
+
 func (m *mockRepository) Close(ctx context.Context) error {
 	if ctx.Err() != nil {
 		return ctx.Err()
@@ -564,6 +831,55 @@ func (m *mockRepository) GetStatistics() out.RepositoryStats {
 	}
 }
 
+// mockTx is a mock transaction that saves the staged ports to its
+// repository on Commit
+type mockTx struct {
+	repo   out.PortRepository
+	staged []*domain.Port
+	done   bool
+}
+
+func (tx *mockTx) SavePort(ctx context.Context, port *domain.Port) error {
+	if tx.done {
+		return out.ErrTxDone
+	}
+	tx.staged = append(tx.staged, port)
+	return nil
+}
+
+func (tx *mockTx) SavePorts(ctx context.Context, ports []*domain.Port) (domain.BatchResult, error) {
+	if tx.done {
+		return domain.BatchResult{}, out.ErrTxDone
+	}
+	result := domain.BatchResult{Items: make([]domain.BatchItemResult, len(ports))}
+	for i, port := range ports {
+		result.Items[i].ID = port.ID
+	}
+	tx.staged = append(tx.staged, ports...)
+	return result, nil
+}
+
+func (tx *mockTx) Commit(ctx context.Context) error {
+	if tx.done {
+		return out.ErrTxDone
+	}
+	tx.done = true
+	for _, port := range tx.staged {
+		if err := tx.repo.SavePort(ctx, port); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tx *mockTx) Rollback(ctx context.Context) error {
+	if tx.done {
+		return out.ErrTxDone
+	}
+	tx.done = true
+	return nil
+}
+
 // errorRepository is a mock repository that always returns errors
 type errorRepository struct{}
 
@@ -571,10 +887,30 @@ func (e *errorRepository) SavePort(ctx context.Context, port *domain.Port) error
 	return fmt.Errorf("mock save error")
 }
 
+func (e *errorRepository) SavePorts(ctx context.Context, ports []*domain.Port) (domain.BatchResult, error) {
+	return domain.BatchResult{}, fmt.Errorf("mock save error")
+}
+
 func (e *errorRepository) GetPort(ctx context.Context, id string) (*domain.Port, error) {
 	return nil, fmt.Errorf("mock get error")
 }
 
+func (e *errorRepository) GetPortIncludingDeleted(ctx context.Context, id string) (*domain.Port, error) {
+	return nil, fmt.Errorf("mock get error")
+}
+
+func (e *errorRepository) DeletePort(ctx context.Context, id string) error {
+	return fmt.Errorf("mock delete error")
+}
+
+func (e *errorRepository) ListPorts(ctx context.Context, filter domain.PortFilter, cursor string, limit int) (domain.PortPage, error) {
+	return domain.PortPage{}, fmt.Errorf("mock list error")
+}
+
+func (e *errorRepository) BeginTx(ctx context.Context) (out.PortTx, error) {
+	return nil, fmt.Errorf("mock begin error")
+}
+
 func (e *errorRepository) Close(ctx context.Context) error {
 	return fmt.Errorf("mock close error")
 }
@@ -583,6 +919,43 @@ func (e *errorRepository) GetStatistics() out.RepositoryStats {
 	return out.RepositoryStats{}
 }
 
+// saveErrorRepository is a mock repository whose reads succeed but whose
+// saves always fail
+type saveErrorRepository struct {
+	*mockRepository
+}
+
+func (s *saveErrorRepository) SavePort(ctx context.Context, port *domain.Port) error {
+	return fmt.Errorf("mock save error")
+}
+
+func (s *saveErrorRepository) BeginTx(ctx context.Context) (out.PortTx, error) {
+	return &saveErrorTx{}, nil
+}
+
+// saveErrorTx is a mock transaction whose saves always fail
+type saveErrorTx struct{}
+
+func (tx *saveErrorTx) SavePort(ctx context.Context, port *domain.Port) error {
+	return fmt.Errorf("mock save error")
+}
+
+func (tx *saveErrorTx) SavePorts(ctx context.Context, ports []*domain.Port) (domain.BatchResult, error) {
+	result := domain.BatchResult{Items: make([]domain.BatchItemResult, len(ports))}
+	for i, port := range ports {
+		result.Items[i] = domain.BatchItemResult{ID: port.ID, Err: fmt.Errorf("mock save error")}
+	}
+	return result, nil
+}
+
+func (tx *saveErrorTx) Commit(ctx context.Context) error {
+	return nil
+}
+
+func (tx *saveErrorTx) Rollback(ctx context.Context) error {
+	return nil
+}
+
 func TestPortService_RepositoryErrors(t *testing.T) {
 	repo := &errorRepository{}
 	service := NewPortService(repo)
@@ -625,7 +998,9 @@ func TestPortService_RepositoryErrors(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	err = service.ProcessPortsFile(ctx, tmpfile.Name())
+	// Imports look up existing ports before saving, so only the save fails
+	service = NewPortService(&saveErrorRepository{newMockRepository()})
+	_, err = service.ProcessPortsFile(ctx, tmpfile.Name(), domain.ImportOptions{})
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to save port")
 	assert.Contains(t, err.Error(), "mock save error")
@@ -677,7 +1052,7 @@ func TestPortService_ConcurrentFileProcessing(t *testing.T) {
 	for i := 0; i < numFiles; i++ {
 		go func(file string) {
 			defer wg.Done()
-			if err := service.ProcessPortsFile(ctx, file); err != nil {
+			if _, err := service.ProcessPortsFile(ctx, file, domain.ImportOptions{}); err != nil {
 				errs <- err
 			}
 		}(files[i])
@@ -704,3 +1079,29 @@ func TestPortService_ConcurrentFileProcessing(t *testing.T) {
 	stats := repo.GetStatistics()
 	assert.Equal(t, int64(numFiles), stats.TotalPorts)
 }
+
+func TestPortService_CreateOrUpdatePorts(t *testing.T) {
+	repo := newMockRepository()
+	service := NewPortService(repo)
+	ctx := context.Background()
+
+	coords := []float64{55.5136433, 25.4052165}
+	port1, _ := domain.NewPort("TEST1", "Test Port 1", "Test City", "Test Country", coords, "", "", nil, "")
+	port2, _ := domain.NewPort("TEST2", "Test Port 2", "Test City", "Test Country", coords, "", "", nil, "")
+
+	result, err := service.CreateOrUpdatePorts(ctx, []*domain.Port{port1, nil, {ID: "NONAME"}, port2})
+	assert.NoError(t, err)
+	if assert.Len(t, result.Items, 4) {
+		assert.NoError(t, result.Items[0].Err)
+		assert.ErrorIs(t, result.Items[1].Err, domain.ErrInvalidPort)
+		assert.Equal(t, "NONAME", result.Items[2].ID)
+		assert.ErrorIs(t, result.Items[2].Err, domain.ErrInvalidPort)
+		assert.Equal(t, "TEST2", result.Items[3].ID)
+		assert.NoError(t, result.Items[3].Err)
+	}
+	assert.Equal(t, int64(2), repo.GetStatistics().TotalPorts)
+
+	// Repository failures fail the whole batch
+	_, err = NewPortService(&errorRepository{}).CreateOrUpdatePorts(ctx, []*domain.Port{port1})
+	assert.ErrorContains(t, err, "mock save error")
+}
@@ -0,0 +1,42 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"portservice/internal/domain"
+	"portservice/internal/ports/out"
+)
+
+// SearchPorts returns the ports best matching a free-text query
+func (s *portService) SearchPorts(ctx context.Context, query string, limit int) ([]domain.SearchResult, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	terms := domain.Tokenize(query)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("%w: empty search query", domain.ErrInvalidQuery)
+	}
+	limit = domain.NormalizeLimit(limit)
+
+	if search, ok := s.repository.(out.SearchRepository); ok {
+		return search.SearchPorts(ctx, query, limit)
+	}
+
+	// Fall back to scoring every port
+	results := make([]domain.SearchResult, 0)
+	err := s.forEachPort(ctx, domain.PortFilter{}, func(port *domain.Port) error {
+		if score := domain.ScoreSearch(terms, port); score > 0 {
+			results = append(results, domain.SearchResult{Port: port, Score: score})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	domain.SortSearchResults(results)
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
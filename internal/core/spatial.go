@@ -0,0 +1,146 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"portservice/internal/domain"
+	"portservice/internal/ports/out"
+)
+
+// FindNearest returns the k ports closest to center, nearest first
+func (s *portService) FindNearest(ctx context.Context, center domain.Coordinate, k int) ([]domain.PortDistance, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err := validateCoordinate(center); err != nil {
+		return nil, err
+	}
+	if k <= 0 {
+		return nil, fmt.Errorf("%w: k must be positive", domain.ErrInvalidQuery)
+	}
+	if k > domain.MaxPageLimit {
+		k = domain.MaxPageLimit
+	}
+
+	if spatial, ok := s.repository.(out.SpatialRepository); ok {
+		return spatial.FindNearest(ctx, center, k)
+	}
+
+	// Fall back to scanning every port, keeping at most 2k candidates
+	results := make([]domain.PortDistance, 0, 2*k)
+	err := s.forEachPort(ctx, domain.PortFilter{}, func(port *domain.Port) error {
+		results = append(results, domain.PortDistance{Port: port, DistanceKm: center.DistanceKm(*port.Coordinates)})
+		if len(results) == cap(results) {
+			domain.SortByDistance(results)
+			results = results[:k]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	domain.SortByDistance(results)
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results, nil
+}
+
+// FindWithinRadius returns the ports within radiusKm of center, nearest first
+func (s *portService) FindWithinRadius(ctx context.Context, center domain.Coordinate, radiusKm float64) ([]domain.PortDistance, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err := validateCoordinate(center); err != nil {
+		return nil, err
+	}
+	if radiusKm <= 0 || math.IsNaN(radiusKm) || math.IsInf(radiusKm, 0) {
+		return nil, fmt.Errorf("%w: radius must be a positive number of kilometres", domain.ErrInvalidQuery)
+	}
+
+	if spatial, ok := s.repository.(out.SpatialRepository); ok {
+		return spatial.FindWithinRadius(ctx, center, radiusKm)
+	}
+
+	// Fall back to scanning every port
+	results := make([]domain.PortDistance, 0)
+	err := s.forEachPort(ctx, domain.PortFilter{}, func(port *domain.Port) error {
+		if distance := center.DistanceKm(*port.Coordinates); distance <= radiusKm {
+			results = append(results, domain.PortDistance{Port: port, DistanceKm: distance})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	domain.SortByDistance(results)
+	return results, nil
+}
+
+// FindInBoundingBox returns the ports inside the box, ordered by port ID
+func (s *portService) FindInBoundingBox(ctx context.Context, box domain.BoundingBox) ([]*domain.Port, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if _, err := domain.NewBoundingBox(box.MinLongitude, box.MinLatitude, box.MaxLongitude, box.MaxLatitude); err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidQuery, err)
+	}
+	return s.findInBoundingBox(ctx, box)
+}
+
+// FindInPolygon returns the ports inside the polygon, ordered by port ID
+func (s *portService) FindInPolygon(ctx context.Context, polygon domain.Polygon) ([]*domain.Port, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if len(polygon.Rings) == 0 || len(polygon.Rings[0]) < 4 {
+		return nil, fmt.Errorf("%w: polygon must have an exterior ring", domain.ErrInvalidQuery)
+	}
+
+	if polygons, ok := s.repository.(out.PolygonRepository); ok {
+		return polygons.FindInPolygon(ctx, polygon)
+	}
+
+	// Narrow candidates with the polygon's bounding box before the exact test
+	candidates, err := s.findInBoundingBox(ctx, polygon.BoundingBox())
+	if err != nil {
+		return nil, err
+	}
+	results := candidates[:0]
+	for _, port := range candidates {
+		if polygon.Contains(*port.Coordinates) {
+			results = append(results, port)
+		}
+	}
+	return results, nil
+}
+
+// findInBoundingBox queries the spatial index, or scans every port when the
+// repository has none
+func (s *portService) findInBoundingBox(ctx context.Context, box domain.BoundingBox) ([]*domain.Port, error) {
+	if spatial, ok := s.repository.(out.SpatialRepository); ok {
+		return spatial.FindInBoundingBox(ctx, box)
+	}
+
+	results := make([]*domain.Port, 0)
+	err := s.forEachPort(ctx, domain.PortFilter{}, func(port *domain.Port) error {
+		if box.Contains(*port.Coordinates) {
+			results = append(results, port)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// validateCoordinate checks that a query coordinate is within range
+func validateCoordinate(c domain.Coordinate) error {
+	if _, err := domain.NewCoordinate(c.Longitude, c.Latitude); err != nil {
+		return fmt.Errorf("%w: %w", domain.ErrInvalidQuery, err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package domain
+
+import "errors"
+
+// BatchItemResult is the outcome of writing one port of a batch; Err is nil
+// when the port was saved
+type BatchItemResult struct {
+	ID  string
+	Err error
+}
+
+// BatchResult reports the outcome of a batch write item by item, in the
+// order the ports were given
+type BatchResult struct {
+	Items []BatchItemResult
+}
+
+// Saved returns the number of ports written successfully
+func (r BatchResult) Saved() int {
+	return len(r.Items) - r.Failed()
+}
+
+// Failed returns the number of ports that could not be written
+func (r BatchResult) Failed() int {
+	failed := 0
+	for _, item := range r.Items {
+		if item.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// FirstError returns the error of the first failed item, or nil if every
+// port was written
+func (r BatchResult) FirstError() error {
+	for _, item := range r.Items {
+		if item.Err != nil {
+			return item.Err
+		}
+	}
+	return nil
+}
+
+// PrepareBatch validates a batch of ports for a repository write, returning
+// copies of the valid ones to store alongside the per-item results
+func PrepareBatch(ports []*Port) ([]*Port, BatchResult) {
+	stored := make([]*Port, 0, len(ports))
+	result := BatchResult{Items: make([]BatchItemResult, len(ports))}
+	for i, port := range ports {
+		if port == nil {
+			result.Items[i].Err = errors.New("port cannot be nil")
+			continue
+		}
+		result.Items[i].ID = port.ID
+		if err := port.Validate(); err != nil {
+			result.Items[i].Err = err
+			continue
+		}
+		stored = append(stored, port.Clone())
+	}
+	return stored, result
+}
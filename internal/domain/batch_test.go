@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchResult(t *testing.T) {
+	errInvalid := errors.New("invalid")
+	result := BatchResult{Items: []BatchItemResult{
+		{ID: "A"},
+		{ID: "B", Err: errInvalid},
+		{ID: "C"},
+	}}
+	assert.Equal(t, 2, result.Saved())
+	assert.Equal(t, 1, result.Failed())
+	assert.Equal(t, errInvalid, result.FirstError())
+
+	assert.NoError(t, BatchResult{}.FirstError())
+	assert.Zero(t, BatchResult{}.Saved())
+}
+
+func TestPrepareBatch(t *testing.T) {
+	port, _ := NewPort("AEAJM", "Ajman", "", "", []float64{55.5, 25.4}, "", "", nil, "")
+	stored, result := PrepareBatch([]*Port{port, nil, {ID: "INVALID"}})
+	assert.Equal(t, 1, result.Saved())
+	assert.Equal(t, []string{"AEAJM", "", "INVALID"}, []string{result.Items[0].ID, result.Items[1].ID, result.Items[2].ID})
+	assert.Error(t, result.Items[1].Err)
+	assert.Error(t, result.Items[2].Err)
+
+	// Valid ports are copied so the caller can't change stored data
+	if assert.Len(t, stored, 1) {
+		assert.Equal(t, port, stored[0])
+		assert.NotSame(t, port, stored[0])
+	}
+}
@@ -0,0 +1,179 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultCSVListSeparator separates the values of multi-value CSV columns
+// when CSVOptions.ListSeparator is unset
+const DefaultCSVListSeparator = ";"
+
+// CSVField names a port field held in a CSV column
+type CSVField string
+
+// CSV fields, named after their default column headers
+const (
+	CSVID        CSVField = "id"
+	CSVName      CSVField = "name"
+	CSVCity      CSVField = "city"
+	CSVCountry   CSVField = "country"
+	CSVLongitude CSVField = "lon"
+	CSVLatitude  CSVField = "lat"
+	CSVProvince  CSVField = "province"
+	CSVTimezone  CSVField = "timezone"
+	CSVUnlocs    CSVField = "unlocs"
+	CSVCode      CSVField = "code"
+	CSVAlias     CSVField = "alias"
+	CSVRegions   CSVField = "regions"
+)
+
+// CSVFields lists every CSV field in the default column order
+var CSVFields = []CSVField{
+	CSVID, CSVName, CSVCity, CSVCountry, CSVLongitude, CSVLatitude,
+	CSVProvince, CSVTimezone, CSVUnlocs, CSVCode, CSVAlias, CSVRegions,
+}
+
+// CSVOptions controls how port data is laid out in CSV. The zero value
+// reads and writes comma-separated columns named after the fields.
+type CSVOptions struct {
+	// Columns maps fields to the header of the column holding them; fields
+	// left out are read from and written to the column named after the field
+	Columns map[CSVField]string
+
+	// Fields lists the columns exports write, in order; empty writes
+	// CSVFields. Imports ignore it.
+	Fields []CSVField
+
+	// BOM starts exports with a UTF-8 byte order mark, which spreadsheets
+	// need to detect the encoding. Imports skip a BOM either way.
+	BOM bool
+
+	// Comma is the field delimiter; zero uses ','
+	Comma rune
+
+	// ListSeparator splits multi-value columns such as unlocs; empty uses
+	// DefaultCSVListSeparator
+	ListSeparator string
+}
+
+// Column returns the header of the column holding field
+func (o CSVOptions) Column(field CSVField) string {
+	if column, ok := o.Columns[field]; ok {
+		return column
+	}
+	return string(field)
+}
+
+// Delimiter returns the field delimiter
+func (o CSVOptions) Delimiter() rune {
+	if o.Comma != 0 {
+		return o.Comma
+	}
+	return ','
+}
+
+// ExportFields returns the fields exports write, in column order
+func (o CSVOptions) ExportFields() []CSVField {
+	if len(o.Fields) > 0 {
+		return o.Fields
+	}
+	return CSVFields
+}
+
+// listSeparator returns the separator between the values of multi-value
+// columns
+func (o CSVOptions) listSeparator() string {
+	if o.ListSeparator != "" {
+		return o.ListSeparator
+	}
+	return DefaultCSVListSeparator
+}
+
+// JoinList joins the values of a multi-value column
+func (o CSVOptions) JoinList(values []string) string {
+	return strings.Join(values, o.listSeparator())
+}
+
+// SplitList splits a multi-value column into its trimmed, non-empty values
+func (o CSVOptions) SplitList(value string) []string {
+	values := make([]string, 0)
+	for _, v := range strings.Split(value, o.listSeparator()) {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// Validate checks that the options are well-formed for an import
+func (o CSVOptions) Validate() error {
+	return o.validate(ErrInvalidImportOptions)
+}
+
+// validate checks that the options are well-formed, wrapping invalid with
+// the reason they are not
+func (o CSVOptions) validate(invalid error) error {
+	for field, column := range o.Columns {
+		if !slices.Contains(CSVFields, field) {
+			return fmt.Errorf("%w: unknown CSV field %q", invalid, field)
+		}
+		if strings.TrimSpace(column) == "" {
+			return fmt.Errorf("%w: empty CSV column for field %q", invalid, field)
+		}
+	}
+	for i, field := range o.Fields {
+		if !slices.Contains(CSVFields, field) {
+			return fmt.Errorf("%w: unknown CSV field %q", invalid, field)
+		}
+		if slices.Contains(o.Fields[:i], field) {
+			return fmt.Errorf("%w: duplicate CSV field %q", invalid, field)
+		}
+	}
+	switch o.Comma {
+	case '"', '\r', '\n', utf8.RuneError:
+		return fmt.Errorf("%w: invalid CSV delimiter %q", invalid, o.Comma)
+	}
+	if o.ListSeparator != "" && strings.ContainsRune(o.ListSeparator, o.Delimiter()) {
+		return fmt.Errorf("%w: CSV list separator must not contain the delimiter", invalid)
+	}
+	return nil
+}
+
+// ParseCSVColumns parses a column mapping of the form
+// "id=LOCODE,name=Port Name" into CSVOptions.Columns
+func ParseCSVColumns(spec string) (map[CSVField]string, error) {
+	columns := make(map[CSVField]string)
+	if strings.TrimSpace(spec) == "" {
+		return columns, nil
+	}
+	for _, pair := range strings.Split(spec, ",") {
+		field, column, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: CSV column mapping %q is not field=column", ErrInvalidImportOptions, pair)
+		}
+		columns[CSVField(NormalizeFilterValue(field))] = strings.TrimSpace(column)
+	}
+	if err := (CSVOptions{Columns: columns}).Validate(); err != nil {
+		return nil, err
+	}
+	return columns, nil
+}
+
+// ParseCSVFields parses a comma-separated list of fields, such as
+// "id,name,lon,lat", into CSVOptions.Fields
+func ParseCSVFields(spec string) ([]CSVField, error) {
+	fields := make([]CSVField, 0)
+	if strings.TrimSpace(spec) == "" {
+		return fields, nil
+	}
+	for _, name := range strings.Split(spec, ",") {
+		fields = append(fields, CSVField(NormalizeFilterValue(name)))
+	}
+	if err := (CSVOptions{Fields: fields}).validate(ErrInvalidExportOptions); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCSVColumns(t *testing.T) {
+	columns, err := ParseCSVColumns(" ID = LOCODE ,name=Port Name")
+	assert.NoError(t, err)
+	assert.Equal(t, map[CSVField]string{CSVID: "LOCODE", CSVName: "Port Name"}, columns)
+
+	columns, err = ParseCSVColumns("")
+	assert.NoError(t, err)
+	assert.Empty(t, columns)
+
+	_, err = ParseCSVColumns("id")
+	assert.ErrorIs(t, err, ErrInvalidImportOptions)
+	_, err = ParseCSVColumns("depth=Depth")
+	assert.ErrorIs(t, err, ErrInvalidImportOptions)
+	_, err = ParseCSVColumns("id=")
+	assert.ErrorIs(t, err, ErrInvalidImportOptions)
+}
+
+func TestCSVOptions(t *testing.T) {
+	var opts CSVOptions
+	assert.Equal(t, "lon", opts.Column(CSVLongitude))
+	assert.Equal(t, ',', opts.Delimiter())
+	assert.Equal(t, []string{"AEAJM", "AEAUH"}, opts.SplitList(" AEAJM ;;AEAUH"))
+	assert.Empty(t, opts.SplitList(""))
+	assert.Equal(t, "AEAJM;AEAUH", opts.JoinList([]string{"AEAJM", "AEAUH"}))
+	assert.Equal(t, CSVFields, opts.ExportFields())
+
+	opts = CSVOptions{Columns: map[CSVField]string{CSVLongitude: "Longitude"}, Comma: '\t', ListSeparator: "|"}
+	assert.Equal(t, "Longitude", opts.Column(CSVLongitude))
+	assert.Equal(t, '\t', opts.Delimiter())
+	assert.Equal(t, []string{"a", "b"}, opts.SplitList("a|b"))
+	assert.Equal(t, "a|b", opts.JoinList([]string{"a", "b"}))
+	assert.NoError(t, opts.Validate())
+
+	assert.ErrorIs(t, CSVOptions{Comma: '"'}.Validate(), ErrInvalidImportOptions)
+	assert.ErrorIs(t, CSVOptions{ListSeparator: ","}.Validate(), ErrInvalidImportOptions)
+}
+
+func TestParseCSVFields(t *testing.T) {
+	fields, err := ParseCSVFields(" ID, name,lon ")
+	assert.NoError(t, err)
+	assert.Equal(t, []CSVField{CSVID, CSVName, CSVLongitude}, fields)
+
+	fields, err = ParseCSVFields("")
+	assert.NoError(t, err)
+	assert.Empty(t, fields)
+
+	_, err = ParseCSVFields("id,depth")
+	assert.ErrorIs(t, err, ErrInvalidExportOptions)
+	_, err = ParseCSVFields("id,name,id")
+	assert.ErrorIs(t, err, ErrInvalidExportOptions)
+}
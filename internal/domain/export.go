@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidExportOptions is returned when export options are malformed
+var ErrInvalidExportOptions = errors.New("invalid export options")
+
+// ExportFormat identifies the encoding ports are exported in
+type ExportFormat string
+
+const (
+	// ExportNDJSON writes one JSON port per line, each with its ID in the id
+	// field, in the shape ImportNDJSON reads
+	ExportNDJSON ExportFormat = "ndjson"
+
+	// ExportGeoJSON writes a GeoJSON FeatureCollection of Point features,
+	// each holding a port's fields in its properties
+	ExportGeoJSON ExportFormat = "geojson"
+
+	// ExportJSON writes an indented JSON object mapping port IDs to records
+	// in the shape ImportJSON reads, ordered so that exporting the same
+	// ports always yields the same bytes
+	ExportJSON ExportFormat = "json"
+
+	// ExportCSV writes a header row and one row per port, with the columns
+	// chosen by ExportOptions.CSV
+	ExportCSV ExportFormat = "csv"
+)
+
+// ParseExportFormat parses an export format name; the empty string selects
+// ExportNDJSON
+func ParseExportFormat(name string) (ExportFormat, error) {
+	switch format := ExportFormat(NormalizeFilterValue(name)); format {
+	case "":
+		return ExportNDJSON, nil
+	case ExportNDJSON, ExportGeoJSON, ExportJSON, ExportCSV:
+		return format, nil
+	default:
+		return "", fmt.Errorf("%w: unknown format %q", ErrInvalidExportOptions, name)
+	}
+}
+
+// ExportOptions controls how ports are exported. The zero value exports
+// every port as NDJSON.
+type ExportOptions struct {
+	Format ExportFormat
+
+	// Filter selects the ports to export
+	Filter PortFilter
+
+	// CSV lays out ExportCSV exports
+	CSV CSVOptions
+}
+
+// Validate checks that the options are well-formed
+func (o ExportOptions) Validate() error {
+	format, err := ParseExportFormat(string(o.Format))
+	if err != nil {
+		return err
+	}
+	// Keyed ports files have no place for deleted_at, so tombstones would
+	// come back to life when the export is imported
+	if format == ExportJSON && o.Filter.IncludeDeleted {
+		return fmt.Errorf("%w: the %s format cannot include deleted ports", ErrInvalidExportOptions, format)
+	}
+	return o.CSV.validate(ErrInvalidExportOptions)
+}
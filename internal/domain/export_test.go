@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseExportFormat(t *testing.T) {
+	format, err := ParseExportFormat("")
+	assert.NoError(t, err)
+	assert.Equal(t, ExportNDJSON, format)
+
+	format, err = ParseExportFormat(" NDJSON ")
+	assert.NoError(t, err)
+	assert.Equal(t, ExportNDJSON, format)
+
+	format, err = ParseExportFormat("geojson")
+	assert.NoError(t, err)
+	assert.Equal(t, ExportGeoJSON, format)
+
+	format, err = ParseExportFormat("json")
+	assert.NoError(t, err)
+	assert.Equal(t, ExportJSON, format)
+
+	format, err = ParseExportFormat("CSV")
+	assert.NoError(t, err)
+	assert.Equal(t, ExportCSV, format)
+
+	_, err = ParseExportFormat("xml")
+	assert.ErrorIs(t, err, ErrInvalidExportOptions)
+}
+
+func TestExportOptions_Validate(t *testing.T) {
+	assert.NoError(t, ExportOptions{}.Validate())
+	assert.ErrorIs(t, ExportOptions{Format: "xml"}.Validate(), ErrInvalidExportOptions)
+	assert.ErrorIs(t, ExportOptions{CSV: CSVOptions{Comma: '\n'}}.Validate(), ErrInvalidExportOptions)
+	assert.NoError(t, ExportOptions{Filter: PortFilter{IncludeDeleted: true}}.Validate())
+	assert.ErrorIs(t, ExportOptions{Format: ExportJSON, Filter: PortFilter{IncludeDeleted: true}}.Validate(), ErrInvalidExportOptions)
+}
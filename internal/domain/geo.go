@@ -0,0 +1,210 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// EarthRadiusKm is the mean Earth radius used for great-circle distances
+const EarthRadiusKm = 6371.0088
+
+// MaxDistanceKm is the largest possible great-circle distance between two points
+const MaxDistanceKm = math.Pi * EarthRadiusKm
+
+// DistanceKm returns the great-circle distance to another coordinate in
+// kilometres using the haversine formula
+func (c Coordinate) DistanceKm(other Coordinate) float64 {
+	lat1 := DegreesToRadians(c.Latitude)
+	lat2 := DegreesToRadians(other.Latitude)
+	dLat := lat2 - lat1
+	dLon := DegreesToRadians(other.Longitude - c.Longitude)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// PortDistance pairs a port with its distance from a query coordinate
+type PortDistance struct {
+	Port       *Port   `json:"port"`
+	DistanceKm float64 `json:"distance_km"`
+}
+
+// SortByDistance orders results by ascending distance, breaking ties by port ID
+func SortByDistance(results []PortDistance) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].DistanceKm != results[j].DistanceKm {
+			return results[i].DistanceKm < results[j].DistanceKm
+		}
+		return results[i].Port.ID < results[j].Port.ID
+	})
+}
+
+// BoundingBox is a longitude/latitude rectangle. A box whose MinLongitude is
+// greater than its MaxLongitude crosses the antimeridian, e.g. a box from
+// 170 to -170 covers the 20 degrees around longitude 180.
+type BoundingBox struct {
+	MinLongitude float64 `json:"min_lon"`
+	MinLatitude  float64 `json:"min_lat"`
+	MaxLongitude float64 `json:"max_lon"`
+	MaxLatitude  float64 `json:"max_lat"`
+}
+
+// NewBoundingBox creates a new bounding box with validation
+func NewBoundingBox(minLon, minLat, maxLon, maxLat float64) (BoundingBox, error) {
+	if _, err := NewCoordinate(minLon, minLat); err != nil {
+		return BoundingBox{}, fmt.Errorf("invalid south-west corner: %w", err)
+	}
+	if _, err := NewCoordinate(maxLon, maxLat); err != nil {
+		return BoundingBox{}, fmt.Errorf("invalid north-east corner: %w", err)
+	}
+	if minLat > maxLat {
+		return BoundingBox{}, errors.New("minimum latitude must not exceed maximum latitude")
+	}
+	return BoundingBox{
+		MinLongitude: minLon,
+		MinLatitude:  minLat,
+		MaxLongitude: maxLon,
+		MaxLatitude:  maxLat,
+	}, nil
+}
+
+// CrossesAntimeridian reports whether the box wraps around longitude 180
+func (b BoundingBox) CrossesAntimeridian() bool {
+	return b.MinLongitude > b.MaxLongitude
+}
+
+// Contains reports whether the coordinate lies inside the box, edges included
+func (b BoundingBox) Contains(c Coordinate) bool {
+	if c.Latitude < b.MinLatitude || c.Latitude > b.MaxLatitude {
+		return false
+	}
+	if b.CrossesAntimeridian() {
+		return c.Longitude >= b.MinLongitude || c.Longitude <= b.MaxLongitude
+	}
+	return c.Longitude >= b.MinLongitude && c.Longitude <= b.MaxLongitude
+}
+
+// Polygon is a GeoJSON polygon: an exterior ring followed by optional holes.
+// Edges are interpreted as straight lines in longitude/latitude space, so
+// polygons crossing the antimeridian must be split as RFC 7946 recommends.
+type Polygon struct {
+	Rings [][]Coordinate
+}
+
+// NewPolygon creates a new polygon from GeoJSON [longitude, latitude]
+// positions with validation. Each ring must be closed and have at least
+// four positions.
+func NewPolygon(rings [][][]float64) (Polygon, error) {
+	if len(rings) == 0 {
+		return Polygon{}, errors.New("polygon must have an exterior ring")
+	}
+	polygon := Polygon{Rings: make([][]Coordinate, len(rings))}
+	for i, ring := range rings {
+		if len(ring) < 4 {
+			return Polygon{}, fmt.Errorf("ring %d must have at least 4 positions", i)
+		}
+		coords := make([]Coordinate, len(ring))
+		for j, position := range ring {
+			if len(position) < 2 {
+				return Polygon{}, fmt.Errorf("ring %d position %d must contain longitude and latitude", i, j)
+			}
+			coordinate, err := NewCoordinate(position[0], position[1])
+			if err != nil {
+				return Polygon{}, fmt.Errorf("ring %d position %d: %w", i, j, err)
+			}
+			coords[j] = *coordinate
+		}
+		if coords[0] != coords[len(coords)-1] {
+			return Polygon{}, fmt.Errorf("ring %d is not closed", i)
+		}
+		polygon.Rings[i] = coords
+	}
+	return polygon, nil
+}
+
+// geoJSONObject is the subset of a GeoJSON object needed to read polygons
+type geoJSONObject struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+	Geometry    *geoJSONObject  `json:"geometry"`
+}
+
+// ParseGeoJSONPolygon reads a GeoJSON Polygon geometry, or a Feature whose
+// geometry is a Polygon
+func ParseGeoJSONPolygon(data []byte) (Polygon, error) {
+	var object geoJSONObject
+	if err := json.Unmarshal(data, &object); err != nil {
+		return Polygon{}, fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+	if object.Type == "Feature" {
+		if object.Geometry == nil {
+			return Polygon{}, errors.New("GeoJSON feature has no geometry")
+		}
+		object = *object.Geometry
+	}
+	if object.Type != "Polygon" {
+		return Polygon{}, fmt.Errorf("unsupported GeoJSON geometry type %q, expected Polygon", object.Type)
+	}
+
+	var rings [][][]float64
+	if err := json.Unmarshal(object.Coordinates, &rings); err != nil {
+		return Polygon{}, fmt.Errorf("invalid polygon coordinates: %w", err)
+	}
+	return NewPolygon(rings)
+}
+
+// BoundingBox returns the smallest box containing the exterior ring
+func (p Polygon) BoundingBox() BoundingBox {
+	box := BoundingBox{
+		MinLongitude: math.Inf(1),
+		MinLatitude:  math.Inf(1),
+		MaxLongitude: math.Inf(-1),
+		MaxLatitude:  math.Inf(-1),
+	}
+	for _, c := range p.Rings[0] {
+		box.MinLongitude = math.Min(box.MinLongitude, c.Longitude)
+		box.MinLatitude = math.Min(box.MinLatitude, c.Latitude)
+		box.MaxLongitude = math.Max(box.MaxLongitude, c.Longitude)
+		box.MaxLatitude = math.Max(box.MaxLatitude, c.Latitude)
+	}
+	return box
+}
+
+// Contains reports whether the coordinate lies inside the exterior ring and
+// outside every hole
+func (p Polygon) Contains(c Coordinate) bool {
+	if len(p.Rings) == 0 || !ringContains(p.Rings[0], c) {
+		return false
+	}
+	for _, hole := range p.Rings[1:] {
+		if ringContains(hole, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// ringContains tests whether a point lies inside a closed ring using the
+// even-odd ray casting rule
+func ringContains(ring []Coordinate, c Coordinate) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Latitude > c.Latitude) != (b.Latitude > c.Latitude) {
+			crossing := (b.Longitude-a.Longitude)*(c.Latitude-a.Latitude)/(b.Latitude-a.Latitude) + a.Longitude
+			if c.Longitude < crossing {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// DegreesToRadians converts an angle from degrees to radians
+func DegreesToRadians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
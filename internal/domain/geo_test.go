@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoordinate_DistanceKm(t *testing.T) {
+	tests := []struct {
+		name   string
+		from   Coordinate
+		to     Coordinate
+		wantKm float64
+	}{
+		{
+			name:   "same point",
+			from:   Coordinate{Longitude: 55.27, Latitude: 25.25},
+			to:     Coordinate{Longitude: 55.27, Latitude: 25.25},
+			wantKm: 0,
+		},
+		{
+			name:   "Dubai to Abu Dhabi",
+			from:   Coordinate{Longitude: 55.27, Latitude: 25.25},
+			to:     Coordinate{Longitude: 54.37, Latitude: 24.47},
+			wantKm: 125.6,
+		},
+		{
+			name:   "across the antimeridian",
+			from:   Coordinate{Longitude: 179.5, Latitude: 0},
+			to:     Coordinate{Longitude: -179.5, Latitude: 0},
+			wantKm: 111.2,
+		},
+		{
+			name:   "antipodes",
+			from:   Coordinate{Longitude: 0, Latitude: 0},
+			to:     Coordinate{Longitude: 180, Latitude: 0},
+			wantKm: MaxDistanceKm,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.wantKm, tt.from.DistanceKm(tt.to), 0.5)
+			assert.InDelta(t, tt.wantKm, tt.to.DistanceKm(tt.from), 0.5)
+		})
+	}
+}
+
+func TestBoundingBox_Contains(t *testing.T) {
+	gulf, err := NewBoundingBox(50, 22, 60, 30)
+	assert.NoError(t, err)
+	assert.False(t, gulf.CrossesAntimeridian())
+	assert.True(t, gulf.Contains(Coordinate{Longitude: 55.27, Latitude: 25.25}))
+	assert.True(t, gulf.Contains(Coordinate{Longitude: 50, Latitude: 30}))
+	assert.False(t, gulf.Contains(Coordinate{Longitude: 10.75, Latitude: 59.91}))
+
+	// Fiji straddles the antimeridian
+	fiji, err := NewBoundingBox(175, -22, -175, -12)
+	assert.NoError(t, err)
+	assert.True(t, fiji.CrossesAntimeridian())
+	assert.True(t, fiji.Contains(Coordinate{Longitude: 178.4, Latitude: -18.1}))
+	assert.True(t, fiji.Contains(Coordinate{Longitude: -179.9, Latitude: -16}))
+	assert.False(t, fiji.Contains(Coordinate{Longitude: 0, Latitude: -16}))
+	assert.False(t, fiji.Contains(Coordinate{Longitude: 178.4, Latitude: 0}))
+
+	_, err = NewBoundingBox(0, 10, 1, 5)
+	assert.Error(t, err)
+	_, err = NewBoundingBox(-181, 0, 1, 5)
+	assert.Error(t, err)
+}
+
+func TestParseGeoJSONPolygon(t *testing.T) {
+	// A square with a square hole
+	geometry := `{
+		"type": "Polygon",
+		"coordinates": [
+			[[0, 0], [10, 0], [10, 10], [0, 10], [0, 0]],
+			[[4, 4], [6, 4], [6, 6], [4, 6], [4, 4]]
+		]
+	}`
+	polygon, err := ParseGeoJSONPolygon([]byte(geometry))
+	assert.NoError(t, err)
+	assert.True(t, polygon.Contains(Coordinate{Longitude: 2, Latitude: 2}))
+	assert.False(t, polygon.Contains(Coordinate{Longitude: 5, Latitude: 5}))
+	assert.False(t, polygon.Contains(Coordinate{Longitude: 11, Latitude: 5}))
+	assert.Equal(t, BoundingBox{MinLongitude: 0, MinLatitude: 0, MaxLongitude: 10, MaxLatitude: 10}, polygon.BoundingBox())
+
+	// Features wrapping a polygon are accepted
+	feature := `{"type": "Feature", "properties": {}, "geometry": ` + geometry + `}`
+	_, err = ParseGeoJSONPolygon([]byte(feature))
+	assert.NoError(t, err)
+
+	invalid := []string{
+		`{"type": "Point", "coordinates": [0, 0]}`,
+		`{"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 1]]]}`,
+		`{"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [0, 0]]]}`,
+		`{"type": "Polygon", "coordinates": [[[0, 0], [200, 0], [1, 1], [0, 0]]]}`,
+		`{"type": "Polygon", "coordinates": []}`,
+		`{"type": "Feature"}`,
+		`not json`,
+	}
+	for _, data := range invalid {
+		_, err := ParseGeoJSONPolygon([]byte(data))
+		assert.Error(t, err, data)
+	}
+}
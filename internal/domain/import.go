@@ -0,0 +1,219 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// DefaultImportBatchSize is the number of ports written per batch when
+// ImportOptions.BatchSize is unset
+const DefaultImportBatchSize = 500
+
+var (
+	// ErrInvalidImportOptions is returned when import options are malformed
+	ErrInvalidImportOptions = errors.New("invalid import options")
+
+	// ErrImportRejected is returned when an import fails validation as a
+	// whole and nothing was committed
+	ErrImportRejected = errors.New("import rejected")
+)
+
+// ImportMode selects how an import treats invalid records
+type ImportMode string
+
+const (
+	// ImportLenient skips invalid records and commits the rest
+	ImportLenient ImportMode = "lenient"
+
+	// ImportStrict fails the import without committing anything if any
+	// record is invalid
+	ImportStrict ImportMode = "strict"
+
+	// ImportThreshold fails the import without committing anything if more
+	// than ImportOptions.MaxRejectedPercent of the records are invalid
+	ImportThreshold ImportMode = "threshold"
+)
+
+// ParseImportMode parses an import mode name; the empty string selects
+// ImportLenient
+func ParseImportMode(name string) (ImportMode, error) {
+	switch mode := ImportMode(NormalizeFilterValue(name)); mode {
+	case "":
+		return ImportLenient, nil
+	case ImportLenient, ImportStrict, ImportThreshold:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%w: unknown mode %q", ErrInvalidImportOptions, name)
+	}
+}
+
+// ImportFormat identifies the encoding of an import's input
+type ImportFormat string
+
+const (
+	// ImportJSON reads a JSON object mapping port IDs to port records
+	ImportJSON ImportFormat = "json"
+
+	// ImportCSV reads CSV with a header row naming the columns
+	ImportCSV ImportFormat = "csv"
+
+	// ImportNDJSON reads one JSON port record per line, each with its ID in
+	// the id field
+	ImportNDJSON ImportFormat = "ndjson"
+
+	// ImportGeoJSON reads a GeoJSON FeatureCollection of Point features,
+	// each holding a port's fields in its properties
+	ImportGeoJSON ImportFormat = "geojson"
+
+	// ImportUNLOCODE reads the UNECE UN/LOCODE code list CSV, merging each
+	// entry into the existing port listing its UN/LOCODE
+	ImportUNLOCODE ImportFormat = "unlocode"
+)
+
+// ParseImportFormat parses an import format name; the empty string selects
+// ImportJSON
+func ParseImportFormat(name string) (ImportFormat, error) {
+	switch format := ImportFormat(NormalizeFilterValue(name)); format {
+	case "":
+		return ImportJSON, nil
+	case ImportJSON, ImportCSV, ImportNDJSON, ImportGeoJSON, ImportUNLOCODE:
+		return format, nil
+	default:
+		return "", fmt.Errorf("%w: unknown format %q", ErrInvalidImportOptions, name)
+	}
+}
+
+// ImportOptions controls how ports files are imported. The zero value
+// imports JSON leniently.
+type ImportOptions struct {
+	Mode   ImportMode
+	Format ImportFormat
+
+	// CSV configures how CSV input is read when Format is ImportCSV
+	CSV CSVOptions
+
+	// MaxRejectedPercent is the share of rejected records, from 0 to 100,
+	// tolerated in threshold mode
+	MaxRejectedPercent float64
+
+	// Workers is the number of goroutines validating and converting
+	// records; zero uses one per CPU
+	Workers int
+
+	// BatchSize is the number of ports written to the repository at a time;
+	// zero uses DefaultImportBatchSize
+	BatchSize int
+
+	// StartLine resumes an NDJSON import at the given line, numbered from
+	// one, skipping the lines before it; zero starts at the first line
+	StartLine int
+}
+
+// WorkerCount returns the number of conversion workers to run
+func (o ImportOptions) WorkerCount() int {
+	if o.Workers > 0 {
+		return o.Workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// BatchLimit returns the number of ports to write per batch
+func (o ImportOptions) BatchLimit() int {
+	if o.BatchSize > 0 {
+		return o.BatchSize
+	}
+	return DefaultImportBatchSize
+}
+
+// Validate checks that the options are well-formed
+func (o ImportOptions) Validate() error {
+	if _, err := ParseImportMode(string(o.Mode)); err != nil {
+		return err
+	}
+	format, err := ParseImportFormat(string(o.Format))
+	if err != nil {
+		return err
+	}
+	if err := o.CSV.Validate(); err != nil {
+		return err
+	}
+	if o.MaxRejectedPercent < 0 || o.MaxRejectedPercent > 100 {
+		return fmt.Errorf("%w: max rejected percent must be between 0 and 100", ErrInvalidImportOptions)
+	}
+	if o.Workers < 0 {
+		return fmt.Errorf("%w: workers must not be negative", ErrInvalidImportOptions)
+	}
+	if o.BatchSize < 0 {
+		return fmt.Errorf("%w: batch size must not be negative", ErrInvalidImportOptions)
+	}
+	if o.StartLine < 0 {
+		return fmt.Errorf("%w: start line must not be negative", ErrInvalidImportOptions)
+	}
+	if o.StartLine > 0 && format != ImportNDJSON {
+		return fmt.Errorf("%w: only NDJSON imports can start at a line", ErrInvalidImportOptions)
+	}
+	return nil
+}
+
+// Check returns ErrImportRejected when the report's rejections exceed what
+// the mode tolerates
+func (o ImportOptions) Check(report *ImportReport) error {
+	total := report.Total()
+	switch o.Mode {
+	case ImportStrict:
+		if report.Rejected > 0 {
+			return fmt.Errorf("%w: %d of %d records are invalid", ErrImportRejected, report.Rejected, total)
+		}
+	case ImportThreshold:
+		if total > 0 && float64(report.Rejected)*100/float64(total) > o.MaxRejectedPercent {
+			return fmt.Errorf("%w: %d of %d records are invalid, more than %v%%",
+				ErrImportRejected, report.Rejected, total, o.MaxRejectedPercent)
+		}
+	}
+	return nil
+}
+
+// ImportRejection describes an input record that was skipped during an import
+type ImportRejection struct {
+	PortID string `json:"port_id"`
+	Field  string `json:"field,omitempty"`
+	Reason string `json:"reason"`
+
+	// Offset is the byte offset of the record in the input; for JSON objects
+	// that of the record's key
+	Offset int64 `json:"offset"`
+
+	// Line is the line number of the record in line-based formats such as
+	// NDJSON and CSV
+	Line int `json:"line,omitempty"`
+}
+
+// ImportReport summarizes the outcome of importing a ports file
+type ImportReport struct {
+	Created    int               `json:"created"`
+	Updated    int               `json:"updated"`
+	Unchanged  int               `json:"unchanged"`
+	Rejected   int               `json:"rejected"`
+	Rejections []ImportRejection `json:"rejections"`
+
+	// Pending counts valid records that have not been committed, either
+	// because the import is in progress or because it failed
+	Pending int `json:"pending,omitempty"`
+}
+
+// NewImportReport creates an empty ImportReport
+func NewImportReport() *ImportReport {
+	return &ImportReport{Rejections: make([]ImportRejection, 0)}
+}
+
+// Total returns the number of records read from the input
+func (r *ImportReport) Total() int {
+	return r.Created + r.Updated + r.Unchanged + r.Rejected + r.Pending
+}
+
+// Reject records a skipped input record
+func (r *ImportReport) Reject(rejection ImportRejection) {
+	r.Rejected++
+	r.Rejections = append(r.Rejections, rejection)
+}
@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseImportMode(t *testing.T) {
+	mode, err := ParseImportMode("")
+	assert.NoError(t, err)
+	assert.Equal(t, ImportLenient, mode)
+
+	mode, err = ParseImportMode(" Strict ")
+	assert.NoError(t, err)
+	assert.Equal(t, ImportStrict, mode)
+
+	_, err = ParseImportMode("careful")
+	assert.ErrorIs(t, err, ErrInvalidImportOptions)
+}
+
+func TestParseImportFormat(t *testing.T) {
+	format, err := ParseImportFormat("")
+	assert.NoError(t, err)
+	assert.Equal(t, ImportJSON, format)
+
+	format, err = ParseImportFormat("CSV")
+	assert.NoError(t, err)
+	assert.Equal(t, ImportCSV, format)
+
+	format, err = ParseImportFormat("GeoJSON")
+	assert.NoError(t, err)
+	assert.Equal(t, ImportGeoJSON, format)
+
+	_, err = ParseImportFormat("xml")
+	assert.ErrorIs(t, err, ErrInvalidImportOptions)
+}
+
+func TestImportOptions_Validate(t *testing.T) {
+	assert.NoError(t, ImportOptions{}.Validate())
+	assert.NoError(t, ImportOptions{Mode: ImportThreshold, MaxRejectedPercent: 2.5}.Validate())
+	assert.ErrorIs(t, ImportOptions{Mode: "careful"}.Validate(), ErrInvalidImportOptions)
+	assert.ErrorIs(t, ImportOptions{Mode: ImportThreshold, MaxRejectedPercent: 101}.Validate(), ErrInvalidImportOptions)
+	assert.ErrorIs(t, ImportOptions{Workers: -1}.Validate(), ErrInvalidImportOptions)
+	assert.ErrorIs(t, ImportOptions{BatchSize: -1}.Validate(), ErrInvalidImportOptions)
+	assert.ErrorIs(t, ImportOptions{Format: "xml"}.Validate(), ErrInvalidImportOptions)
+	assert.NoError(t, ImportOptions{Format: ImportNDJSON, StartLine: 10}.Validate())
+	assert.ErrorIs(t, ImportOptions{Format: ImportNDJSON, StartLine: -1}.Validate(), ErrInvalidImportOptions)
+	assert.ErrorIs(t, ImportOptions{StartLine: 10}.Validate(), ErrInvalidImportOptions)
+	assert.ErrorIs(t, ImportOptions{CSV: CSVOptions{Comma: '\n'}}.Validate(), ErrInvalidImportOptions)
+}
+
+func TestImportOptions_Defaults(t *testing.T) {
+	assert.Positive(t, ImportOptions{}.WorkerCount())
+	assert.Equal(t, 3, ImportOptions{Workers: 3}.WorkerCount())
+	assert.Equal(t, DefaultImportBatchSize, ImportOptions{}.BatchLimit())
+	assert.Equal(t, 10, ImportOptions{BatchSize: 10}.BatchLimit())
+}
+
+func TestImportOptions_Check(t *testing.T) {
+	report := NewImportReport()
+	report.Pending = 95
+	for i := 0; i < 5; i++ {
+		report.Reject(ImportRejection{PortID: "BAD"})
+	}
+
+	assert.NoError(t, ImportOptions{}.Check(report))
+	assert.ErrorIs(t, ImportOptions{Mode: ImportStrict}.Check(report), ErrImportRejected)
+	assert.NoError(t, ImportOptions{Mode: ImportThreshold, MaxRejectedPercent: 5}.Check(report))
+	assert.ErrorIs(t, ImportOptions{Mode: ImportThreshold, MaxRejectedPercent: 4.9}.Check(report), ErrImportRejected)
+
+	// Empty imports never exceed the threshold
+	assert.NoError(t, ImportOptions{Mode: ImportThreshold}.Check(NewImportReport()))
+}
@@ -1,8 +1,20 @@
 package domain
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"slices"
+	"time"
+)
+
+var (
+	// ErrInvalidPort is returned when port data fails validation
+	ErrInvalidPort = errors.New("invalid port")
+
+	// ErrPortNotFound is returned when an operation targets a port that does not exist
+	ErrPortNotFound = errors.New("port not found")
 )
 
 // Coordinate represents a geographical coordinate
@@ -13,10 +25,10 @@ type Coordinate struct {
 
 // NewCoordinate creates a new coordinate with validation
 func NewCoordinate(longitude, latitude float64) (*Coordinate, error) {
-	if longitude < -180 || longitude > 180 {
+	if math.IsNaN(longitude) || longitude < -180 || longitude > 180 {
 		return nil, errors.New("longitude must be between -180 and 180")
 	}
-	if latitude < -90 || latitude > 90 {
+	if math.IsNaN(latitude) || latitude < -90 || latitude > 90 {
 		return nil, errors.New("latitude must be between -90 and 90")
 	}
 	return &Coordinate{
@@ -30,21 +42,75 @@ func (c *Coordinate) String() string {
 	return fmt.Sprintf("[%.6f, %.6f]", c.Longitude, c.Latitude)
 }
 
+// MarshalJSON encodes the coordinate as a [longitude, latitude] pair
+func (c Coordinate) MarshalJSON() ([]byte, error) {
+	return json.Marshal([2]float64{c.Longitude, c.Latitude})
+}
+
+// UnmarshalJSON decodes a [longitude, latitude] pair with validation
+func (c *Coordinate) UnmarshalJSON(data []byte) error {
+	var pair []float64
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return fmt.Errorf("invalid coordinates: %w", err)
+	}
+	if len(pair) != 2 {
+		return errors.New("coordinates must contain exactly longitude and latitude")
+	}
+	coordinate, err := NewCoordinate(pair[0], pair[1])
+	if err != nil {
+		return fmt.Errorf("invalid coordinates: %w", err)
+	}
+	*c = *coordinate
+	return nil
+}
+
 // Port represents a port entity in the domain
 type Port struct {
 	ID          string      `json:"id"`
 	Name        string      `json:"name"`
 	City        string      `json:"city"`
 	Country     string      `json:"country"`
-	Coordinates *Coordinate `json:"-"`
+	Alias       []string    `json:"alias"`
+	Regions     []string    `json:"regions"`
+	Coordinates *Coordinate `json:"coordinates"`
 	Province    string      `json:"province"`
 	Timezone    string      `json:"timezone"`
 	Unlocs      []string    `json:"unlocs"`
 	Code        string      `json:"code"`
+
+	// Functions lists the kinds of transport the location provides
+	Functions []PortFunction `json:"functions,omitempty"`
+
+	// DeletedAt is set when the port has been soft-deleted
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// PortOption sets an optional field when creating a Port
+type PortOption func(*Port)
+
+// WithAlias sets the alternative names the port is known by
+func WithAlias(alias ...string) PortOption {
+	return func(p *Port) {
+		p.Alias = alias
+	}
+}
+
+// WithRegions sets the regions the port belongs to
+func WithRegions(regions ...string) PortOption {
+	return func(p *Port) {
+		p.Regions = regions
+	}
+}
+
+// WithFunctions sets the kinds of transport the port provides
+func WithFunctions(functions ...PortFunction) PortOption {
+	return func(p *Port) {
+		p.Functions = functions
+	}
 }
 
 // NewPort creates a new Port with validation
-func NewPort(id, name, city, country string, coords []float64, province, timezone string, unlocs []string, code string) (*Port, error) {
+func NewPort(id, name, city, country string, coords []float64, province, timezone string, unlocs []string, code string, opts ...PortOption) (*Port, error) {
 	if id == "" {
 		return nil, errors.New("port ID cannot be empty")
 	}
@@ -60,7 +126,7 @@ func NewPort(id, name, city, country string, coords []float64, province, timezon
 		return nil, fmt.Errorf("invalid coordinates: %w", err)
 	}
 
-	return &Port{
+	port := &Port{
 		ID:          id,
 		Name:        name,
 		City:        city,
@@ -70,7 +136,11 @@ func NewPort(id, name, city, country string, coords []float64, province, timezon
 		Timezone:    timezone,
 		Unlocs:      unlocs,
 		Code:        code,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(port)
+	}
+	return port, nil
 }
 
 // Validate performs domain validation on the port
@@ -84,9 +154,70 @@ func (p *Port) Validate() error {
 	if p.Coordinates == nil {
 		return errors.New("port must have coordinates")
 	}
+	for _, function := range p.Functions {
+		if !function.Valid() {
+			return fmt.Errorf("unknown port function %q", function)
+		}
+	}
 	return nil
 }
 
+// Clone returns a deep copy of the port
+func (p *Port) Clone() *Port {
+	clone := *p
+	if p.Coordinates != nil {
+		coordinates := *p.Coordinates
+		clone.Coordinates = &coordinates
+	}
+	if p.Alias != nil {
+		clone.Alias = append([]string(nil), p.Alias...)
+	}
+	if p.Regions != nil {
+		clone.Regions = append([]string(nil), p.Regions...)
+	}
+	if p.Unlocs != nil {
+		clone.Unlocs = append([]string(nil), p.Unlocs...)
+	}
+	if p.Functions != nil {
+		clone.Functions = append([]PortFunction(nil), p.Functions...)
+	}
+	if p.DeletedAt != nil {
+		deletedAt := *p.DeletedAt
+		clone.DeletedAt = &deletedAt
+	}
+	return &clone
+}
+
+// IsDeleted reports whether the port has been soft-deleted
+func (p *Port) IsDeleted() bool {
+	return p.DeletedAt != nil
+}
+
+// Equal reports whether two ports hold the same data, ignoring deletion state.
+// Nil and empty slices are considered equal.
+func (p *Port) Equal(other *Port) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+	if (p.Coordinates == nil) != (other.Coordinates == nil) {
+		return false
+	}
+	if p.Coordinates != nil && *p.Coordinates != *other.Coordinates {
+		return false
+	}
+	return p.ID == other.ID &&
+		p.Name == other.Name &&
+		p.City == other.City &&
+		p.Country == other.Country &&
+		p.Province == other.Province &&
+		p.Timezone == other.Timezone &&
+		p.Code == other.Code &&
+		slices.Equal(p.Alias, other.Alias) &&
+		slices.Equal(p.Regions, other.Regions) &&
+		slices.Equal(p.Unlocs, other.Unlocs) &&
+		slices.Equal(p.Functions, other.Functions)
+}
+
 // String returns a string representation of the port
 func (p *Port) String() string {
 	return fmt.Sprintf("Port{ID: %s, Name: %s, Location: %s, %s}",
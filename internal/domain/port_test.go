@@ -1,7 +1,9 @@
 package domain
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -179,6 +181,16 @@ func TestPort_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "unknown function",
+			port: &Port{
+				ID:          "AEAJM",
+				Name:        "Ajman",
+				Coordinates: validCoord,
+				Functions:   []PortFunction{FunctionPort, "ferry"},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -246,4 +258,80 @@ func TestPort_Equal(t *testing.T) {
 
 	assert.NotEqual(t, port1.ID, port4.ID)
 	assert.NotEqual(t, port1.Coordinates, port4.Coordinates)
+
+	assert.True(t, port1.Equal(port2))
+	assert.False(t, port1.Equal(port3))
+	assert.False(t, port1.Equal(port4))
+	assert.False(t, port1.Equal(nil))
+
+	// Nil and empty slices hold the same data, deletion state is ignored
+	clone := port1.Clone()
+	clone.Alias = []string{}
+	deletedAt := time.Now()
+	clone.DeletedAt = &deletedAt
+	assert.True(t, port1.Equal(clone))
+
+	clone.Functions = []PortFunction{FunctionPort}
+	assert.False(t, port1.Equal(clone))
+
+	clone.Coordinates = nil
+	assert.False(t, port1.Equal(clone))
+}
+
+func TestPort_JSON(t *testing.T) {
+	coords := []float64{55.5136433, 25.4052165}
+	port, err := NewPort("AEAJM", "Ajman", "Ajman", "United Arab Emirates", coords, "Ajman", "Asia/Dubai", []string{"AEAJM"}, "52000")
+	assert.NoError(t, err)
+
+	data, err := json.Marshal(port)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"coordinates":[55.5136433,25.4052165]`)
+
+	var decoded Port
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, *port, decoded)
+
+	// Invalid coordinates are rejected when decoding
+	err = json.Unmarshal([]byte(`{"id": "X", "coordinates": [181, 0]}`), &decoded)
+	assert.Error(t, err)
+	err = json.Unmarshal([]byte(`{"id": "X", "coordinates": [1]}`), &decoded)
+	assert.Error(t, err)
+}
+
+func TestPort_Clone(t *testing.T) {
+	coords := []float64{55.5136433, 25.4052165}
+	port, err := NewPort("TEST1", "Test Port", "Test City", "Test Country", coords, "", "UTC", []string{"TEST1"}, "")
+	assert.NoError(t, err)
+
+	port.Functions = []PortFunction{FunctionPort, FunctionRail}
+
+	clone := port.Clone()
+	assert.Equal(t, port, clone)
+
+	// Mutating the clone leaves the original untouched
+	clone.Unlocs[0] = "CHANGED"
+	clone.Functions[0] = FunctionAirport
+	clone.Coordinates.Latitude = 0
+	assert.Equal(t, "TEST1", port.Unlocs[0])
+	assert.Equal(t, FunctionPort, port.Functions[0])
+	assert.Equal(t, 25.4052165, port.Coordinates.Latitude)
+}
+
+func TestNewPort_Options(t *testing.T) {
+	coords := []float64{55.5136433, 25.4052165}
+	port, err := NewPort("AEDXB", "Dubai", "Dubai", "United Arab Emirates", coords, "", "", nil, "",
+		WithAlias("Port Rashid"), WithRegions("Middle East", "Persian Gulf"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Port Rashid"}, port.Alias)
+	assert.Equal(t, []string{"Middle East", "Persian Gulf"}, port.Regions)
+
+	// Options are round-tripped through JSON and clones
+	data, err := json.Marshal(port)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"alias":["Port Rashid"]`)
+	assert.Contains(t, string(data), `"regions":["Middle East","Persian Gulf"]`)
+
+	clone := port.Clone()
+	clone.Alias[0] = "Changed"
+	assert.Equal(t, "Port Rashid", port.Alias[0])
 }
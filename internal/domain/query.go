@@ -0,0 +1,129 @@
+package domain
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+const (
+	// DefaultPageLimit is the page size used when no limit is requested
+	DefaultPageLimit = 100
+
+	// MaxPageLimit is the largest page size a single list call returns
+	MaxPageLimit = 1000
+)
+
+// cursorPrefix versions the cursor encoding so it can evolve compatibly
+const cursorPrefix = "v1:"
+
+var (
+	// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	// ErrInvalidQuery is returned when query parameters are out of range
+	ErrInvalidQuery = errors.New("invalid query")
+)
+
+// PortFilter restricts which ports are returned by list queries. Each
+// non-empty set matches ports whose field equals any of its values, and all
+// non-empty sets must match. Comparisons ignore case and surrounding spaces.
+type PortFilter struct {
+	Countries []string `json:"countries,omitempty"`
+	Provinces []string `json:"provinces,omitempty"`
+	Timezones []string `json:"timezones,omitempty"`
+	Codes     []string `json:"codes,omitempty"`
+
+	// Unlocs matches ports listing any of the given UN/LOCODEs
+	Unlocs []string `json:"unlocs,omitempty"`
+
+	// Regions matches ports belonging to any of the given regions
+	Regions []string `json:"regions,omitempty"`
+
+	// IncludeDeleted also returns soft-deleted ports
+	IncludeDeleted bool `json:"include_deleted,omitempty"`
+}
+
+// HasFieldCriteria reports whether the filter restricts any port field
+func (f PortFilter) HasFieldCriteria() bool {
+	return len(f.Countries) > 0 || len(f.Provinces) > 0 || len(f.Timezones) > 0 ||
+		len(f.Codes) > 0 || len(f.Unlocs) > 0 || len(f.Regions) > 0
+}
+
+// Matches reports whether the port satisfies the filter
+func (f PortFilter) Matches(p *Port) bool {
+	if p.IsDeleted() && !f.IncludeDeleted {
+		return false
+	}
+	return matchesAny(f.Countries, p.Country) &&
+		matchesAny(f.Provinces, p.Province) &&
+		matchesAny(f.Timezones, p.Timezone) &&
+		matchesAny(f.Codes, p.Code) &&
+		matchesAny(f.Unlocs, p.Unlocs...) &&
+		matchesAny(f.Regions, p.Regions...)
+}
+
+// NormalizeFilterValue returns the canonical form used to compare filter values
+func NormalizeFilterValue(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+// matchesAny reports whether the set is empty or contains one of the values
+func matchesAny(set []string, values ...string) bool {
+	if len(set) == 0 {
+		return true
+	}
+	for _, want := range set {
+		want = NormalizeFilterValue(want)
+		for _, value := range values {
+			if NormalizeFilterValue(value) == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PortPage is a single page of ports ordered by port ID
+type PortPage struct {
+	Ports []*Port `json:"ports"`
+
+	// NextCursor resumes listing after the last port of this page and is
+	// empty when there are no more ports
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NormalizeLimit clamps a requested page size to the supported range
+func NormalizeLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultPageLimit
+	}
+	if limit > MaxPageLimit {
+		return MaxPageLimit
+	}
+	return limit
+}
+
+// EncodeCursor returns an opaque cursor positioned after the given port ID.
+// Because cursors encode a position in ID order rather than an offset, they
+// remain valid while ports are concurrently added or removed.
+func EncodeCursor(lastID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + lastID))
+}
+
+// DecodeCursor returns the port ID encoded in a cursor. An empty cursor
+// decodes to an empty ID, meaning the start of the listing.
+func DecodeCursor(cursor string) (string, error) {
+	if cursor == "" {
+		return "", nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", ErrInvalidCursor
+	}
+	lastID, ok := strings.CutPrefix(string(raw), cursorPrefix)
+	if !ok || lastID == "" {
+		return "", ErrInvalidCursor
+	}
+	return lastID, nil
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	for _, id := range []string{"AEAJM", "PORT:1", "ÅLESUND"} {
+		cursor := EncodeCursor(id)
+		decoded, err := DecodeCursor(cursor)
+		assert.NoError(t, err)
+		assert.Equal(t, id, decoded)
+	}
+
+	// Empty cursor starts from the beginning
+	decoded, err := DecodeCursor("")
+	assert.NoError(t, err)
+	assert.Empty(t, decoded)
+
+	// Malformed cursors are rejected
+	for _, cursor := range []string{"!!!", "QUVBSk0", EncodeCursor("")} {
+		_, err := DecodeCursor(cursor)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	}
+}
+
+func TestNormalizeLimit(t *testing.T) {
+	assert.Equal(t, DefaultPageLimit, NormalizeLimit(0))
+	assert.Equal(t, DefaultPageLimit, NormalizeLimit(-5))
+	assert.Equal(t, 10, NormalizeLimit(10))
+	assert.Equal(t, MaxPageLimit, NormalizeLimit(MaxPageLimit+1))
+}
+
+func TestPortFilter_Matches(t *testing.T) {
+	port, err := NewPort("NOOSL", "Oslo", "Oslo", "Norway", []float64{10.75, 59.91}, "Oslo", "Europe/Oslo", []string{"NOOSL", "NOFRK"}, "40300",
+		WithRegions("Scandinavia"))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		filter PortFilter
+		want   bool
+	}{
+		{name: "empty filter", filter: PortFilter{}, want: true},
+		{name: "country equality", filter: PortFilter{Countries: []string{"Norway"}}, want: true},
+		{name: "country case-insensitive", filter: PortFilter{Countries: []string{" norway "}}, want: true},
+		{name: "country set", filter: PortFilter{Countries: []string{"Sweden", "Norway"}}, want: true},
+		{name: "country mismatch", filter: PortFilter{Countries: []string{"Sweden"}}, want: false},
+		{name: "unloc membership", filter: PortFilter{Unlocs: []string{"NOFRK"}}, want: true},
+		{name: "combined criteria", filter: PortFilter{Countries: []string{"Norway"}, Timezones: []string{"Asia/Dubai"}}, want: false},
+		{name: "code and province", filter: PortFilter{Codes: []string{"40300"}, Provinces: []string{"Oslo"}}, want: true},
+		{name: "region membership", filter: PortFilter{Regions: []string{"scandinavia"}}, want: true},
+		{name: "region mismatch", filter: PortFilter{Regions: []string{"Baltic"}}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.Matches(port))
+		})
+	}
+
+	// Deleted ports only match when requested
+	deletedAt := time.Now()
+	port.DeletedAt = &deletedAt
+	assert.False(t, PortFilter{}.Matches(port))
+	assert.True(t, PortFilter{IncludeDeleted: true}.Matches(port))
+}
@@ -0,0 +1,204 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Match scores for a single query term against an indexed token
+const (
+	exactMatchScore  = 1.0
+	prefixMatchScore = 0.8
+	fuzzyMatchScore  = 0.6
+)
+
+// SearchResult pairs a port with its relevance score for a text query
+type SearchResult struct {
+	Port  *Port   `json:"port"`
+	Score float64 `json:"score"`
+}
+
+// SearchField is a piece of port text indexed for search with a relevance weight
+type SearchField struct {
+	Text   string
+	Weight float64
+}
+
+// SearchFields returns the port text covered by full-text search
+func (p *Port) SearchFields() []SearchField {
+	fields := []SearchField{
+		{Text: p.Name, Weight: 3},
+		{Text: p.City, Weight: 2},
+		{Text: p.Province, Weight: 1},
+	}
+	for _, alias := range p.Alias {
+		fields = append(fields, SearchField{Text: alias, Weight: 2})
+	}
+	return fields
+}
+
+// SearchTokens returns the distinct folded tokens of every searchable field
+func (p *Port) SearchTokens() []string {
+	seen := make(map[string]struct{})
+	var tokens []string
+	for _, field := range p.SearchFields() {
+		for _, token := range Tokenize(field.Text) {
+			if _, ok := seen[token]; !ok {
+				seen[token] = struct{}{}
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	return tokens
+}
+
+// foldTransformer strips diacritics by decomposing characters and dropping
+// combining marks and spacing modifiers such as the cedilla in "Z¸aby"
+var foldTransformer = transform.Chain(
+	norm.NFD,
+	runes.Remove(runes.Predicate(func(r rune) bool {
+		return unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Sk, r)
+	})),
+	norm.NFC,
+)
+
+// FoldText lower-cases text and strips diacritics
+func FoldText(text string) string {
+	folded, _, err := transform.String(foldTransformer, text)
+	if err != nil {
+		folded = text
+	}
+	return strings.ToLower(folded)
+}
+
+// Tokenize folds text and splits it into letter and digit runs
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(FoldText(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// MaxEditDistance returns the number of typos tolerated for a query term;
+// short terms must match exactly or by prefix
+func MaxEditDistance(term string) int {
+	switch n := len([]rune(term)); {
+	case n <= 3:
+		return 0
+	case n <= 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// TermMatchScore rates how well a folded query term matches an indexed
+// token: exact matches score highest, then prefixes, then matches within
+// MaxEditDistance typos. It returns 0 when the token does not match.
+func TermMatchScore(term, token string) float64 {
+	if term == token {
+		return exactMatchScore
+	}
+	if strings.HasPrefix(token, term) {
+		// Prefer tokens that are completed by fewer characters
+		return prefixMatchScore * (0.5 + 0.5*float64(len(term))/float64(len(token)))
+	}
+	maxDistance := MaxEditDistance(term)
+	if maxDistance == 0 {
+		return 0
+	}
+	distance := EditDistance(term, token, maxDistance)
+	if distance > maxDistance {
+		return 0
+	}
+	return fuzzyMatchScore / float64(distance)
+}
+
+// EditDistance returns the optimal string alignment distance between a and
+// b, counting insertions, deletions, substitutions and adjacent
+// transpositions. Computation stops early once the distance exceeds max, in
+// which case max+1 is returned.
+func EditDistance(a, b string, max int) int {
+	ra, rb := []rune(a), []rune(b)
+	if diff := len(ra) - len(rb); diff > max || -diff > max {
+		return max + 1
+	}
+
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return min(prev[len(rb)], max+1)
+}
+
+// ScoreSearch rates how relevant a port is to the tokenized query terms. Each
+// term contributes its best weighted match across the searchable fields, and
+// the total is scaled by the fraction of terms that matched so ports matching
+// every term rank first. It returns 0 when no term matches.
+func ScoreSearch(terms []string, p *Port) float64 {
+	if len(terms) == 0 {
+		return 0
+	}
+	fields := p.SearchFields()
+	fieldTokens := make([][]string, len(fields))
+	for i, field := range fields {
+		fieldTokens[i] = Tokenize(field.Text)
+	}
+
+	total := 0.0
+	matched := 0
+	for _, term := range terms {
+		best := 0.0
+		for i, field := range fields {
+			for _, token := range fieldTokens[i] {
+				if score := TermMatchScore(term, token) * field.Weight; score > best {
+					best = score
+				}
+			}
+		}
+		if best > 0 {
+			total += best
+			matched++
+		}
+	}
+	return total * float64(matched) / float64(len(terms))
+}
+
+// SortSearchResults orders results by descending score, breaking ties by
+// port name and then ID
+func SortSearchResults(results []SearchResult) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].Port.Name != results[j].Port.Name {
+			return results[i].Port.Name < results[j].Port.Name
+		}
+		return results[i].Port.ID < results[j].Port.ID
+	})
+}
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "Abu Dhabi", want: []string{"abu", "dhabi"}},
+		{text: "Abu Z¸aby [Abu Dhabi]", want: []string{"abu", "zaby", "abu", "dhabi"}},
+		{text: "Ålesund", want: []string{"alesund"}},
+		{text: "São Paulo-Guarujá", want: []string{"sao", "paulo", "guaruja"}},
+		{text: "  ", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got := Tokenize(tt.text)
+			if len(tt.want) == 0 {
+				assert.Empty(t, got)
+			} else {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, EditDistance("dubai", "dubai", 2))
+	assert.Equal(t, 1, EditDistance("dubay", "dubai", 2))
+	assert.Equal(t, 1, EditDistance("dabi", "dhabi", 2))
+	assert.Equal(t, 1, EditDistance("ajamn", "ajman", 2))
+	assert.Equal(t, 2, EditDistance("rotterdm", "roterdam", 2))
+	assert.Equal(t, 3, EditDistance("oslo", "singapore", 2))
+	assert.Equal(t, 2, EditDistance("", "ab", 2))
+}
+
+func TestTermMatchScore(t *testing.T) {
+	assert.Equal(t, 1.0, TermMatchScore("dubai", "dubai"))
+	assert.Greater(t, TermMatchScore("dub", "dubai"), TermMatchScore("dubay", "dubai"))
+	assert.Greater(t, TermMatchScore("dubay", "dubai"), 0.0)
+	assert.Zero(t, TermMatchScore("abd", "abu"), "short terms need an exact or prefix match")
+	assert.Zero(t, TermMatchScore("oslo", "dubai"))
+}
+
+func TestScoreSearch(t *testing.T) {
+	abuDhabi, _ := NewPort("AEAUH", "Abu Dhabi", "Abu Dhabi", "United Arab Emirates", []float64{54.37, 24.47}, "Abu Z¸aby [Abu Dhabi]", "Asia/Dubai", nil, "")
+	dubai, _ := NewPort("AEDXB", "Dubai", "Dubai", "United Arab Emirates", []float64{55.27, 25.25}, "Dubayy [Dubai]", "Asia/Dubai", nil, "")
+	abuMusa, _ := NewPort("AEAMU", "Abu Musa", "Abu Musa", "United Arab Emirates", []float64{55.03, 25.87}, "", "Asia/Dubai", nil, "")
+
+	terms := Tokenize("abu dabi")
+	assert.Greater(t, ScoreSearch(terms, abuDhabi), ScoreSearch(terms, abuMusa))
+	assert.Zero(t, ScoreSearch(terms, dubai))
+
+	terms = Tokenize("dubay")
+	assert.Greater(t, ScoreSearch(terms, dubai), 0.0)
+	assert.Zero(t, ScoreSearch(terms, abuDhabi))
+
+	assert.Zero(t, ScoreSearch(nil, dubai))
+}
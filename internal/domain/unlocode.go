@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PortFunction classifies the transport a location provides, as listed in
+// the function column of the UN/LOCODE code list
+type PortFunction string
+
+// Port functions, one per UN/LOCODE classifier position
+const (
+	FunctionPort       PortFunction = "port"
+	FunctionRail       PortFunction = "rail"
+	FunctionRoad       PortFunction = "road"
+	FunctionAirport    PortFunction = "airport"
+	FunctionPostal     PortFunction = "postal"
+	FunctionMultimodal PortFunction = "multimodal"
+	FunctionFixed      PortFunction = "fixed"
+	FunctionBorder     PortFunction = "border"
+)
+
+// functionClassifier lists the functions in the order of the UN/LOCODE
+// classifier positions, each set by the character shown
+var functionClassifier = []struct {
+	flag     byte
+	function PortFunction
+}{
+	{'1', FunctionPort},
+	{'2', FunctionRail},
+	{'3', FunctionRoad},
+	{'4', FunctionAirport},
+	{'5', FunctionPostal},
+	{'6', FunctionMultimodal},
+	{'7', FunctionFixed},
+	{'B', FunctionBorder},
+}
+
+// ParsePortFunction parses a port function name
+func ParsePortFunction(name string) (PortFunction, error) {
+	if function := PortFunction(NormalizeFilterValue(name)); function.Valid() {
+		return function, nil
+	}
+	return "", fmt.Errorf("unknown port function %q", name)
+}
+
+// Valid reports whether f is one of the known port functions
+func (f PortFunction) Valid() bool {
+	for _, c := range functionClassifier {
+		if c.function == f {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseFunctionClassifier parses the eight-character UN/LOCODE function
+// classifier, such as "1-3-----", into the functions it sets. A classifier
+// of "0" marks the functions as unknown and yields none.
+func ParseFunctionClassifier(classifier string) ([]PortFunction, error) {
+	functions := make([]PortFunction, 0)
+	if classifier == "" || strings.HasPrefix(classifier, "0") {
+		return functions, nil
+	}
+	if len(classifier) != len(functionClassifier) {
+		return nil, fmt.Errorf("function classifier %q must have %d characters", classifier, len(functionClassifier))
+	}
+	for i, c := range functionClassifier {
+		switch classifier[i] {
+		case c.flag:
+			functions = append(functions, c.function)
+		case '-':
+		default:
+			return nil, fmt.Errorf("invalid function classifier %q", classifier)
+		}
+	}
+	return functions, nil
+}
+
+// ParseDegreeMinutes parses UN/LOCODE coordinates of the form "DDMMN DDDMMW"
+// into a coordinate
+func ParseDegreeMinutes(value string) (*Coordinate, error) {
+	lat, lon, ok := strings.Cut(strings.TrimSpace(value), " ")
+	if !ok {
+		return nil, fmt.Errorf("coordinates %q must be latitude and longitude", value)
+	}
+	latitude, err := parseDegreeMinutes(lat, 2, 'N', 'S')
+	if err != nil {
+		return nil, fmt.Errorf("invalid latitude: %w", err)
+	}
+	longitude, err := parseDegreeMinutes(strings.TrimSpace(lon), 3, 'E', 'W')
+	if err != nil {
+		return nil, fmt.Errorf("invalid longitude: %w", err)
+	}
+	return NewCoordinate(longitude, latitude)
+}
+
+// parseDegreeMinutes parses an angle written as degreeDigits digits of
+// degrees, two digits of minutes and a hemisphere letter
+func parseDegreeMinutes(value string, degreeDigits int, positive, negative byte) (float64, error) {
+	if len(value) != degreeDigits+3 {
+		return 0, fmt.Errorf("%q must have %d digits and a hemisphere", value, degreeDigits+2)
+	}
+	degrees, err := strconv.ParseUint(value[:degreeDigits], 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid degrees in %q", value)
+	}
+	minutes, err := strconv.ParseUint(value[degreeDigits:degreeDigits+2], 10, 8)
+	if err != nil || minutes >= 60 {
+		return 0, fmt.Errorf("invalid minutes in %q", value)
+	}
+
+	angle := float64(degrees) + float64(minutes)/60
+	switch value[degreeDigits+2] {
+	case positive:
+		return angle, nil
+	case negative:
+		return -angle, nil
+	default:
+		return 0, fmt.Errorf("invalid hemisphere in %q", value)
+	}
+}
@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFunctionClassifier(t *testing.T) {
+	functions, err := ParseFunctionClassifier("1-3-----")
+	assert.NoError(t, err)
+	assert.Equal(t, []PortFunction{FunctionPort, FunctionRoad}, functions)
+
+	functions, err = ParseFunctionClassifier("1234567B")
+	assert.NoError(t, err)
+	assert.Len(t, functions, 8)
+	assert.Equal(t, FunctionBorder, functions[7])
+
+	functions, err = ParseFunctionClassifier("0-------")
+	assert.NoError(t, err)
+	assert.Empty(t, functions)
+
+	for _, classifier := range []string{"1-3", "2-------", "1-3----X"} {
+		_, err = ParseFunctionClassifier(classifier)
+		assert.Error(t, err, classifier)
+	}
+}
+
+func TestParsePortFunction(t *testing.T) {
+	function, err := ParsePortFunction(" Airport ")
+	assert.NoError(t, err)
+	assert.Equal(t, FunctionAirport, function)
+
+	_, err = ParsePortFunction("ferry")
+	assert.Error(t, err)
+
+	assert.True(t, FunctionBorder.Valid())
+	assert.False(t, PortFunction("Port").Valid())
+}
+
+func TestParseDegreeMinutes(t *testing.T) {
+	coordinate, err := ParseDegreeMinutes("2524N 05530E")
+	assert.NoError(t, err)
+	assert.InDelta(t, 25.4, coordinate.Latitude, 1e-9)
+	assert.InDelta(t, 55.5, coordinate.Longitude, 1e-9)
+
+	coordinate, err = ParseDegreeMinutes("3352S 15112W")
+	assert.NoError(t, err)
+	assert.InDelta(t, -33.8666667, coordinate.Latitude, 1e-6)
+	assert.InDelta(t, -151.2, coordinate.Longitude, 1e-9)
+
+	for _, value := range []string{"", "2524N", "2524N 0553E", "2560N 05530E", "2524X 05530E", "9500N 05530E", "2524N 18100E", "25a4N 05530E"} {
+		_, err = ParseDegreeMinutes(value)
+		assert.Error(t, err, value)
+	}
+}
@@ -2,6 +2,7 @@ package in
 
 import (
 	"context"
+	"io"
 
 	"portservice/internal/domain"
 )
@@ -11,9 +12,52 @@ type PortService interface {
 	// CreateOrUpdatePort creates a new port or updates an existing one
 	CreateOrUpdatePort(ctx context.Context, port *domain.Port) error
 
+	// CreateOrUpdatePorts creates or updates a batch of ports, reporting the
+	// outcome of each. Invalid ports are skipped while the rest are saved.
+	CreateOrUpdatePorts(ctx context.Context, ports []*domain.Port) (domain.BatchResult, error)
+
 	// GetPort retrieves a port by its ID
 	GetPort(ctx context.Context, id string) (*domain.Port, error)
 
-	// ProcessPortsFile processes a JSON file containing port data
-	ProcessPortsFile(ctx context.Context, filePath string) error
+	// GetPortIncludingDeleted retrieves a port by its ID, including soft-deleted ports
+	GetPortIncludingDeleted(ctx context.Context, id string) (*domain.Port, error)
+
+	// DeletePort removes a port by its ID
+	DeletePort(ctx context.Context, id string) error
+
+	// ListPorts returns a page of ports matching the filter, ordered by port ID.
+	// Pass the returned NextCursor to fetch the following page.
+	ListPorts(ctx context.Context, filter domain.PortFilter, cursor string, limit int) (domain.PortPage, error)
+
+	// FindNearest returns the k ports closest to center by great-circle
+	// distance, nearest first
+	FindNearest(ctx context.Context, center domain.Coordinate, k int) ([]domain.PortDistance, error)
+
+	// FindWithinRadius returns the ports within radiusKm of center by
+	// great-circle distance, nearest first
+	FindWithinRadius(ctx context.Context, center domain.Coordinate, radiusKm float64) ([]domain.PortDistance, error)
+
+	// FindInBoundingBox returns the ports inside the box, ordered by port ID.
+	// Boxes with MinLongitude greater than MaxLongitude cross the antimeridian.
+	FindInBoundingBox(ctx context.Context, box domain.BoundingBox) ([]*domain.Port, error)
+
+	// FindInPolygon returns the ports inside the polygon, ordered by port ID
+	FindInPolygon(ctx context.Context, polygon domain.Polygon) ([]*domain.Port, error)
+
+	// SearchPorts returns up to limit ports whose name, city, province or alias match
+	// the free-text query, tolerating accents, prefixes and typos, most
+	// relevant first
+	SearchPorts(ctx context.Context, query string, limit int) ([]domain.SearchResult, error)
+
+	// ProcessPorts imports port data streamed from r in the options' format and
+	// reports which records were created, updated, unchanged or rejected. The
+	// options choose whether invalid records are skipped or fail the import.
+	ProcessPorts(ctx context.Context, r io.Reader, opts domain.ImportOptions) (*domain.ImportReport, error)
+
+	// ProcessPortsFile imports a file containing port data like ProcessPorts
+	ProcessPortsFile(ctx context.Context, filePath string, opts domain.ImportOptions) (*domain.ImportReport, error)
+
+	// ExportPorts streams the ports matching the options' filter to w in the
+	// chosen format, ordered by port ID, and returns how many were written
+	ExportPorts(ctx context.Context, w io.Writer, opts domain.ExportOptions) (int, error)
 }
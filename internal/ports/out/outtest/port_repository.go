@@ -0,0 +1,463 @@
+// Package outtest checks implementations of the outbound ports against the
+// behaviour the core relies on, so every adapter is held to the same
+// contract
+package outtest
+
+import (
+	"context"
+	"testing"
+
+	"portservice/internal/domain"
+	"portservice/internal/ports/out"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Storage prepares empty storage for one test and returns a function that
+// opens repositories over it. Repositories are closed when the test ends.
+type Storage func(t *testing.T) Opener
+
+// Opener opens a repository over the storage, tombstoning deleted ports
+// instead of removing them if softDelete is set
+type Opener func(softDelete bool) out.PortRepository
+
+// NewPort returns a valid port with the given ID and name and fixed other
+// fields
+func NewPort(id, name string) *domain.Port {
+	port, _ := domain.NewPort(id, name, "Test City", "Test Country", []float64{55.5136433, 25.4052165}, "", "UTC", []string{id}, "")
+	return port
+}
+
+// PortIDs returns the IDs of ports in order
+func PortIDs(ports []*domain.Port) []string {
+	ids := make([]string, len(ports))
+	for i, port := range ports {
+		ids[i] = port.ID
+	}
+	return ids
+}
+
+// TestPortRepository runs the out.PortRepository contract as subtests, each
+// against a repository opened over fresh storage
+func TestPortRepository(t *testing.T, storage Storage) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, open Opener)
+	}{
+		{"SaveAndGet", testSaveAndGet},
+		{"SavePorts", testSavePorts},
+		{"Delete", testDelete},
+		{"SoftDelete", testSoftDelete},
+		{"ListPorts", testListPorts},
+		{"ListPorts_Filter", testListPortsFilter},
+		{"Statistics", testStatistics},
+		{"Transaction", testTransaction},
+		{"ContextCancellation", testContextCancellation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, storage(t))
+		})
+	}
+}
+
+// TestPersistence checks that ports and tombstones survive closing a
+// repository and opening its storage again
+func TestPersistence(t *testing.T, storage Storage) {
+	open := storage(t)
+	ctx := context.Background()
+
+	repo := open(true)
+	assert.NoError(t, repo.SavePort(ctx, NewPort("AEAJM", "Ajman")))
+	assert.NoError(t, repo.SavePort(ctx, NewPort("AEAJM", "Ajman Port")))
+	assert.NoError(t, repo.SavePort(ctx, NewPort("AEAUH", "Abu Dhabi")))
+	assert.NoError(t, repo.DeletePort(ctx, "AEAUH"))
+	deleted, err := repo.GetPortIncludingDeleted(ctx, "AEAUH")
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Close(ctx))
+
+	reopened := open(true)
+	assert.Equal(t, int64(1), reopened.GetStatistics().TotalPorts)
+	port, err := reopened.GetPort(ctx, "AEAJM")
+	assert.NoError(t, err)
+	assert.Equal(t, NewPort("AEAJM", "Ajman Port"), port)
+
+	// Tombstones keep their deletion time
+	port, err = reopened.GetPortIncludingDeleted(ctx, "AEAUH")
+	assert.NoError(t, err)
+	if assert.NotNil(t, port) && assert.NotNil(t, port.DeletedAt) && assert.NotNil(t, deleted) {
+		assert.True(t, deleted.DeletedAt.Equal(*port.DeletedAt))
+	}
+}
+
+// TestPersistentStatistics checks that the statistics counters survive
+// closing a repository and opening its storage again, for backends that
+// store them rather than recount them on startup
+func TestPersistentStatistics(t *testing.T, storage Storage) {
+	open := storage(t)
+	ctx := context.Background()
+
+	repo := open(false)
+	assert.NoError(t, repo.SavePort(ctx, NewPort("AEAJM", "Ajman")))
+	assert.NoError(t, repo.SavePort(ctx, NewPort("AEAJM", "Ajman Port")))
+	assert.NoError(t, repo.SavePort(ctx, NewPort("AEAUH", "Abu Dhabi")))
+	assert.NoError(t, repo.DeletePort(ctx, "AEAUH"))
+	stats := repo.GetStatistics()
+	assert.NoError(t, repo.Close(ctx))
+
+	assert.Equal(t, stats, open(false).GetStatistics())
+}
+
+func testSaveAndGet(t *testing.T, open Opener) {
+	repo := open(false)
+	ctx := context.Background()
+
+	port, err := domain.NewPort("AEAJM", "Ajman", "Ajman", "United Arab Emirates",
+		[]float64{55.5136433, 25.4052165}, "Ajman", "Asia/Dubai", []string{"AEAJM", "AEAJX"}, "52000")
+	assert.NoError(t, err)
+	port.Alias = []string{"ajman", "عجمان"}
+	port.Regions = []string{"Gulf"}
+	port.Functions = []domain.PortFunction{domain.FunctionPort, domain.FunctionRail}
+	assert.NoError(t, repo.SavePort(ctx, port))
+
+	retrieved, err := repo.GetPort(ctx, "AEAJM")
+	assert.NoError(t, err)
+	assert.Equal(t, port, retrieved)
+
+	// Changing the saved port afterwards leaves the stored one alone
+	port.Name = "Modified"
+	retrieved, err = repo.GetPort(ctx, "AEAJM")
+	assert.NoError(t, err)
+	assert.Equal(t, "Ajman", retrieved.Name)
+
+	// Updates replace the list fields
+	updated := NewPort("AEAJM", "Ajman Port")
+	assert.NoError(t, repo.SavePort(ctx, updated))
+	retrieved, err = repo.GetPort(ctx, "AEAJM")
+	assert.NoError(t, err)
+	assert.Equal(t, updated, retrieved)
+
+	retrieved, err = repo.GetPort(ctx, "MISSING")
+	assert.NoError(t, err)
+	assert.Nil(t, retrieved)
+
+	assert.Error(t, repo.SavePort(ctx, &domain.Port{ID: "INVALID"}))
+	retrieved, err = repo.GetPortIncludingDeleted(ctx, "INVALID")
+	assert.NoError(t, err)
+	assert.Nil(t, retrieved)
+}
+
+func testSavePorts(t *testing.T, open Opener) {
+	repo := open(false)
+	ctx := context.Background()
+
+	assert.NoError(t, repo.SavePort(ctx, NewPort("AEAJM", "Ajman")))
+	result, err := repo.SavePorts(ctx, []*domain.Port{
+		NewPort("AEAJM", "Ajman Port"), nil, {ID: "INVALID"}, NewPort("AEAUH", "Abu Dhabi"), NewPort("AEAUH", "Abu Dhabi Port"),
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, result.Items, 5) {
+		assert.Equal(t, "AEAJM", result.Items[0].ID)
+		assert.NoError(t, result.Items[0].Err)
+		assert.Error(t, result.Items[1].Err)
+		assert.Equal(t, "INVALID", result.Items[2].ID)
+		assert.Error(t, result.Items[2].Err)
+	}
+	assert.Equal(t, 3, result.Saved())
+	assert.Equal(t, 2, result.Failed())
+
+	// Later ports in the batch win
+	page, err := repo.ListPorts(ctx, domain.PortFilter{}, "", 10)
+	assert.NoError(t, err)
+	if assert.Equal(t, []string{"AEAJM", "AEAUH"}, PortIDs(page.Ports)) {
+		assert.Equal(t, "Ajman Port", page.Ports[0].Name)
+		assert.Equal(t, "Abu Dhabi Port", page.Ports[1].Name)
+	}
+	stats := repo.GetStatistics()
+	assert.Equal(t, int64(2), stats.TotalPorts)
+	assert.Equal(t, int64(4), stats.TotalUpdates)
+}
+
+func testDelete(t *testing.T, open Opener) {
+	repo := open(false)
+	ctx := context.Background()
+
+	assert.NoError(t, repo.SavePort(ctx, NewPort("AEAJM", "Ajman")))
+	assert.NoError(t, repo.DeletePort(ctx, "AEAJM"))
+	assert.ErrorIs(t, repo.DeletePort(ctx, "AEAJM"), domain.ErrPortNotFound)
+	assert.ErrorIs(t, repo.DeletePort(ctx, "MISSING"), domain.ErrPortNotFound)
+
+	// Hard-deleted ports are gone entirely
+	port, err := repo.GetPort(ctx, "AEAJM")
+	assert.NoError(t, err)
+	assert.Nil(t, port)
+	port, err = repo.GetPortIncludingDeleted(ctx, "AEAJM")
+	assert.NoError(t, err)
+	assert.Nil(t, port)
+	page, err := repo.ListPorts(ctx, domain.PortFilter{Countries: []string{"Test Country"}, IncludeDeleted: true}, "", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, page.Ports)
+
+	stats := repo.GetStatistics()
+	assert.Zero(t, stats.TotalPorts)
+	assert.Equal(t, int64(1), stats.TotalDeletes)
+}
+
+func testSoftDelete(t *testing.T, open Opener) {
+	repo := open(true)
+	ctx := context.Background()
+
+	ajman := NewPort("AEAJM", "Ajman")
+	assert.NoError(t, repo.SavePort(ctx, ajman))
+	assert.NoError(t, repo.SavePort(ctx, NewPort("AEAUH", "Abu Dhabi")))
+	assert.NoError(t, repo.DeletePort(ctx, "AEAJM"))
+	assert.ErrorIs(t, repo.DeletePort(ctx, "AEAJM"), domain.ErrPortNotFound)
+	assert.False(t, ajman.IsDeleted(), "caller's port must not be mutated")
+
+	// Tombstoned ports are hidden unless explicitly requested
+	port, err := repo.GetPort(ctx, "AEAJM")
+	assert.NoError(t, err)
+	assert.Nil(t, port)
+	port, err = repo.GetPortIncludingDeleted(ctx, "AEAJM")
+	assert.NoError(t, err)
+	if assert.NotNil(t, port) {
+		assert.True(t, port.IsDeleted())
+	}
+
+	for _, filter := range []domain.PortFilter{{}, {Countries: []string{"Test Country"}}} {
+		page, err := repo.ListPorts(ctx, filter, "", 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"AEAUH"}, PortIDs(page.Ports))
+		filter.IncludeDeleted = true
+		page, err = repo.ListPorts(ctx, filter, "", 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"AEAJM", "AEAUH"}, PortIDs(page.Ports))
+	}
+	stats := repo.GetStatistics()
+	assert.Equal(t, int64(1), stats.TotalPorts)
+	assert.Equal(t, int64(1), stats.TotalDeletes)
+
+	// Saving the port again revives it
+	assert.NoError(t, repo.SavePort(ctx, NewPort("AEAJM", "Ajman")))
+	port, err = repo.GetPort(ctx, "AEAJM")
+	assert.NoError(t, err)
+	if assert.NotNil(t, port) {
+		assert.False(t, port.IsDeleted())
+	}
+	assert.Equal(t, int64(2), repo.GetStatistics().TotalPorts)
+}
+
+func testListPorts(t *testing.T, open Opener) {
+	repo := open(true)
+	ctx := context.Background()
+
+	// IDs sort by byte value whatever the storage collation
+	for _, id := range []string{"bbbbb", "AAAAA", "aaaaa", "BBBBB", "CCCCC", "DDDDD"} {
+		assert.NoError(t, repo.SavePort(ctx, NewPort(id, id)))
+	}
+	assert.NoError(t, repo.DeletePort(ctx, "CCCCC"))
+
+	page, err := repo.ListPorts(ctx, domain.PortFilter{}, "", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"AAAAA", "BBBBB"}, PortIDs(page.Ports))
+	assert.NotEmpty(t, page.NextCursor)
+
+	// Ports saved between pages are listed if they sort after the cursor
+	assert.NoError(t, repo.SavePort(ctx, NewPort("AAAAB", "Early")))
+	assert.NoError(t, repo.SavePort(ctx, NewPort("BBBBC", "Late")))
+
+	page, err = repo.ListPorts(ctx, domain.PortFilter{}, page.NextCursor, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"BBBBC", "DDDDD"}, PortIDs(page.Ports))
+
+	page, err = repo.ListPorts(ctx, domain.PortFilter{}, page.NextCursor, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"aaaaa", "bbbbb"}, PortIDs(page.Ports))
+	if page.NextCursor != "" {
+		page, err = repo.ListPorts(ctx, domain.PortFilter{}, page.NextCursor, 2)
+		assert.NoError(t, err)
+		assert.Empty(t, page.Ports)
+		assert.Empty(t, page.NextCursor)
+	}
+
+	// Deleted ports are listed only on request
+	page, err = repo.ListPorts(ctx, domain.PortFilter{IncludeDeleted: true}, "", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"AAAAA", "AAAAB", "BBBBB", "BBBBC", "CCCCC"}, PortIDs(page.Ports))
+
+	_, err = repo.ListPorts(ctx, domain.PortFilter{}, "not a cursor!", 2)
+	assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+}
+
+func testListPortsFilter(t *testing.T, open Opener) {
+	repo := open(false)
+	ctx := context.Background()
+
+	coords := []float64{55.5136433, 25.4052165}
+	ajman, _ := domain.NewPort("AEAJM", "Ajman", "Ajman", "United Arab Emirates", coords, "Ajman", "Asia/Dubai", []string{"AEAJM"}, "52000")
+	ajman.Regions = []string{"Gulf"}
+	dubai, _ := domain.NewPort("AEDXB", "Dubai", "Dubai", "United Arab Emirates", coords, "Dubai", "Asia/Dubai", []string{"AEDXB", "AEJEA"}, "52005")
+	jebelAli, _ := domain.NewPort("AEJEA", "Jebel Ali", "Dubai", "United Arab Emirates", coords, "Dubai", "Asia/Dubai", []string{"AEJEA"}, "52051")
+	khorFakkan, _ := domain.NewPort("AEKLF", "Khor Fakkan", "", "United Arab Emirates", coords, "", "", nil, "", domain.WithRegions("Gulf of Oman"))
+	rotterdam, _ := domain.NewPort("NLRTM", "Rotterdam", "Rotterdam", "Netherlands", coords, "South Holland", "Europe/Amsterdam", []string{"NLRTM"}, "42157")
+	_, err := repo.SavePorts(ctx, []*domain.Port{ajman, dubai, jebelAli, khorFakkan, rotterdam})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		filter domain.PortFilter
+		want   []string
+	}{
+		{"country ignores case", domain.PortFilter{Countries: []string{"united arab emirates"}}, []string{"AEAJM", "AEDXB", "AEJEA", "AEKLF"}},
+		{"any of several countries", domain.PortFilter{Countries: []string{"Netherlands", "Nowhere"}}, []string{"NLRTM"}},
+		{"repeated country", domain.PortFilter{Countries: []string{"Netherlands", " NETHERLANDS"}}, []string{"NLRTM"}},
+		{"province", domain.PortFilter{Provinces: []string{"Dubai"}}, []string{"AEDXB", "AEJEA"}},
+		{"timezone", domain.PortFilter{Timezones: []string{"Europe/Amsterdam"}}, []string{"NLRTM"}},
+		{"code", domain.PortFilter{Codes: []string{"52000"}}, []string{"AEAJM"}},
+		{"any unloc", domain.PortFilter{Unlocs: []string{"aejea"}}, []string{"AEDXB", "AEJEA"}},
+		{"port listed under several unlocs once", domain.PortFilter{Unlocs: []string{"AEDXB", "AEJEA"}}, []string{"AEDXB", "AEJEA"}},
+		{"region", domain.PortFilter{Regions: []string{"GULF"}}, []string{"AEAJM"}},
+		{"empty value matches missing field", domain.PortFilter{Provinces: []string{""}}, []string{"AEKLF"}},
+		{"empty among other values", domain.PortFilter{Timezones: []string{" ", "Europe/Amsterdam"}}, []string{"AEKLF", "NLRTM"}},
+		{"index and field criteria combine", domain.PortFilter{Unlocs: []string{"AEJEA"}, Codes: []string{"52051"}}, []string{"AEJEA"}},
+		{"criteria combine", domain.PortFilter{Countries: []string{"United Arab Emirates"}, Timezones: []string{"Europe/Amsterdam"}}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := repo.ListPorts(ctx, tt.filter, "", 10)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, PortIDs(page.Ports))
+		})
+	}
+
+	list := func(filter domain.PortFilter) []string {
+		page, err := repo.ListPorts(ctx, filter, "", 10)
+		assert.NoError(t, err)
+		return PortIDs(page.Ports)
+	}
+
+	// Filters follow updates and deletes
+	moved := rotterdam.Clone()
+	moved.Country = "Belgium"
+	assert.NoError(t, repo.SavePort(ctx, moved))
+	assert.Empty(t, list(domain.PortFilter{Countries: []string{"Netherlands"}}))
+	assert.Equal(t, []string{"NLRTM"}, list(domain.PortFilter{Countries: []string{"Belgium"}}))
+	assert.NoError(t, repo.DeletePort(ctx, "AEJEA"))
+	assert.Equal(t, []string{"AEDXB"}, list(domain.PortFilter{Unlocs: []string{"AEJEA"}}))
+
+	// Pagination applies to filtered results
+	filter := domain.PortFilter{Provinces: []string{"Ajman", "Dubai"}}
+	page, err := repo.ListPorts(ctx, filter, "", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"AEAJM"}, PortIDs(page.Ports))
+	page, err = repo.ListPorts(ctx, filter, page.NextCursor, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"AEDXB"}, PortIDs(page.Ports))
+	if page.NextCursor != "" {
+		page, err = repo.ListPorts(ctx, filter, page.NextCursor, 1)
+		assert.NoError(t, err)
+		assert.Empty(t, page.Ports)
+		assert.Empty(t, page.NextCursor)
+	}
+}
+
+func testStatistics(t *testing.T, open Opener) {
+	repo := open(false)
+	ctx := context.Background()
+
+	stats := repo.GetStatistics()
+	assert.Zero(t, stats.TotalPorts)
+	assert.Zero(t, stats.TotalUpdates)
+	assert.Zero(t, stats.TotalDeletes)
+
+	assert.NoError(t, repo.SavePort(ctx, NewPort("AEAJM", "Ajman")))
+	assert.NoError(t, repo.SavePort(ctx, NewPort("AEAJM", "Ajman Port")))
+	assert.NoError(t, repo.SavePort(ctx, NewPort("AEAUH", "Abu Dhabi")))
+	stats = repo.GetStatistics()
+	assert.Equal(t, int64(2), stats.TotalPorts)
+	assert.Equal(t, int64(3), stats.TotalUpdates)
+
+	assert.NoError(t, repo.DeletePort(ctx, "AEAUH"))
+	stats = repo.GetStatistics()
+	assert.Equal(t, int64(1), stats.TotalPorts)
+	assert.Equal(t, int64(3), stats.TotalUpdates)
+	assert.Equal(t, int64(1), stats.TotalDeletes)
+	assert.NotEmpty(t, stats.LastUpdate)
+}
+
+func testTransaction(t *testing.T, open Opener) {
+	repo := open(false)
+	ctx := context.Background()
+
+	tx, err := repo.BeginTx(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.SavePort(ctx, NewPort("AEAJM", "Ajman")))
+	assert.NoError(t, tx.SavePort(ctx, NewPort("AEAUH", "Abu Dhabi")))
+	assert.NoError(t, tx.SavePort(ctx, NewPort("AEAJM", "Ajman Port")))
+	assert.Error(t, tx.SavePort(ctx, &domain.Port{ID: "INVALID"}))
+	result, err := tx.SavePorts(ctx, []*domain.Port{NewPort("AEDXB", "Dubai"), {ID: "INVALID"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Saved())
+	assert.Equal(t, 1, result.Failed())
+
+	// Staged ports are invisible until committed
+	port, err := repo.GetPort(ctx, "AEAJM")
+	assert.NoError(t, err)
+	assert.Nil(t, port)
+
+	// Later writes to the same ID win
+	assert.NoError(t, tx.Commit(ctx))
+	port, err = repo.GetPort(ctx, "AEAJM")
+	assert.NoError(t, err)
+	if assert.NotNil(t, port) {
+		assert.Equal(t, "Ajman Port", port.Name)
+	}
+	page, err := repo.ListPorts(ctx, domain.PortFilter{}, "", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"AEAJM", "AEAUH", "AEDXB"}, PortIDs(page.Ports))
+	stats := repo.GetStatistics()
+	assert.Equal(t, int64(3), stats.TotalPorts)
+	assert.Equal(t, int64(4), stats.TotalUpdates)
+
+	assert.ErrorIs(t, tx.Commit(ctx), out.ErrTxDone)
+	assert.ErrorIs(t, tx.SavePort(ctx, NewPort("NLRTM", "Rotterdam")), out.ErrTxDone)
+	assert.ErrorIs(t, tx.Rollback(ctx), out.ErrTxDone)
+
+	// Rolled back ports are never stored
+	tx, err = repo.BeginTx(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.SavePort(ctx, NewPort("NLRTM", "Rotterdam")))
+	assert.NoError(t, tx.Rollback(ctx))
+	assert.ErrorIs(t, tx.Commit(ctx), out.ErrTxDone)
+	port, err = repo.GetPort(ctx, "NLRTM")
+	assert.NoError(t, err)
+	assert.Nil(t, port)
+	assert.Equal(t, int64(4), repo.GetStatistics().TotalUpdates)
+}
+
+func testContextCancellation(t *testing.T, open Opener) {
+	repo := open(false)
+	ctx := context.Background()
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	assert.NoError(t, repo.SavePort(ctx, NewPort("AEAJM", "Ajman")))
+	assert.ErrorIs(t, repo.SavePort(canceled, NewPort("AEAUH", "Abu Dhabi")), context.Canceled)
+	_, err := repo.SavePorts(canceled, []*domain.Port{NewPort("AEAUH", "Abu Dhabi")})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, repo.DeletePort(canceled, "AEAJM"), context.Canceled)
+	_, err = repo.BeginTx(canceled)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// A canceled commit stores nothing and leaves the transaction open
+	tx, err := repo.BeginTx(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.SavePort(ctx, NewPort("AEDXB", "Dubai")))
+	assert.ErrorIs(t, tx.Commit(canceled), context.Canceled)
+	assert.NoError(t, tx.Rollback(ctx))
+
+	page, err := repo.ListPorts(ctx, domain.PortFilter{}, "", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"AEAJM"}, PortIDs(page.Ports))
+	assert.Equal(t, int64(1), repo.GetStatistics().TotalUpdates)
+}
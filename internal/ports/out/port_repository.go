@@ -10,6 +10,7 @@ import (
 type RepositoryStats struct {
 	TotalPorts   int64
 	TotalUpdates int64
+	TotalDeletes int64
 	LastUpdate   string
 }
 
@@ -18,9 +19,30 @@ type PortRepository interface {
 	// SavePort saves or updates a port in the repository
 	SavePort(ctx context.Context, port *domain.Port) error
 
+	// SavePorts saves or updates a batch of ports in one operation. Ports
+	// that fail validation are reported in the result and skipped while the
+	// rest are saved; the error is reserved for failures of the whole batch.
+	SavePorts(ctx context.Context, ports []*domain.Port) (domain.BatchResult, error)
+
 	// GetPort retrieves a port by its ID
+	// Soft-deleted ports are not returned
 	GetPort(ctx context.Context, id string) (*domain.Port, error)
 
+	// GetPortIncludingDeleted retrieves a port by its ID, including soft-deleted ports
+	GetPortIncludingDeleted(ctx context.Context, id string) (*domain.Port, error)
+
+	// DeletePort removes a port from the repository, returning
+	// domain.ErrPortNotFound if it does not exist
+	DeletePort(ctx context.Context, id string) error
+
+	// ListPorts returns up to limit ports matching the filter, ordered by
+	// port ID and starting after the position encoded in cursor
+	ListPorts(ctx context.Context, filter domain.PortFilter, cursor string, limit int) (domain.PortPage, error)
+
+	// BeginTx starts a transaction whose staged writes are applied
+	// atomically on Commit and discarded on Rollback
+	BeginTx(ctx context.Context) (PortTx, error)
+
 	// Close closes the repository and frees any resources
 	Close(ctx context.Context) error
 
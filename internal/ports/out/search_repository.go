@@ -0,0 +1,16 @@
+package out
+
+import (
+	"context"
+
+	"portservice/internal/domain"
+)
+
+// SearchRepository is implemented by repositories that maintain a full-text
+// index over port names and places. Services fall back to scanning ListPorts
+// for repositories that don't implement it.
+type SearchRepository interface {
+	// SearchPorts returns up to limit live ports matching the text query,
+	// most relevant first, scored with domain.ScoreSearch
+	SearchPorts(ctx context.Context, query string, limit int) ([]domain.SearchResult, error)
+}
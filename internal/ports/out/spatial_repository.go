@@ -0,0 +1,29 @@
+package out
+
+import (
+	"context"
+
+	"portservice/internal/domain"
+)
+
+// SpatialRepository is implemented by repositories that maintain a spatial
+// index over port coordinates. Services fall back to scanning ListPorts for
+// repositories that don't implement it.
+type SpatialRepository interface {
+	// FindNearest returns the k live ports closest to center, nearest first
+	FindNearest(ctx context.Context, center domain.Coordinate, k int) ([]domain.PortDistance, error)
+
+	// FindWithinRadius returns live ports within radiusKm of center, nearest first
+	FindWithinRadius(ctx context.Context, center domain.Coordinate, radiusKm float64) ([]domain.PortDistance, error)
+
+	// FindInBoundingBox returns live ports inside the box, ordered by port ID
+	FindInBoundingBox(ctx context.Context, box domain.BoundingBox) ([]*domain.Port, error)
+}
+
+// PolygonRepository is implemented by spatial repositories that can test
+// polygon containment in their index. Services fall back to filtering the
+// polygon's bounding box for repositories that don't implement it.
+type PolygonRepository interface {
+	// FindInPolygon returns live ports inside the polygon, ordered by port ID
+	FindInPolygon(ctx context.Context, polygon domain.Polygon) ([]*domain.Port, error)
+}
@@ -0,0 +1,100 @@
+package out
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"portservice/internal/domain"
+)
+
+// ErrTxDone is returned when a transaction is used after Commit or Rollback
+var ErrTxDone = errors.New("transaction has already been committed or rolled back")
+
+// PortTx stages port writes that become visible together when committed
+type PortTx interface {
+	// SavePort stages a port to be saved or updated on Commit
+	SavePort(ctx context.Context, port *domain.Port) error
+
+	// SavePorts stages a batch of ports to be saved or updated on Commit,
+	// reporting invalid ports in the result like PortRepository.SavePorts
+	SavePorts(ctx context.Context, ports []*domain.Port) (domain.BatchResult, error)
+
+	// Commit applies every staged write atomically
+	Commit(ctx context.Context) error
+
+	// Rollback discards the staged writes
+	Rollback(ctx context.Context) error
+}
+
+// stagedTx implements PortTx by buffering copies of the staged ports and
+// passing them to store on Commit
+type stagedTx struct {
+	store func(ctx context.Context, ports []*domain.Port) error
+
+	mu     sync.Mutex
+	staged []*domain.Port
+	done   bool
+}
+
+// NewStagedTx returns a PortTx for repositories that apply a batch of
+// ports atomically. store is called once on Commit with every staged port
+// in order, so later writes to the same ID win, and must save all of them
+// or none.
+func NewStagedTx(store func(ctx context.Context, ports []*domain.Port) error) PortTx {
+	return &stagedTx{store: store}
+}
+
+// SavePort stages a copy of the port to be saved on Commit
+func (tx *stagedTx) SavePort(ctx context.Context, port *domain.Port) error {
+	result, err := tx.SavePorts(ctx, []*domain.Port{port})
+	if err != nil {
+		return err
+	}
+	return result.FirstError()
+}
+
+// SavePorts stages copies of the valid ports to be saved on Commit
+func (tx *stagedTx) SavePorts(ctx context.Context, ports []*domain.Port) (domain.BatchResult, error) {
+	if ctx.Err() != nil {
+		return domain.BatchResult{}, ctx.Err()
+	}
+	stored, result := domain.PrepareBatch(ports)
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.done {
+		return domain.BatchResult{}, ErrTxDone
+	}
+	tx.staged = append(tx.staged, stored...)
+	return result, nil
+}
+
+// Commit stores every staged port
+func (tx *stagedTx) Commit(ctx context.Context) error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.done {
+		return ErrTxDone
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	tx.done = true
+
+	err := tx.store(ctx, tx.staged)
+	tx.staged = nil
+	return err
+}
+
+// Rollback discards the staged ports
+func (tx *stagedTx) Rollback(ctx context.Context) error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+	tx.staged = nil
+	return nil
+}
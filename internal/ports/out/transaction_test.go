@@ -0,0 +1,95 @@
+package out_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"portservice/internal/domain"
+	"portservice/internal/ports/out"
+	"portservice/internal/ports/out/outtest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingStore returns a store function that records the batches passed
+// to it and fails with err
+func recordingStore(batches *[][]*domain.Port, err error) func(context.Context, []*domain.Port) error {
+	return func(ctx context.Context, ports []*domain.Port) error {
+		*batches = append(*batches, ports)
+		return err
+	}
+}
+
+func TestStagedTx_Commit(t *testing.T) {
+	var batches [][]*domain.Port
+	tx := out.NewStagedTx(recordingStore(&batches, nil))
+	ctx := context.Background()
+
+	ajman := outtest.NewPort("AEAJM", "Ajman")
+	assert.NoError(t, tx.SavePort(ctx, ajman))
+	assert.NoError(t, tx.SavePort(ctx, outtest.NewPort("AEAUH", "Abu Dhabi")))
+	result, err := tx.SavePorts(ctx, []*domain.Port{outtest.NewPort("AEAJM", "Ajman Port"), {ID: "INVALID"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Saved())
+	assert.Equal(t, 1, result.Failed())
+	assert.Error(t, tx.SavePort(ctx, &domain.Port{ID: "INVALID"}))
+
+	// Staged ports are copies, unaffected by later changes
+	ajman.Name = "Modified"
+
+	// Nothing is stored until Commit, which stores every valid port in order
+	assert.Empty(t, batches)
+	assert.NoError(t, tx.Commit(ctx))
+	if assert.Len(t, batches, 1) && assert.Len(t, batches[0], 3) {
+		assert.Equal(t, "Ajman", batches[0][0].Name)
+		assert.Equal(t, "AEAUH", batches[0][1].ID)
+		assert.Equal(t, "Ajman Port", batches[0][2].Name)
+	}
+
+	assert.ErrorIs(t, tx.Commit(ctx), out.ErrTxDone)
+	assert.ErrorIs(t, tx.SavePort(ctx, outtest.NewPort("AEDXB", "Dubai")), out.ErrTxDone)
+	assert.ErrorIs(t, tx.Rollback(ctx), out.ErrTxDone)
+	assert.Len(t, batches, 1)
+}
+
+func TestStagedTx_CommitError(t *testing.T) {
+	storeErr := errors.New("disk full")
+	var batches [][]*domain.Port
+	tx := out.NewStagedTx(recordingStore(&batches, storeErr))
+	ctx := context.Background()
+
+	assert.NoError(t, tx.SavePort(ctx, outtest.NewPort("AEAJM", "Ajman")))
+	assert.ErrorIs(t, tx.Commit(ctx), storeErr)
+
+	// A failed commit ends the transaction
+	assert.ErrorIs(t, tx.Commit(ctx), out.ErrTxDone)
+	assert.Len(t, batches, 1)
+}
+
+func TestStagedTx_Canceled(t *testing.T) {
+	var batches [][]*domain.Port
+	tx := out.NewStagedTx(recordingStore(&batches, nil))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	assert.NoError(t, tx.SavePort(ctx, outtest.NewPort("AEAJM", "Ajman")))
+	cancel()
+	assert.ErrorIs(t, tx.SavePort(ctx, outtest.NewPort("AEAUH", "Abu Dhabi")), context.Canceled)
+
+	// A canceled commit stores nothing and leaves the transaction open
+	assert.ErrorIs(t, tx.Commit(ctx), context.Canceled)
+	assert.Empty(t, batches)
+	assert.NoError(t, tx.Rollback(context.Background()))
+}
+
+func TestStagedTx_Rollback(t *testing.T) {
+	var batches [][]*domain.Port
+	tx := out.NewStagedTx(recordingStore(&batches, nil))
+	ctx := context.Background()
+
+	assert.NoError(t, tx.SavePort(ctx, outtest.NewPort("AEAJM", "Ajman")))
+	assert.NoError(t, tx.Rollback(ctx))
+	assert.ErrorIs(t, tx.Commit(ctx), out.ErrTxDone)
+	assert.ErrorIs(t, tx.Rollback(ctx), out.ErrTxDone)
+	assert.Empty(t, batches)
+}